@@ -1,11 +1,10 @@
 package main
 
 import (
-	"bufio"
 	_ "embed"
-	"errors"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"net"
 	"os"
 	"runtime"
 	"strings"
@@ -14,23 +13,100 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/getlantern/systray"
 
+	"radio-tui/internal/bookmarks"
 	"radio-tui/internal/config"
+	"radio-tui/internal/ctl"
+	"radio-tui/internal/favorites"
+	"radio-tui/internal/history"
 	"radio-tui/internal/ipc"
+	"radio-tui/internal/launch"
 	"radio-tui/internal/player"
 	"radio-tui/internal/radio"
 	"radio-tui/internal/ui"
 )
 
-const (
-	cmdPlayPause = "PLAY_PAUSE"
-	cmdNext      = "NEXT"
-	cmdPrev      = "PREV"
-	cmdQuit      = "QUIT"
-	cmdStatus    = "STATUS"
-)
+// attached is set when the tray joined a session owned by another process,
+// which must keep playing after the tray exits.
+var attached bool
+
+// background is set by --background: the tray runs the playback engine
+// itself and opens a TUI only when asked.
+var background bool
+
+// engineDone is closed when the engine of a background tray has stopped.
+var engineDone chan struct{}
+
+// engineStopTimeout bounds waiting for the engine to shut down on exit.
+const engineStopTimeout = 2 * time.Second
+
+// startupPlay is the station given as "valvefm play <uuid|name>" when no
+// session was running to forward it to.
+var startupPlay *ipc.PlayArgs
 
 func main() {
-	systray.Run(onReady, onExit)
+	flags := flag.NewFlagSet("valvefm", flag.ExitOnError)
+	flags.BoolVar(&background, "background", false, "run the playback engine in the tray and open a TUI only when asked")
+	config.RegisterFlags(flags)
+	flags.Usage = func() { usage(flags) }
+	_ = flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	if len(args) == 0 {
+		systray.Run(onReady, onExit)
+		return
+	}
+	background = false // only the plain tray runs in the background
+	var err error
+	switch command := args[0]; {
+	case command == "daemon":
+		parseCommandFlags(command, args[1:])
+		err = runDaemon()
+	case command == "attach":
+		parseCommandFlags(command, args[1:])
+		err = runTUI(ui.ModeAttached)
+	case command == "ctl":
+		os.Exit(ctl.Run("valvefm ctl", args[1:], os.Stdout, os.Stderr))
+	case command == "favorites":
+		os.Exit(favorites.Run("valvefm favorites", args[1:], os.Stdin, os.Stdout, os.Stderr))
+	case command == "config":
+		os.Exit(config.Run("valvefm config", args[1:], os.Stdout, os.Stderr))
+	case command == "history":
+		os.Exit(history.Run("valvefm history", args[1:], os.Stdout, os.Stderr))
+	case command == "bookmarks":
+		os.Exit(bookmarks.Run("valvefm bookmarks", args[1:], os.Stdout, os.Stderr))
+	case command == "play" && ipc.Ping() != nil:
+		// Nothing to forward to: start a session that plays it.
+		target := ctl.PlayTarget(args[1:])
+		startupPlay = &target
+		systray.Run(onReady, onExit)
+		return
+	case ctl.IsCommand(command):
+		// A session is running; hand the command over and exit.
+		os.Exit(ctl.Run("valvefm", args, os.Stdout, os.Stderr))
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "usage: %s [flags] [daemon|attach|ctl|favorites|config|history|bookmarks|<ctl command>]\n\nflags:\n", os.Args[0])
+	flags.PrintDefaults()
+}
+
+// parseCommandFlags takes config flags given after daemon or attach.
+func parseCommandFlags(command string, args []string) {
+	flags := flag.NewFlagSet("valvefm "+command, flag.ExitOnError)
+	config.RegisterFlags(flags)
+	_ = flags.Parse(args)
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "valvefm %s: unexpected argument %q\n", command, flags.Arg(0))
+		os.Exit(2)
+	}
 }
 
 func onReady() {
@@ -38,66 +114,195 @@ func onReady() {
 		systray.SetIcon(icon)
 	}
 	systray.SetTooltip("Valve FM")
+	menu := newTrayMenu()
 
-	mPlayPause := systray.AddMenuItem("Play/Pause", "Toggle playback")
-	mNext := systray.AddMenuItem("Next", "Next station")
-	mPrev := systray.AddMenuItem("Previous", "Previous station")
-	systray.AddSeparator()
-	mQuit := systray.AddMenuItem("Quit", "Quit Valve FM")
+	attached = ipc.Ping() == nil
+	switch {
+	case background && !attached:
+		engineDone = make(chan struct{})
+		go runEngine()
+	case !background:
+		mode := ui.ModeStandalone
+		if attached {
+			mode = ui.ModeAttached
+		}
+		go func() {
+			if err := runTUI(mode); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+			}
+			systray.Quit()
+		}()
+	}
 
 	go func() {
-		if err := runTUI(); err != nil {
-			fmt.Fprintln(os.Stderr, "error:", err)
+		for range menu.openTUI.ClickedCh {
+			if err := openTUI(); err != nil {
+				fmt.Fprintln(os.Stderr, "open TUI:", err)
+			}
 		}
-		systray.Quit()
 	}()
 
+	for item, cmd := range map[*systray.MenuItem]string{
+		menu.playPause: ipc.CmdPlayPause,
+		menu.next:      ipc.CmdNext,
+		menu.prev:      ipc.CmdPrev,
+		menu.mute:      ipc.CmdMute,
+		menu.bookmark:  ipc.CmdBookmark,
+	} {
+		go func() {
+			for range item.ClickedCh {
+				_ = ipc.Call(cmd, nil, nil)
+			}
+		}()
+	}
+	if attached {
+		menu.quit.SetTooltip("Close the tray; the session keeps playing")
+	}
 	go func() {
-		for range mPlayPause.ClickedCh {
-			_, _ = sendCommand(cmdPlayPause)
+		// onExit stops the session only if the tray started it.
+		for range menu.quit.ClickedCh {
+			systray.Quit()
 		}
 	}()
-	go func() {
-		for range mNext.ClickedCh {
-			_, _ = sendCommand(cmdNext)
+
+	go watchStatus(menu)
+}
+
+// watchStatus keeps the menu and tooltip current from pushed events,
+// resubscribing whenever the connection drops. The tooltip itself ticks
+// locally so the sleep countdown moves without asking the TUI.
+func watchStatus(menu *trayMenu) {
+	for {
+		sub, err := ipc.Subscribe(ipc.EventStation, ipc.EventPlayState, ipc.EventTitle, ipc.EventTimers, ipc.EventFavorites, ipc.EventVolume)
+		if err != nil {
+			menu.setConnected(false)
+			time.Sleep(trayRetryInterval)
+			continue
 		}
-	}()
-	go func() {
-		for range mPrev.ClickedCh {
-			_, _ = sendCommand(cmdPrev)
+
+		var status ipc.Status
+		var sleepAt time.Time
+		refreshFavorites := func() {
+			var favorites []ipc.Station
+			if err := ipc.Call(ipc.CmdFavorites, nil, &favorites); err == nil {
+				menu.favorites.set(favorites)
+			}
 		}
-	}()
-	go func() {
-		for range mQuit.ClickedCh {
-			_, _ = sendCommand(cmdQuit)
-			systray.Quit()
+		refreshRecent := func() {
+			var recent []ipc.Station
+			if err := ipc.Call(ipc.CmdRecent, nil, &recent); err == nil {
+				menu.recent.set(recent)
+			}
 		}
-	}()
+		resync := func() {
+			if err := ipc.Call(ipc.CmdStatus, nil, &status); err == nil {
+				sleepAt = sleepDeadline(status)
+			}
+			refreshFavorites()
+			refreshRecent()
+		}
+		resync()
+		menu.setConnected(true)
+		menu.setStatus(status)
 
-	go func() {
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			status, err := sendCommand(cmdStatus)
-			if err != nil {
-				systray.SetTooltip("Valve FM (disconnected)")
-				mPlayPause.Disable()
-				mNext.Disable()
-				mPrev.Disable()
-				mQuit.Disable()
-				continue
+		ticker := time.NewTicker(time.Second)
+		for connected := true; connected; {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					connected = false
+					break
+				}
+				switch event.Event {
+				case ipc.EventTitle:
+					var title ipc.TitleEvent
+					if json.Unmarshal(event.Data, &title) == nil {
+						status.Title = title.Title
+					}
+				case ipc.EventFavorites:
+					refreshFavorites()
+				case ipc.EventVolume:
+					var volume ipc.VolumeStatus
+					if json.Unmarshal(event.Data, &volume) == nil {
+						status.Volume, status.Muted = volume.Level, volume.Muted
+					}
+				case ipc.EventDropped:
+					resync()
+				case ipc.EventStation:
+					refreshRecent()
+					fallthrough
+				default:
+					if json.Unmarshal(event.Data, &status) == nil {
+						sleepAt = sleepDeadline(status)
+					}
+				}
+				menu.setStatus(status)
+			case <-ticker.C:
 			}
-			systray.SetTooltip("Valve FM " + status)
-			mPlayPause.Enable()
-			mNext.Enable()
-			mPrev.Enable()
-			mQuit.Enable()
+			if !sleepAt.IsZero() {
+				status.SleepRemaining = max(0, int(time.Until(sleepAt).Seconds()))
+			}
+			systray.SetTooltip(statusTooltip(status))
 		}
-	}()
+		ticker.Stop()
+		sub.Close()
+		menu.setConnected(false)
+	}
+}
+
+// trayRetryInterval is how long the tray waits before resubscribing.
+const trayRetryInterval = 2 * time.Second
+
+// sleepDeadline turns a status's remaining sleep time into a wall clock time.
+func sleepDeadline(status ipc.Status) time.Time {
+	if status.SleepRemaining <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(status.SleepRemaining) * time.Second)
 }
 
 func onExit() {
-	_, _ = sendCommand(cmdQuit)
+	if attached {
+		return
+	}
+	_ = ipc.Call(ipc.CmdQuit, nil, nil)
+	if engineDone != nil {
+		select {
+		case <-engineDone:
+		case <-time.After(engineStopTimeout):
+		}
+	}
+}
+
+// runEngine plays headless inside a background tray, as the daemon would,
+// and takes the tray down with it when it is told to quit.
+func runEngine() {
+	model, err := newModel(ui.ModeDaemon)
+	if err == nil {
+		err = ui.RunDaemon(model)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	close(engineDone)
+	systray.Quit()
+}
+
+// openTUI starts "valvefm attach" in a new terminal window.
+func openTUI() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd, err := launch.Terminal(exe, append(config.Args(), "attach")...)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
 }
 
 //go:embed assets/icon.png
@@ -113,51 +318,71 @@ func trayIcon() []byte {
 	return iconPNG
 }
 
-func runTUI() error {
-	api, err := radio.NewClient("ValveFM/1.0 (terminal radio)")
+func runTUI(mode ui.Mode) error {
+	model, err := newModel(mode)
 	if err != nil {
 		return err
 	}
-
-	playerInstance, playerErr := player.New()
-	favorites, favErr := config.LoadFavorites()
-	cfg := config.LoadConfig()
-
-	model := ui.NewModel(api, playerInstance, favorites, playerErr, favErr, cfg.Theme)
 	program := tea.NewProgram(model, tea.WithAltScreen())
 	_, err = program.Run()
 	return err
 }
 
-func sendCommand(command string) (string, error) {
-	ep, err := ipc.ResolveEndpoint()
+// runDaemon plays headless so that TUIs and the tray can attach to and
+// detach from the session.
+func runDaemon() error {
+	if err := ipc.Ping(); err == nil {
+		return ipc.ErrAlreadyRunning
+	}
+	model, err := newModel(ui.ModeDaemon)
 	if err != nil {
-		return "", err
+		return err
 	}
+	return ui.RunDaemon(model)
+}
 
-	conn, err := net.DialTimeout(ep.Network, ep.Address, 500*time.Millisecond)
+func newModel(mode ui.Mode) (ui.Model, error) {
+	cfg := config.LoadConfig()
+	api, err := radio.NewClientAt("ValveFM/1.0 (terminal radio)", cfg.API.Mirror)
 	if err != nil {
-		return "", err
+		return ui.Model{}, err
 	}
-	defer conn.Close()
 
-	_, err = fmt.Fprintln(conn, command)
-	if err != nil {
-		return "", err
+	// An attached TUI leaves the audio to the daemon.
+	var (
+		playerInstance player.Backend
+		playerErr      error
+	)
+	if mode != ui.ModeAttached {
+		_ = ui.ApplyAudioConfig(cfg.Audio)
+		playerInstance, playerErr = player.NewWith(cfg.Player.Backend, cfg.Player.MPVPath)
+	}
+	favorites, favErr := config.LoadFavorites()
+
+	model := ui.NewModel(api, playerInstance, favorites, playerErr, favErr, cfg).WithMode(mode)
+	if startupPlay != nil {
+		model = model.WithStartupPlay(*startupPlay)
 	}
+	return model, nil
+}
 
-	_ = conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-	reader := bufio.NewReader(conn)
-	line, err := reader.ReadString('\n')
-	if err != nil {
-		return "", err
+// statusTooltip renders a STATUS reply as a short tooltip, counting down
+// the sleep timer and showing the next alarm.
+func statusTooltip(status ipc.Status) string {
+	state := "stopped"
+	if status.Playing {
+		state = "playing"
+	}
+	parts := []string{fmt.Sprintf("Valve FM - %s (%s)", status.Station, state)}
+	if status.Title != "" {
+		parts = append(parts, status.Title)
 	}
-	line = strings.TrimSpace(line)
-	if strings.HasPrefix(line, "ERR ") {
-		return "", errors.New(strings.TrimPrefix(line, "ERR "))
+	if status.SleepRemaining > 0 {
+		remaining := time.Duration(status.SleepRemaining) * time.Second
+		parts = append(parts, fmt.Sprintf("Sleep in %dm%02ds", int(remaining.Minutes()), int(remaining.Seconds())%60))
 	}
-	if line == "OK" {
-		return "", nil
+	if status.NextAlarm != "" {
+		parts = append(parts, "Alarm "+status.NextAlarm)
 	}
-	return line, nil
+	return strings.Join(parts, " | ")
 }
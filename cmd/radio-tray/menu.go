@@ -0,0 +1,174 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/getlantern/systray"
+
+	"radio-tui/internal/ipc"
+)
+
+const (
+	// trayFavoriteSlots caps the favorites submenu.
+	trayFavoriteSlots = 30
+	// trayRecentSlots matches the number of stations a session remembers.
+	trayRecentSlots = 10
+)
+
+// trayMenu holds the tray's menu items.
+type trayMenu struct {
+	openTUI    *systray.MenuItem
+	nowPlaying *systray.MenuItem
+	playPause  *systray.MenuItem
+	next       *systray.MenuItem
+	prev       *systray.MenuItem
+	mute       *systray.MenuItem
+	bookmark   *systray.MenuItem
+	favorites  *stationMenu
+	recent     *stationMenu
+	quit       *systray.MenuItem
+}
+
+func newTrayMenu() *trayMenu {
+	m := &trayMenu{}
+	m.openTUI = systray.AddMenuItem("Open TUI", "Open the player in a terminal")
+	m.nowPlaying = systray.AddMenuItem("Not playing", "")
+	m.nowPlaying.Disable()
+	systray.AddSeparator()
+	m.playPause = systray.AddMenuItem("Play/Pause", "Toggle playback")
+	m.next = systray.AddMenuItem("Next", "Next station")
+	m.prev = systray.AddMenuItem("Previous", "Previous station")
+	m.mute = systray.AddMenuItemCheckbox("Mute", "Mute or unmute", false)
+	m.bookmark = systray.AddMenuItem("Bookmark song", "Save the song playing to the bookmarks")
+	m.bookmark.Disable()
+	systray.AddSeparator()
+	m.favorites = newStationMenu("Favorites", "Play a favorite", trayFavoriteSlots)
+	m.recent = newStationMenu("Recent", "Play a recently played station", trayRecentSlots)
+	systray.AddSeparator()
+	m.quit = systray.AddMenuItem("Quit", "Quit Valve FM")
+	return m
+}
+
+// setConnected enables the controls while a session is reachable.
+func (m *trayMenu) setConnected(connected bool) {
+	for _, item := range []*systray.MenuItem{m.openTUI, m.playPause, m.next, m.prev, m.mute, m.favorites.parent, m.recent.parent, m.quit} {
+		if connected {
+			item.Enable()
+		} else {
+			item.Disable()
+		}
+	}
+	if !connected {
+		m.bookmark.Disable()
+		m.nowPlaying.SetTitle("Valve FM is not running")
+		systray.SetTooltip("Valve FM (disconnected)")
+	}
+}
+
+// setStatus shows what is playing and checks the active station.
+func (m *trayMenu) setStatus(status ipc.Status) {
+	m.nowPlaying.SetTitle(nowPlayingLabel(status))
+	setChecked(m.mute, status.Muted)
+	// Only a song title can be bookmarked.
+	if status.Playing && status.Title != "" {
+		m.bookmark.Enable()
+	} else {
+		m.bookmark.Disable()
+	}
+	active := ""
+	if status.Playing {
+		active = status.UUID
+	}
+	m.favorites.mark(active)
+	m.recent.mark(active)
+}
+
+// nowPlayingLabel renders the disabled first menu item.
+func nowPlayingLabel(status ipc.Status) string {
+	if !status.Playing {
+		return "Not playing"
+	}
+	label := "Now playing: " + status.Station
+	if status.Title != "" {
+		label += " - " + status.Title
+	}
+	return label
+}
+
+// stationMenu is a submenu of stations that play when clicked. systray
+// cannot remove items, so it keeps a fixed number of slots and hides the
+// ones it does not need.
+type stationMenu struct {
+	parent *systray.MenuItem
+	empty  *systray.MenuItem
+	items  []*systray.MenuItem
+
+	mu     sync.Mutex
+	uuids  []string
+	active string
+}
+
+func newStationMenu(title, tooltip string, slots int) *stationMenu {
+	menu := &stationMenu{parent: systray.AddMenuItem(title, tooltip), uuids: make([]string, slots)}
+	menu.empty = menu.parent.AddSubMenuItem("None", "")
+	menu.empty.Disable()
+	for i := range slots {
+		item := menu.parent.AddSubMenuItemCheckbox("", "", false)
+		item.Hide()
+		menu.items = append(menu.items, item)
+		go func() {
+			for range item.ClickedCh {
+				if uuid := menu.uuid(i); uuid != "" {
+					_ = ipc.Call(ipc.CmdPlay, ipc.PlayArgs{UUID: uuid}, nil)
+				}
+			}
+		}()
+	}
+	return menu
+}
+
+func (s *stationMenu) uuid(slot int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uuids[slot]
+}
+
+// set fills the slots with stations; any beyond the last slot are left out.
+func (s *stationMenu) set(stations []ipc.Station) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, item := range s.items {
+		if i >= len(stations) {
+			s.uuids[i] = ""
+			item.Hide()
+			continue
+		}
+		s.uuids[i] = stations[i].UUID
+		item.SetTitle(stations[i].Name)
+		setChecked(item, stations[i].UUID == s.active)
+		item.Show()
+	}
+	if len(stations) == 0 {
+		s.empty.Show()
+	} else {
+		s.empty.Hide()
+	}
+}
+
+// mark checks the slot of the playing station, if it is listed.
+func (s *stationMenu) mark(active string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = active
+	for i, item := range s.items {
+		setChecked(item, active != "" && s.uuids[i] == active)
+	}
+}
+
+func setChecked(item *systray.MenuItem, checked bool) {
+	if checked {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -13,17 +14,22 @@ import (
 )
 
 func main() {
-	api, err := radio.NewClient("ValveFM/1.0 (terminal radio)")
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	cfg := config.LoadConfig()
+
+	api, err := radio.NewClientAt("ValveFM/1.0 (terminal radio)", cfg.API.Mirror)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "radio api error:", err)
 		os.Exit(1)
 	}
 
-	playerInstance, playerErr := player.New()
+	_ = ui.ApplyAudioConfig(cfg.Audio)
+
+	playerInstance, playerErr := player.NewWith(cfg.Player.Backend, cfg.Player.MPVPath)
 	favorites, favErr := config.LoadFavorites()
-	cfg := config.LoadConfig()
 
-	model := ui.NewModel(api, playerInstance, favorites, playerErr, favErr, cfg.Theme)
+	model := ui.NewModel(api, playerInstance, favorites, playerErr, favErr, cfg)
 	program := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
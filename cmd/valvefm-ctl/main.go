@@ -0,0 +1,13 @@
+// Command valvefm-ctl controls a running valvefm from scripts, hotkeys and
+// status bars.
+package main
+
+import (
+	"os"
+
+	"radio-tui/internal/ctl"
+)
+
+func main() {
+	os.Exit(ctl.Run("valvefm-ctl", os.Args[1:], os.Stdout, os.Stderr))
+}
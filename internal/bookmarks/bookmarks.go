@@ -0,0 +1,217 @@
+// Package bookmarks keeps the songs saved while they played: the title the
+// station announced, with the station and stream it came from. They are
+// kept in bookmarks.json in the valvefm config directory and shared by
+// every valvefm process.
+package bookmarks
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"radio-tui/internal/config"
+)
+
+// Bookmark is one saved song.
+type Bookmark struct {
+	Title   string    `json:"title"`
+	Station string    `json:"station"`
+	UUID    string    `json:"uuid,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Matches reports whether the title or station contains query, ignoring
+// case. An empty query matches every bookmark.
+func (b Bookmark) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	return strings.Contains(strings.ToLower(b.Title), query) || strings.Contains(strings.ToLower(b.Station), query)
+}
+
+var (
+	// ErrNoTitle is returned when a song is bookmarked while the station
+	// announces no title.
+	ErrNoTitle = errors.New("the station sends no song title")
+	// ErrExists is returned when an export would replace a file.
+	ErrExists = errors.New("the file already exists")
+
+	errUnchanged = errors.New("bookmarks unchanged")
+)
+
+// fileVersion is the schema of bookmarks.json.
+const fileVersion = 1
+
+// Store is the list of saved songs in one bookmarks file.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	items []Bookmark // oldest first
+}
+
+type storedFile struct {
+	Version   int        `json:"version"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// Open returns the bookmarks in the valvefm config directory. Nothing is
+// read until Reload or a change.
+func Open() (*Store, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenFile(filepath.Join(configDir, "valvefm", "bookmarks.json")), nil
+}
+
+// OpenFile returns the bookmarks kept at path.
+func OpenFile(path string) *Store {
+	return &Store{path: path}
+}
+
+// reloadLocked replaces the bookmarks in memory with those in the file. A
+// missing file holds no bookmarks.
+func (s *Store) reloadLocked() error {
+	var stored storedFile
+	if err := config.ReadJSONFile(s.path, &stored); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		stored = storedFile{Version: fileVersion}
+	}
+	if stored.Version > fileVersion {
+		return fmt.Errorf("%s: bookmarks version %d is newer than this version of Valve FM supports", s.path, stored.Version)
+	}
+	s.items = stored.Bookmarks
+	return nil
+}
+
+// Reload re-reads the file to pick up bookmarks other processes saved.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return config.WithFileLock(s.path, s.reloadLocked)
+}
+
+// Add saves a bookmark and reports whether it is new: pressing the key
+// twice for the same song at the same station keeps one bookmark.
+func (s *Store) Add(bookmark Bookmark) (bool, error) {
+	bookmark.Title = strings.TrimSpace(bookmark.Title)
+	if bookmark.Title == "" {
+		return false, ErrNoTitle
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := false
+	err := s.updateLocked(func() error {
+		if n := len(s.items); n > 0 && s.items[n-1].Title == bookmark.Title && s.items[n-1].UUID == bookmark.UUID {
+			return errUnchanged
+		}
+		s.items = append(s.items, bookmark)
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// Remove deletes a bookmark, matched by its title, station and time.
+func (s *Store) Remove(bookmark Bookmark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateLocked(func() error {
+		i := slices.IndexFunc(s.items, func(item Bookmark) bool {
+			return item.Title == bookmark.Title && item.UUID == bookmark.UUID && item.Time.Equal(bookmark.Time)
+		})
+		if i < 0 {
+			return errUnchanged
+		}
+		s.items = slices.Delete(s.items, i, i+1)
+		return nil
+	})
+}
+
+// List returns the bookmarks, latest first.
+func (s *Store) List() []Bookmark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := slices.Clone(s.items)
+	slices.Reverse(list)
+	return list
+}
+
+// updateLocked applies change to the bookmarks as they are on disk and
+// saves them. The caller holds s.mu.
+func (s *Store) updateLocked(change func() error) error {
+	return config.WithFileLock(s.path, func() error {
+		if err := s.reloadLocked(); err != nil {
+			return err
+		}
+		if err := change(); err != nil {
+			if errors.Is(err, errUnchanged) {
+				return nil
+			}
+			return err
+		}
+		data, err := json.MarshalIndent(storedFile{Version: fileVersion, Bookmarks: s.items}, "", "  ")
+		if err != nil {
+			return err
+		}
+		return config.WriteFileAtomic(s.path, data)
+	})
+}
+
+// Export writes bookmarks to a new file at path as CSV or, for a .json
+// path, as JSON. An existing file is only replaced when overwrite is set;
+// otherwise Export returns ErrExists.
+func Export(path string, bookmarks []Bookmark, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s: %w", path, ErrExists)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = writeJSON(f, bookmarks)
+	} else {
+		err = writeCSV(f, bookmarks)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeJSON(w io.Writer, bookmarks []Bookmark) error {
+	if bookmarks == nil {
+		bookmarks = []Bookmark{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bookmarks)
+}
+
+func writeCSV(w io.Writer, bookmarks []Bookmark) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"time", "title", "station", "uuid", "url"}); err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if err := out.Write([]string{b.Time.Format(time.RFC3339), b.Title, b.Station, b.UUID, b.URL}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,141 @@
+package bookmarks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_AddListRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	bookmarks := OpenFile(path)
+	at := time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)
+
+	first := Bookmark{Title: "Artist - Song", Station: "Jazz FM", UUID: "1", URL: "http://jazz.example/stream", Time: at}
+	if added, err := bookmarks.Add(first); err != nil || !added {
+		t.Fatalf("Add() = %v, %v", added, err)
+	}
+	// The same song again is not saved twice.
+	again := first
+	again.Time = at.Add(time.Minute)
+	if added, err := bookmarks.Add(again); err != nil || added {
+		t.Errorf("Add() of the same song = %v, %v, want not added", added, err)
+	}
+	second := Bookmark{Title: "Other - Tune", Station: "Rock FM", UUID: "2", Time: at.Add(2 * time.Minute)}
+	if _, err := bookmarks.Add(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bookmarks.Add(Bookmark{Title: "  ", UUID: "3"}); !errors.Is(err, ErrNoTitle) {
+		t.Errorf("Add() without a title = %v, want ErrNoTitle", err)
+	}
+
+	// Another process sees both, latest first.
+	other := OpenFile(path)
+	if err := other.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	list := other.List()
+	if len(list) != 2 || list[0].UUID != "2" || list[1].URL != first.URL || !list[1].Time.Equal(at) {
+		t.Fatalf("List() = %+v", list)
+	}
+
+	if err := other.Remove(list[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := bookmarks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if list := bookmarks.List(); len(list) != 1 || list[0].UUID != "2" {
+		t.Errorf("List() after Remove() = %+v", list)
+	}
+}
+
+func TestBookmark_Matches(t *testing.T) {
+	b := Bookmark{Title: "Artist - Song", Station: "Jazz FM"}
+	for query, want := range map[string]bool{"": true, "song": true, "JAZZ": true, "rock": false} {
+		if got := b.Matches(query); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	bookmarks := []Bookmark{{Title: "Artist - Song, live", Station: "Jazz FM", UUID: "1", URL: "http://jazz.example/stream", Time: time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)}}
+
+	csvPath := filepath.Join(dir, "bookmarks.csv")
+	if err := Export(csvPath, bookmarks, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(csvPath)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][0] != "2026-10-19T21:30:00Z" || rows[1][1] != "Artist - Song, live" || rows[1][4] != "http://jazz.example/stream" {
+		t.Errorf("csv export = %q, %v", data, err)
+	}
+
+	jsonPath := filepath.Join(dir, "bookmarks.json")
+	if err := Export(jsonPath, bookmarks, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(jsonPath)
+	var exported []Bookmark
+	if err := json.Unmarshal(data, &exported); err != nil || len(exported) != 1 || exported[0].Station != "Jazz FM" {
+		t.Errorf("json export = %q, %v", data, err)
+	}
+
+	// An existing file is kept unless overwriting is asked for.
+	if err := Export(csvPath, nil, false); !errors.Is(err, ErrExists) {
+		t.Errorf("Export() over an existing file = %v, want ErrExists", err)
+	}
+	if data, _ := os.ReadFile(csvPath); !bytes.Contains(data, []byte("Jazz FM")) {
+		t.Errorf("the existing export was changed: %q", data)
+	}
+	if err := Export(csvPath, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(csvPath); bytes.Contains(data, []byte("Jazz FM")) {
+		t.Errorf("the export was not replaced: %q", data)
+	}
+}
+
+func TestRun_Export(t *testing.T) {
+	dir := t.TempDir()
+	store := OpenFile(filepath.Join(dir, "bookmarks.json"))
+	if _, err := store.Add(Bookmark{Title: "Artist - Song", Station: "Jazz FM", UUID: "1", Time: time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		c := &command{name: "valvefm bookmarks", open: func() (*Store, error) { return OpenFile(store.path), nil }, stdout: &stdout, stderr: &stderr}
+		return c.run(args), stdout.String(), stderr.String()
+	}
+
+	code, out, _ := run("export")
+	var exported []Bookmark
+	if err := json.Unmarshal([]byte(out), &exported); code != ExitOK || err != nil || len(exported) != 1 || exported[0].Title != "Artist - Song" {
+		t.Errorf("json export = %d, %q", code, out)
+	}
+
+	csvPath := filepath.Join(dir, "bookmarks.csv")
+	if code, _, errOut := run("export", "-o", csvPath); code != ExitOK {
+		t.Fatalf("csv export = %d, %s", code, errOut)
+	}
+	data, _ := os.ReadFile(csvPath)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][2] != "Jazz FM" {
+		t.Errorf("csv export = %q, %v", data, err)
+	}
+
+	if code, _, _ := run("export", "--format", "xml"); code != ExitUsage {
+		t.Errorf("export --format xml = %d, want %d", code, ExitUsage)
+	}
+	if code, _, _ := run("rename"); code != ExitUsage {
+		t.Errorf("unknown command = %d, want %d", code, ExitUsage)
+	}
+}
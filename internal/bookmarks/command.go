@@ -0,0 +1,122 @@
+package bookmarks
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Exit codes, as for ctl.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+type command struct {
+	name   string
+	open   func() (*Store, error)
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run executes "bookmarks <args>" and returns the exit code. name is the
+// program name shown in messages.
+func Run(name string, args []string, stdout, stderr io.Writer) int {
+	c := &command{name: name, open: Open, stdout: stdout, stderr: stderr}
+	return c.run(args)
+}
+
+func (c *command) run(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return ExitUsage
+	}
+	switch args[0] {
+	case "export":
+		return c.export(args[1:])
+	case "help", "-h", "--help":
+		c.usage()
+		return ExitOK
+	}
+	fmt.Fprintf(c.stderr, "%s: unknown command %q\n", c.name, args[0])
+	c.usage()
+	return ExitUsage
+}
+
+func (c *command) usage() {
+	fmt.Fprintf(c.stderr, `usage: %s <command> [flags]
+
+commands:
+  export [--format csv|json] [-o FILE]
+                   write the bookmarks, latest first
+
+The format defaults to the file extension, then to json.
+`, c.name)
+}
+
+func (c *command) fail(err error) int {
+	fmt.Fprintf(c.stderr, "%s: %v\n", c.name, err)
+	return ExitError
+}
+
+// load reads the bookmarks, latest first.
+func (c *command) load() ([]Bookmark, error) {
+	store, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store.List(), nil
+}
+
+func (c *command) export(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = c.usage
+	format := flags.String("format", "", "csv or json")
+	output := flags.String("o", "", "write to this file instead of standard output")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return ExitUsage
+	}
+	if *format == "" {
+		*format = "json"
+		if ext := strings.ToLower(filepath.Ext(*output)); ext == ".csv" {
+			*format = "csv"
+		}
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(c.stderr, "%s: cannot export %q; use csv or json\n", c.name, *format)
+		return ExitUsage
+	}
+
+	list, err := c.load()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	var buf bytes.Buffer
+	if *format == "csv" {
+		err = writeCSV(&buf, list)
+	} else {
+		err = writeJSON(&buf, list)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	if *output == "" {
+		_, err = c.stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alarm starts a favorite station at a local time of day, every day.
+type Alarm struct {
+	Time    string `json:"time"` // "HH:MM", 24-hour local time
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// ParseClock parses an "HH:MM" time of day.
+func ParseClock(value string) (int, int, error) {
+	hourText, minuteText, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time %q (want HH:MM)", value)
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour in %q", value)
+	}
+	minute, err := strconv.Atoi(minuteText)
+	if err != nil || minute < 0 || minute > 59 || len(minuteText) != 2 {
+		return 0, 0, fmt.Errorf("invalid minute in %q", value)
+	}
+	return hour, minute, nil
+}
+
+// FormatClock formats a time of day as "HH:MM".
+func FormatClock(hour, minute int) string {
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
+
+// Validate checks that the alarm has a parseable time and a station.
+func (a Alarm) Validate() error {
+	if _, _, err := ParseClock(a.Time); err != nil {
+		return err
+	}
+	if strings.TrimSpace(a.UUID) == "" {
+		return errors.New("alarm station is required")
+	}
+	return nil
+}
+
+// Next returns the first time strictly after the given instant at which the
+// alarm rings, in that instant's location.
+func (a Alarm) Next(after time.Time) (time.Time, error) {
+	hour, minute, err := ParseClock(a.Time)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := time.Date(after.Year(), after.Month(), after.Day(), hour, minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// Due reports whether an enabled alarm rang in the interval (from, to].
+func (a Alarm) Due(from, to time.Time) bool {
+	if !a.Enabled {
+		return false
+	}
+	next, err := a.Next(from)
+	if err != nil {
+		return false
+	}
+	return !next.After(to)
+}
+
+// NextAlarm returns the enabled alarm that rings first after the given
+// instant, together with when it rings.
+func NextAlarm(alarms []Alarm, after time.Time) (Alarm, time.Time, bool) {
+	var (
+		best     Alarm
+		bestTime time.Time
+		found    bool
+	)
+	for _, alarm := range alarms {
+		if !alarm.Enabled {
+			continue
+		}
+		next, err := alarm.Next(after)
+		if err != nil {
+			continue
+		}
+		if !found || next.Before(bestTime) {
+			best, bestTime, found = alarm, next, true
+		}
+	}
+	return best, bestTime, found
+}
+
+// SaveAlarms persists the alarm list to the config file,
+// preserving any other fields that may exist.
+func SaveAlarms(alarms []Alarm) error {
+	if alarms == nil {
+		alarms = []Alarm{}
+	}
+	return saveField("alarms", alarms)
+}
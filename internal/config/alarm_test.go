@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		input     string
+		hour, min int
+		wantErr   bool
+	}{
+		{"07:30", 7, 30, false},
+		{"7:05", 7, 5, false},
+		{" 23:59 ", 23, 59, false},
+		{"00:00", 0, 0, false},
+		{"24:00", 0, 0, true},
+		{"12:60", 0, 0, true},
+		{"12:5", 0, 0, true},
+		{"1230", 0, 0, true},
+		{"", 0, 0, true},
+		{"aa:bb", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			hour, min, err := ParseClock(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseClock(%q) should return error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseClock(%q) error = %v", tt.input, err)
+			}
+			if hour != tt.hour || min != tt.min {
+				t.Errorf("ParseClock(%q) = %d:%d, want %d:%d", tt.input, hour, min, tt.hour, tt.min)
+			}
+		})
+	}
+}
+
+func TestAlarm_Next(t *testing.T) {
+	alarm := Alarm{Time: "07:30", UUID: "a", Enabled: true}
+	loc := time.UTC
+
+	before := time.Date(2026, 3, 1, 6, 0, 0, 0, loc)
+	next, err := alarm.Next(before)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if want := time.Date(2026, 3, 1, 7, 30, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("Next() = %v, want %v", next, want)
+	}
+
+	// Exactly at the alarm time rolls over to the next day.
+	next, _ = alarm.Next(time.Date(2026, 3, 1, 7, 30, 0, 0, loc))
+	if want := time.Date(2026, 3, 2, 7, 30, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("Next() at alarm time = %v, want %v", next, want)
+	}
+}
+
+func TestAlarm_Due(t *testing.T) {
+	alarm := Alarm{Time: "07:30", UUID: "a", Enabled: true}
+	loc := time.UTC
+	from := time.Date(2026, 3, 1, 7, 29, 59, 0, loc)
+	to := time.Date(2026, 3, 1, 7, 30, 0, 0, loc)
+
+	if !alarm.Due(from, to) {
+		t.Error("Due() should be true when the alarm time falls in the interval")
+	}
+	if alarm.Due(to, to.Add(time.Second)) {
+		t.Error("Due() should be false once the alarm time has passed")
+	}
+
+	alarm.Enabled = false
+	if alarm.Due(from, to) {
+		t.Error("Due() should be false for disabled alarms")
+	}
+}
+
+func TestAlarm_Validate(t *testing.T) {
+	if err := (Alarm{Time: "07:30", UUID: "a"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (Alarm{Time: "07:30"}).Validate(); err == nil {
+		t.Error("Validate() should require a station")
+	}
+	if err := (Alarm{Time: "late", UUID: "a"}).Validate(); err == nil {
+		t.Error("Validate() should reject bad times")
+	}
+}
+
+func TestNextAlarm(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	alarms := []Alarm{
+		{Time: "07:00", UUID: "morning", Enabled: true},
+		{Time: "13:00", UUID: "disabled", Enabled: false},
+		{Time: "18:00", UUID: "evening", Enabled: true},
+	}
+
+	alarm, at, ok := NextAlarm(alarms, now)
+	if !ok {
+		t.Fatal("NextAlarm() should find an alarm")
+	}
+	if alarm.UUID != "evening" {
+		t.Errorf("NextAlarm() = %q, want evening", alarm.UUID)
+	}
+	if want := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC); !at.Equal(want) {
+		t.Errorf("NextAlarm() at = %v, want %v", at, want)
+	}
+
+	if _, _, ok := NextAlarm(nil, now); ok {
+		t.Error("NextAlarm(nil) should find nothing")
+	}
+}
+
+func TestAppConfig_AlarmsJSON(t *testing.T) {
+	data := []byte(`{"theme":"nord","alarms":[{"time":"06:45","uuid":"x","name":"Jazz","enabled":true}]}`)
+	var cfg AppConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(cfg.Alarms) != 1 || cfg.Alarms[0].Time != "06:45" || !cfg.Alarms[0].Enabled {
+		t.Errorf("Alarms = %+v, want one enabled 06:45 alarm", cfg.Alarms)
+	}
+}
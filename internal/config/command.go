@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes of Run, as for ctl.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// Run executes "config print|validate" and returns the exit code. name is
+// the program name shown in messages.
+func Run(name string, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		configUsage(name, stderr)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "print":
+		cfg, path, err := CheckConfig()
+		if path == "" {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			return ExitError
+		}
+		if err != nil {
+			// Print what would be used anyway, and say what is wrong.
+			fmt.Fprintf(stderr, "%s: %s does not validate; run %s validate\n", name, path, name)
+			cfg = LoadConfig()
+		}
+		out, err := json.MarshalIndent(cfg.WithDefaults(), "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			return ExitError
+		}
+		fmt.Fprintf(stdout, "%s\n", out)
+		return ExitOK
+	case "validate":
+		_, path, err := CheckConfig()
+		if err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(stderr, "%s: %s\n", path, line)
+			}
+			return ExitError
+		}
+		fmt.Fprintf(stdout, "%s: ok\n", path)
+		return ExitOK
+	case "help", "-h", "--help":
+		configUsage(name, stderr)
+		return ExitOK
+	}
+	fmt.Fprintf(stderr, "%s: unknown command %q\n", name, args[0])
+	configUsage(name, stderr)
+	return ExitUsage
+}
+
+func configUsage(name string, w io.Writer) {
+	fmt.Fprintf(w, `usage: %s <command>
+
+commands:
+  print      show the settings in effect, with defaults filled in
+  validate   check the config file, environment and flags
+
+Settings come from the config file, then VALVEFM_* environment
+variables, then command-line flags:
+`, name)
+	for _, s := range settings {
+		fmt.Fprintf(w, "  --%-14s %-18s %-24s %s\n", s.name, s.field, s.env(), s.usage)
+	}
+}
@@ -1,71 +1,201 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
 )
 
-// AppConfig holds application-level configuration.
+// AppConfig holds application-level configuration. Zero values use the
+// defaults WithDefaults fills in.
 type AppConfig struct {
-	Theme string `json:"theme"`
+	Theme         string              `json:"theme"`
+	Startup       StartupConfig       `json:"startup"`
+	Player        PlayerConfig        `json:"player"`
+	API           APIConfig           `json:"api"`
+	CacheDir      string              `json:"cache_dir,omitempty"` // icons and other downloads; the user cache directory by default
+	UI            UIConfig            `json:"ui"`
+	Keys          map[string][]string `json:"keys,omitempty"` // action -> keys, replacing its default keys
+	Audio         AudioConfig         `json:"audio"`
+	Alarms        []Alarm             `json:"alarms,omitempty"`
+	HTTP          HTTPConfig          `json:"http"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Hooks         []Hook              `json:"hooks,omitempty"`
 }
 
-// LoadConfig reads the app config from ~/.config/valvefm/config.json.
-// Returns a zero-value AppConfig if the file does not exist.
+// StartupConfig picks what valvefm lists and plays when it starts. Unset,
+// it comes back where it left off; see State.
+type StartupConfig struct {
+	Source   string `json:"source,omitempty"`   // "favorites", "country" or "recent"; the list shown last by default
+	Country  string `json:"country,omitempty"`  // ISO 3166 code; the country shown last by default, or US
+	Station  string `json:"station,omitempty"`  // UUID or name of a station to play on startup
+	Autoplay *bool  `json:"autoplay,omitempty"` // play Station, or resume the last station, on startup; true by default
+	Volume   *int   `json:"volume,omitempty"`   // percent, 100 by default; 0 starts muted
+}
+
+// PlayerConfig chooses the audio backend.
+type PlayerConfig struct {
+	Backend string `json:"backend,omitempty"`  // "auto", "native", "mpv" or "ffplay"; auto by default
+	MPVPath string `json:"mpv_path,omitempty"` // mpv or ffplay executable; looked up by default
+}
+
+// APIConfig points the Radio Browser client at a server.
+type APIConfig struct {
+	Mirror string `json:"mirror,omitempty"` // server URL; a random mirror by default
+}
+
+// UIConfig tunes the terminal interface.
+type UIConfig struct {
+	PageSize     int   `json:"page_size,omitempty"`     // stations per page, 200 by default
+	TuningStatic *bool `json:"tuning_static,omitempty"` // static while tuning between stations, true by default
+	KeyHints     *bool `json:"key_hints,omitempty"`     // the key hint line, true by default
+}
+
+// Hook runs a shell command when a playback event happens: "start",
+// "stop", "track" or "error".
+type Hook struct {
+	Event   string `json:"event"`
+	Command string `json:"command"`
+	Timeout int    `json:"timeout,omitempty"` // seconds, 10 by default
+}
+
+// AudioConfig tunes audio output. Zero values use the player defaults.
+type AudioConfig struct {
+	SampleRate int    `json:"sample_rate,omitempty"` // Hz, e.g. 44100 or 48000
+	BufferMs   int    `json:"buffer_ms,omitempty"`   // device buffer in milliseconds
+	Device     string `json:"device,omitempty"`      // output device passed to mpv/ffplay
+}
+
+// NotificationsConfig turns on desktop notifications for station and
+// song changes.
+type NotificationsConfig struct {
+	Enabled     bool `json:"enabled,omitempty"`
+	MinInterval int  `json:"min_interval,omitempty"` // seconds between notifications, 5 by default
+}
+
+// HTTPConfig enables the web remote-control API. It listens on localhost
+// unless Addr says otherwise, which then requires a Token.
+type HTTPConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Addr    string `json:"addr,omitempty"`  // host:port, 127.0.0.1:8765 by default
+	Token   string `json:"token,omitempty"` // bearer token; required off localhost
+}
+
+// LoadConfig reads the app config from ~/.config/valvefm/config.json,
+// falling back to config.json.bak when the file is damaged, and applies
+// the VALVEFM_* environment variables and flags. The file is skipped if
+// neither it nor its backup can be read.
 func LoadConfig() AppConfig {
+	var cfg AppConfig
+	if path, err := configPath(); err == nil {
+		if err := readJSONFile(path, &cfg); err != nil {
+			cfg = AppConfig{}
+		}
+	}
+	_ = applyOverrides(&cfg)
+	return cfg
+}
+
+// ReadConfig reads the config file as it is, for reloading it after a
+// change on disk. Unlike LoadConfig it reports a file that does not parse,
+// and leaves it alone. A missing file is an empty config.
+func ReadConfig() (AppConfig, error) {
 	path, err := configPath()
 	if err != nil {
-		return AppConfig{}
+		return AppConfig{}, err
 	}
-	data, err := os.ReadFile(path)
+	var cfg AppConfig
+	if err := parseJSONFile(path, &cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return AppConfig{}, err
+	}
+	_ = applyOverrides(&cfg)
+	return cfg, nil
+}
+
+// CheckConfig reads the config file strictly, rejecting fields it does not
+// know, applies the environment and flags, and validates the result. It
+// returns the config file path along with everything that is wrong.
+func CheckConfig() (AppConfig, string, error) {
+	path, err := configPath()
 	if err != nil {
-		return AppConfig{}
+		return AppConfig{}, "", err
 	}
 	var cfg AppConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return AppConfig{}
+	var errs []error
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return AppConfig{}, path, err
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return AppConfig{}, path, err
+		}
 	}
-	return cfg
+	if err := applyOverrides(&cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, path, errors.Join(errs...)
 }
 
 // SaveTheme persists the theme slug to the config file,
 // preserving any other fields that may exist.
 func SaveTheme(slug string) error {
+	return saveField("theme", slug)
+}
+
+// SaveAudio persists the audio settings to the config file,
+// preserving any other fields that may exist.
+func SaveAudio(audio AudioConfig) error {
+	return saveField("audio", audio)
+}
+
+// saveField sets a single top-level key in the config file. It re-reads
+// the file under the lock, so fields saved meanwhile by another valvefm
+// process are kept, and refuses to overwrite a file it cannot parse.
+func saveField(key string, value interface{}) error {
 	path, err := configPath()
 	if err != nil {
 		return err
 	}
 
-	// Load existing config to preserve other fields.
-	var raw map[string]interface{}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
+	return withFileLock(path, func() error {
+		// Load existing config to preserve other fields.
+		raw := make(map[string]interface{})
+		if err := readJSONFile(path, &raw); err != nil && !errors.Is(err, os.ErrNotExist) {
 			return err
 		}
-		raw = make(map[string]interface{})
-	} else {
-		if err := json.Unmarshal(data, &raw); err != nil {
+		if raw == nil {
+			// The file holds a bare null.
 			raw = make(map[string]interface{})
 		}
-	}
-
-	raw["theme"] = slug
 
-	out, err := json.MarshalIndent(raw, "", "  ")
-	if err != nil {
-		return err
-	}
+		raw[key] = value
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-	return os.WriteFile(path, out, 0o644)
+		out, err := json.MarshalIndent(raw, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, out)
+	})
 }
 
+// configPath is the file given with --config or VALVEFM_CONFIG, or
+// config.json in the valvefm config directory.
 func configPath() (string, error) {
+	if pathFlag != "" {
+		return pathFlag, nil
+	}
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return path, nil
+	}
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
@@ -202,3 +202,33 @@ func TestAppConfig_ExtraFields(t *testing.T) {
 		t.Errorf("Theme = %q, want %q", cfg.Theme, "nord")
 	}
 }
+
+func TestAudioConfig_JSONRoundTrip(t *testing.T) {
+	original := AppConfig{
+		Theme: "nord",
+		Audio: AudioConfig{SampleRate: 48000, BufferMs: 300, Device: "pulse/usb-dac"},
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded AppConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Theme != original.Theme || decoded.Audio != original.Audio {
+		t.Errorf("decoded = %+v, want %+v", decoded, original)
+	}
+}
+
+func TestAudioConfig_OmitsDefaults(t *testing.T) {
+	data, err := json.Marshal(AudioConfig{})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != "{}" {
+		t.Errorf("Marshal(AudioConfig{}) = %s, want {}", data)
+	}
+}
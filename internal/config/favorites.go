@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 	"sync"
 
@@ -17,16 +22,77 @@ type Favorite struct {
 	Name    string `json:"name"`
 	Country string `json:"country"`
 	Tags    string `json:"tags"`
+
+	// URL and Frequency are set for custom stations only; see IsCustom.
+	URL       string  `json:"url,omitempty"`
+	Frequency float64 `json:"frequency,omitempty"` // MHz, shown on the dial
+}
+
+// customPrefix starts the ids of custom stations: streams that are not in
+// Radio Browser and play straight from their URL.
+const customPrefix = "custom-"
+
+// FM broadcast bands run from 76 MHz (Japan) to 108 MHz.
+const (
+	minFrequency = 76.0
+	maxFrequency = 108.0
+)
+
+// IsCustom reports whether a favorite id belongs to a custom station.
+func IsCustom(uuid string) bool {
+	return strings.HasPrefix(uuid, customPrefix)
+}
+
+// validateCustom checks the details of a custom station.
+func (f Favorite) validateCustom() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("station name is required")
+	}
+	u, err := url.Parse(strings.TrimSpace(f.URL))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("stream URL %q is not a full URL such as http://host/stream", f.URL)
+	}
+	if f.Frequency != 0 && (f.Frequency < minFrequency || f.Frequency > maxFrequency) {
+		return fmt.Errorf("frequency %.1f MHz is outside %.0f-%.0f MHz", f.Frequency, minFrequency, maxFrequency)
+	}
+	return nil
+}
+
+// Group is a named, ordered collection of favorites. A station may belong
+// to several groups.
+type Group struct {
+	Name     string   `json:"name"`
+	Stations []string `json:"stations"` // favorite UUIDs
 }
 
+var (
+	ErrGroupExists   = errors.New("a group with that name already exists")
+	ErrGroupNotFound = errors.New("no such group")
+)
+
+// favoritesVersion is the schema of favorites.json. Version 1 files have
+// no version field and hold only the stations; version 2 added groups and
+// version 3 keeps stations and group members in the order the user chose.
+// Version 4 added custom stations, which older versions would save without
+// their stream URLs.
+const favoritesVersion = 4
+
+// PresetCount is how many favorites, from the top of the order, are
+// presets.
+const PresetCount = 9
+
 type Favorites struct {
-	mu    sync.Mutex
-	path  string
-	items map[string]Favorite
+	mu     sync.Mutex
+	path   string
+	items  map[string]Favorite
+	order  []string // favorite UUIDs in the user's order
+	groups []Group
 }
 
 type favoritesFile struct {
+	Version  int        `json:"version"`
 	Stations []Favorite `json:"stations"`
+	Groups   []Group    `json:"groups,omitempty"`
 }
 
 func LoadFavorites() (*Favorites, error) {
@@ -39,26 +105,137 @@ func LoadFavorites() (*Favorites, error) {
 		path:  path,
 		items: map[string]Favorite{},
 	}
+	if err := withFileLock(path, favs.reloadLocked); err != nil {
+		return nil, err
+	}
+	return favs, nil
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return favs, nil
+// reloadLocked replaces the favorites in memory with those in the file. A
+// missing file holds no favorites.
+func (f *Favorites) reloadLocked() error {
+	var stored favoritesFile
+	if err := readJSONFile(f.path, &stored); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
 		}
-		return nil, err
+		stored = favoritesFile{Version: favoritesVersion}
 	}
+	return f.setLocked(stored)
+}
+
+// Reload re-reads the file after it changed on disk and reports whether
+// the favorites differ from those in memory. A file that does not parse,
+// such as one saved halfway through a hand edit, is left alone and the
+// favorites are kept.
+func (f *Favorites) Reload() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
 	var stored favoritesFile
-	if err := json.Unmarshal(data, &stored); err != nil {
-		return nil, err
+	if err := parseJSONFile(f.path, &stored); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+		stored = favoritesFile{Version: favoritesVersion}
 	}
+	items, order, groups := f.items, f.order, f.groups
+	if err := f.setLocked(stored); err != nil {
+		return false, err
+	}
+	same := maps.Equal(items, f.items) && slices.Equal(order, f.order) &&
+		slices.EqualFunc(groups, f.groups, func(a, b Group) bool {
+			return a.Name == b.Name && slices.Equal(a.Stations, b.Stations)
+		})
+	return !same, nil
+}
+
+// setLocked replaces the favorites in memory with stored.
+func (f *Favorites) setLocked(stored favoritesFile) error {
+	if err := migrateFavorites(&stored); err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.items = make(map[string]Favorite, len(stored.Stations))
+	f.order = make([]string, 0, len(stored.Stations))
 	for _, fav := range stored.Stations {
-		if fav.UUID != "" {
-			favs.items[fav.UUID] = fav
+		if _, seen := f.items[fav.UUID]; fav.UUID != "" && !seen {
+			f.items[fav.UUID] = fav
+			f.order = append(f.order, fav.UUID)
 		}
 	}
+	f.groups = cleanGroups(stored.Groups, f.items)
+	return nil
+}
 
-	return favs, nil
+// errUnchanged tells updateLocked that a change left the favorites as
+// they were, so there is nothing to save.
+var errUnchanged = errors.New("favorites unchanged")
+
+// updateLocked applies change to the favorites as they are on disk,
+// holding the file lock from reading to saving, so that edits made by
+// other valvefm processes in the meantime are kept rather than
+// overwritten. The caller holds f.mu.
+func (f *Favorites) updateLocked(change func() error) error {
+	return withFileLock(f.path, func() error {
+		if err := f.reloadLocked(); err != nil {
+			return err
+		}
+		if err := change(); err != nil {
+			if errors.Is(err, errUnchanged) {
+				return nil
+			}
+			return err
+		}
+		return f.saveLocked()
+	})
+}
+
+// migrateFavorites brings a stored file up to favoritesVersion. The file
+// itself is rewritten on the next change.
+func migrateFavorites(stored *favoritesFile) error {
+	if stored.Version > favoritesVersion {
+		return fmt.Errorf("favorites version %d is newer than this version of Valve FM supports", stored.Version)
+	}
+	if stored.Version < 2 {
+		// Version 1 had no groups.
+		stored.Groups = nil
+	}
+	if stored.Version < 3 {
+		// Older files were always shown by name; start the order from there.
+		names := make(map[string]string, len(stored.Stations))
+		for _, fav := range stored.Stations {
+			names[fav.UUID] = fav.Name
+		}
+		sortFavorites(stored.Stations)
+		for _, group := range stored.Groups {
+			slices.SortStableFunc(group.Stations, func(a, b string) int {
+				return compareFavorites(Favorite{UUID: a, Name: names[a]}, Favorite{UUID: b, Name: names[b]})
+			})
+		}
+	}
+	stored.Version = favoritesVersion
+	return nil
+}
+
+// cleanGroups drops unnamed and duplicate groups, and members that are not
+// favorites, from hand-edited files.
+func cleanGroups(groups []Group, items map[string]Favorite) []Group {
+	cleaned := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		group.Name = strings.TrimSpace(group.Name)
+		if group.Name == "" || indexGroup(cleaned, group.Name) >= 0 {
+			continue
+		}
+		members := make([]string, 0, len(group.Stations))
+		for _, uuid := range group.Stations {
+			if _, ok := items[uuid]; ok && !slices.Contains(members, uuid) {
+				members = append(members, uuid)
+			}
+		}
+		cleaned = append(cleaned, Group{Name: group.Name, Stations: members})
+	}
+	return cleaned
 }
 
 func (f *Favorites) Toggle(station radio.Station) (bool, error) {
@@ -69,18 +246,139 @@ func (f *Favorites) Toggle(station radio.Station) (bool, error) {
 		return false, errors.New("station uuid is required")
 	}
 
-	if _, ok := f.items[station.UUID]; ok {
-		delete(f.items, station.UUID)
-		return false, f.saveLocked()
+	added := false
+	err := f.updateLocked(func() error {
+		if _, ok := f.items[station.UUID]; ok {
+			delete(f.items, station.UUID)
+			f.order = slices.DeleteFunc(f.order, func(uuid string) bool {
+				return uuid == station.UUID
+			})
+			for i := range f.groups {
+				f.groups[i].Stations = slices.DeleteFunc(f.groups[i].Stations, func(uuid string) bool {
+					return uuid == station.UUID
+				})
+			}
+			return nil
+		}
+		f.items[station.UUID] = FavoriteOf(station)
+		f.order = append(f.order, station.UUID)
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// Add makes stations favorites, at the end of the order, skipping those
+// that already are. It saves once and returns how many were added.
+func (f *Favorites) Add(stations ...radio.Station) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, station := range stations {
+		if station.UUID == "" {
+			return 0, errors.New("station uuid is required")
+		}
+	}
+	added := 0
+	err := f.updateLocked(func() error {
+		for _, station := range stations {
+			if _, ok := f.items[station.UUID]; ok {
+				continue
+			}
+			f.items[station.UUID] = FavoriteOf(station)
+			f.order = append(f.order, station.UUID)
+			added++
+		}
+		if added == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
+	return added, nil
+}
 
-	f.items[station.UUID] = Favorite{
+// FavoriteOf records a station as a favorite. It keeps the stream URL and
+// frequency of custom stations only; directory stations are resolved
+// afresh on every play.
+func FavoriteOf(station radio.Station) Favorite {
+	fav := Favorite{
 		UUID:    station.UUID,
 		Name:    station.Name,
 		Country: station.Country,
 		Tags:    station.Tags,
 	}
-	return true, f.saveLocked()
+	if IsCustom(station.UUID) {
+		fav.URL = station.URL
+		fav.Frequency = station.Frequency.Float64()
+	}
+	return fav
+}
+
+// AddCustom saves a custom station as a new favorite, at the end of the
+// order, and returns it with its new id.
+func (f *Favorites) AddCustom(fav Favorite) (Favorite, error) {
+	fav = trimCustom(fav)
+	if err := fav.validateCustom(); err != nil {
+		return Favorite{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Favorite{}, err
+	}
+	fav.UUID = customPrefix + hex.EncodeToString(id)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.updateLocked(func() error {
+		f.items[fav.UUID] = fav
+		f.order = append(f.order, fav.UUID)
+		return nil
+	})
+	if err != nil {
+		return Favorite{}, err
+	}
+	return fav, nil
+}
+
+// UpdateCustom replaces the details of a custom station, keeping its place
+// in the order and in groups.
+func (f *Favorites) UpdateCustom(fav Favorite) error {
+	fav = trimCustom(fav)
+	if !IsCustom(fav.UUID) {
+		return errors.New("only custom stations can be edited")
+	}
+	if err := fav.validateCustom(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updateLocked(func() error {
+		if _, ok := f.items[fav.UUID]; !ok {
+			return errors.New("no such custom station")
+		}
+		f.items[fav.UUID] = fav
+		return nil
+	})
+}
+
+func trimCustom(fav Favorite) Favorite {
+	fav.Name = strings.TrimSpace(fav.Name)
+	fav.URL = strings.TrimSpace(fav.URL)
+	fav.Country = strings.TrimSpace(fav.Country)
+	fav.Tags = strings.TrimSpace(fav.Tags)
+	return fav
+}
+
+// Get returns a favorite by id.
+func (f *Favorites) Get(uuid string) (Favorite, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fav, ok := f.items[uuid]
+	return fav, ok
 }
 
 func (f *Favorites) IsFavorite(uuid string) bool {
@@ -100,36 +398,252 @@ func (f *Favorites) List() []Favorite {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	list := make([]Favorite, 0, len(f.items))
-	for _, fav := range f.items {
-		list = append(list, fav)
+	list := make([]Favorite, 0, len(f.order))
+	for _, uuid := range f.order {
+		list = append(list, f.items[uuid])
+	}
+	return list
+}
+
+// Preset returns preset n, the nth favorite in the user's order, counting
+// from 1.
+func (f *Favorites) Preset(n int) (Favorite, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if n < 1 || n > PresetCount || n > len(f.order) {
+		return Favorite{}, false
 	}
-	sort.Slice(list, func(i, j int) bool {
-		ni := strings.ToLower(strings.TrimSpace(list[i].Name))
-		nj := strings.ToLower(strings.TrimSpace(list[j].Name))
-		if ni == nj {
-			return list[i].UUID < list[j].UUID
+	return f.items[f.order[n-1]], true
+}
+
+// Move moves a favorite delta places up (negative) or down the order,
+// stopping at either end.
+func (f *Favorites) Move(uuid string, delta int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updateLocked(func() error {
+		order, err := moveUUID(f.order, uuid, delta)
+		if err != nil {
+			return err
 		}
-		return ni < nj
+		f.order = order
+		return nil
 	})
-	return list
 }
 
-func (f *Favorites) saveLocked() error {
-	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
-		return err
+// MoveInGroup moves a station within a group's order.
+func (f *Favorites) MoveInGroup(uuid, name string, delta int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updateLocked(func() error {
+		i := indexGroup(f.groups, name)
+		if i < 0 {
+			return ErrGroupNotFound
+		}
+		order, err := moveUUID(f.groups[i].Stations, uuid, delta)
+		if err != nil {
+			return err
+		}
+		f.groups[i].Stations = order
+		return nil
+	})
+}
+
+func moveUUID(order []string, uuid string, delta int) ([]string, error) {
+	i := slices.Index(order, uuid)
+	if i < 0 {
+		return nil, errors.New("station is not in the list")
+	}
+	j := max(0, min(len(order)-1, i+delta))
+	if i != j {
+		order = slices.Insert(slices.Delete(order, i, i+1), j, uuid)
+	}
+	return order, nil
+}
+
+// ListGroup returns the favorites in a group, in the group's order.
+func (f *Favorites) ListGroup(name string) ([]Favorite, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := indexGroup(f.groups, name)
+	if i < 0 {
+		return nil, ErrGroupNotFound
+	}
+	list := make([]Favorite, 0, len(f.groups[i].Stations))
+	for _, uuid := range f.groups[i].Stations {
+		list = append(list, f.items[uuid])
+	}
+	return list, nil
+}
+
+// Groups returns the groups in their display order.
+func (f *Favorites) Groups() []Group {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	groups := make([]Group, len(f.groups))
+	for i, group := range f.groups {
+		groups[i] = Group{Name: group.Name, Stations: slices.Clone(group.Stations)}
 	}
+	return groups
+}
+
+// HasGroup reports whether a group exists; names match case-insensitively.
+func (f *Favorites) HasGroup(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return indexGroup(f.groups, name) >= 0
+}
+
+// InGroup reports whether a station belongs to a group.
+func (f *Favorites) InGroup(uuid, name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := indexGroup(f.groups, name)
+	return i >= 0 && slices.Contains(f.groups[i].Stations, uuid)
+}
+
+// CreateGroup adds an empty group at the end.
+func (f *Favorites) CreateGroup(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	list := make([]Favorite, 0, len(f.items))
-	for _, fav := range f.items {
-		list = append(list, fav)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("group name is required")
+	}
+	return f.updateLocked(func() error {
+		if indexGroup(f.groups, name) >= 0 {
+			return ErrGroupExists
+		}
+		f.groups = append(f.groups, Group{Name: name, Stations: []string{}})
+		return nil
+	})
+}
+
+// RenameGroup renames a group in place.
+func (f *Favorites) RenameGroup(name, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return errors.New("group name is required")
+	}
+	return f.updateLocked(func() error {
+		i := indexGroup(f.groups, name)
+		if i < 0 {
+			return ErrGroupNotFound
+		}
+		if j := indexGroup(f.groups, newName); j >= 0 && j != i {
+			return ErrGroupExists
+		}
+		f.groups[i].Name = newName
+		return nil
+	})
+}
+
+// DeleteGroup removes a group; its stations stay favorites.
+func (f *Favorites) DeleteGroup(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updateLocked(func() error {
+		i := indexGroup(f.groups, name)
+		if i < 0 {
+			return ErrGroupNotFound
+		}
+		f.groups = slices.Delete(f.groups, i, i+1)
+		return nil
+	})
+}
+
+// MoveGroup moves a group delta places up (negative) or down the order,
+// stopping at either end.
+func (f *Favorites) MoveGroup(name string, delta int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updateLocked(func() error {
+		i := indexGroup(f.groups, name)
+		if i < 0 {
+			return ErrGroupNotFound
+		}
+		j := max(0, min(len(f.groups)-1, i+delta))
+		if i == j {
+			return errUnchanged
+		}
+		group := f.groups[i]
+		f.groups = slices.Insert(slices.Delete(f.groups, i, i+1), j, group)
+		return nil
+	})
+}
+
+// SetInGroup adds a favorite to a group or takes it out.
+func (f *Favorites) SetInGroup(uuid, name string, in bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.updateLocked(func() error {
+		i := indexGroup(f.groups, name)
+		if i < 0 {
+			return ErrGroupNotFound
+		}
+		if _, ok := f.items[uuid]; !ok && in {
+			return errors.New("only favorites can be added to a group")
+		}
+		members := f.groups[i].Stations
+		at := slices.Index(members, uuid)
+		switch {
+		case in && at < 0:
+			f.groups[i].Stations = append(members, uuid)
+		case !in && at >= 0:
+			f.groups[i].Stations = slices.Delete(members, at, at+1)
+		default:
+			return errUnchanged
+		}
+		return nil
+	})
+}
+
+func indexGroup(groups []Group, name string) int {
+	name = strings.TrimSpace(name)
+	return slices.IndexFunc(groups, func(group Group) bool {
+		return strings.EqualFold(group.Name, name)
+	})
+}
+
+// sortFavorites sorts by name, the order before version 3.
+func sortFavorites(list []Favorite) {
+	slices.SortFunc(list, compareFavorites)
+}
+
+func compareFavorites(a, b Favorite) int {
+	na := strings.ToLower(strings.TrimSpace(a.Name))
+	nb := strings.ToLower(strings.TrimSpace(b.Name))
+	if c := strings.Compare(na, nb); c != 0 {
+		return c
+	}
+	return strings.Compare(a.UUID, b.UUID)
+}
+
+// saveLocked writes the favorites; callers go through updateLocked.
+func (f *Favorites) saveLocked() error {
+	list := make([]Favorite, 0, len(f.order))
+	for _, uuid := range f.order {
+		list = append(list, f.items[uuid])
 	}
 
-	data, err := json.MarshalIndent(favoritesFile{Stations: list}, "", "  ")
+	stored := favoritesFile{Version: favoritesVersion, Stations: list, Groups: f.groups}
+	data, err := json.MarshalIndent(stored, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(f.path, data, 0o644)
+	return writeFileAtomic(f.path, data)
 }
 
 func favoritesPath() (string, error) {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -294,10 +295,319 @@ func TestFavorites_CountAndList(t *testing.T) {
 		t.Fatalf("Count() = %d, want 2", got)
 	}
 
+	// Favorites keep the order they were added in, not their names.
 	list := favs.List()
 	gotOrder := []string{list[0].Name, list[1].Name}
-	wantOrder := []string{"Alpha FM", "Zulu FM"}
+	wantOrder := []string{"Zulu FM", "Alpha FM"}
 	if !reflect.DeepEqual(gotOrder, wantOrder) {
 		t.Fatalf("List() order = %v, want %v", gotOrder, wantOrder)
 	}
 }
+
+func TestLoadFavorites_MigratesVersion1(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	path, err := favoritesPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	v1 := `{"stations": [{"uuid": "1", "name": "Jazz FM"}, {"uuid": "2", "name": "News"}]}`
+	if err := os.WriteFile(path, []byte(v1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	favs, err := LoadFavorites()
+	if err != nil {
+		t.Fatalf("LoadFavorites() error = %v", err)
+	}
+	if favs.Count() != 2 || len(favs.Groups()) != 0 {
+		t.Fatalf("loaded %d favorites, %d groups", favs.Count(), len(favs.Groups()))
+	}
+
+	// The next change writes the current version.
+	if err := favs.CreateGroup("Jazz"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored favoritesFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != favoritesVersion || len(stored.Stations) != 2 || len(stored.Groups) != 1 {
+		t.Errorf("stored = %+v", stored)
+	}
+}
+
+func TestLoadFavorites_RejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	path, _ := favoritesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"version": 99, "stations": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFavorites(); err == nil {
+		t.Fatal("LoadFavorites() accepted a newer schema")
+	}
+}
+
+func TestMigrateFavorites_CleansGroups(t *testing.T) {
+	items := map[string]Favorite{"1": {UUID: "1"}, "2": {UUID: "2"}}
+	groups := cleanGroups([]Group{
+		{Name: " Jazz ", Stations: []string{"1", "1", "gone"}},
+		{Name: "jazz", Stations: []string{"2"}},
+		{Name: "", Stations: []string{"2"}},
+	}, items)
+	want := []Group{{Name: "Jazz", Stations: []string{"1"}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("cleanGroups() = %+v, want %+v", groups, want)
+	}
+}
+
+func TestFavorites_Groups(t *testing.T) {
+	favs := newTestFavorites(t)
+	for _, s := range []radio.Station{
+		{UUID: "1", Name: "Zulu Jazz"},
+		{UUID: "2", Name: "Alpha Jazz"},
+		{UUID: "3", Name: "News"},
+	} {
+		if _, err := favs.Toggle(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"Jazz", "News", "Work focus"} {
+		if err := favs.CreateGroup(name); err != nil {
+			t.Fatalf("CreateGroup(%q) error = %v", name, err)
+		}
+	}
+	if err := favs.CreateGroup("jazz"); !errors.Is(err, ErrGroupExists) {
+		t.Errorf("duplicate CreateGroup() error = %v", err)
+	}
+
+	for _, uuid := range []string{"1", "2"} {
+		if err := favs.SetInGroup(uuid, "Jazz", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := favs.SetInGroup("2", "Work focus", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := favs.SetInGroup("not-a-favorite", "Jazz", true); err == nil {
+		t.Error("SetInGroup() added a station that is not a favorite")
+	}
+	list, err := favs.ListGroup("jazz")
+	if err != nil || len(list) != 2 || list[0].Name != "Zulu Jazz" {
+		t.Fatalf("ListGroup() = %+v, %v", list, err)
+	}
+
+	if err := favs.RenameGroup("Work focus", "Focus"); err != nil {
+		t.Fatal(err)
+	}
+	if err := favs.RenameGroup("Focus", "News"); !errors.Is(err, ErrGroupExists) {
+		t.Errorf("RenameGroup() onto another group error = %v", err)
+	}
+	if err := favs.MoveGroup("Focus", -5); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, group := range favs.Groups() {
+		names = append(names, group.Name)
+	}
+	if want := []string{"Focus", "Jazz", "News"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("group order = %v, want %v", names, want)
+	}
+
+	// Removing a favorite takes it out of every group.
+	if _, err := favs.Toggle(radio.Station{UUID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if favs.InGroup("2", "Jazz") || favs.InGroup("2", "Focus") {
+		t.Error("removed favorite is still in a group")
+	}
+
+	if err := favs.DeleteGroup("Jazz"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := favs.ListGroup("Jazz"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("ListGroup() of a deleted group error = %v", err)
+	}
+	if !favs.IsFavorite("1") {
+		t.Error("deleting a group removed its favorites")
+	}
+}
+
+func TestFavorites_MoveAndPresets(t *testing.T) {
+	favs := newTestFavorites(t)
+	for _, s := range []radio.Station{{UUID: "a", Name: "A"}, {UUID: "b", Name: "B"}, {UUID: "c", Name: "C"}} {
+		if _, err := favs.Toggle(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	order := func() []string {
+		var uuids []string
+		for _, fav := range favs.List() {
+			uuids = append(uuids, fav.UUID)
+		}
+		return uuids
+	}
+
+	if err := favs.Move("c", -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := favs.Move("a", 10); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := order(), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if err := favs.Move("missing", 1); err == nil {
+		t.Error("Move() of an unknown station succeeded")
+	}
+
+	if fav, ok := favs.Preset(1); !ok || fav.UUID != "c" {
+		t.Errorf("Preset(1) = %+v, %v, want c", fav, ok)
+	}
+	if _, ok := favs.Preset(4); ok {
+		t.Error("Preset(4) exists with 3 favorites")
+	}
+
+	// The file keeps the order.
+	data, err := os.ReadFile(favs.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored favoritesFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	var storedOrder []string
+	for _, fav := range stored.Stations {
+		storedOrder = append(storedOrder, fav.UUID)
+	}
+	if want := []string{"c", "b", "a"}; !reflect.DeepEqual(storedOrder, want) {
+		t.Errorf("stored order = %v, want %v", storedOrder, want)
+	}
+
+	if err := favs.CreateGroup("G"); err != nil {
+		t.Fatal(err)
+	}
+	for _, uuid := range []string{"a", "b"} {
+		if err := favs.SetInGroup(uuid, "G", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := favs.MoveInGroup("b", "G", -1); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := favs.ListGroup("G"); list[0].UUID != "b" {
+		t.Errorf("group order = %+v, want b first", list)
+	}
+}
+
+func TestMigrateFavorites_OrdersByName(t *testing.T) {
+	stored := favoritesFile{
+		Version:  2,
+		Stations: []Favorite{{UUID: "1", Name: "Zulu"}, {UUID: "2", Name: "alpha"}, {UUID: "3", Name: "Mike"}},
+		Groups:   []Group{{Name: "G", Stations: []string{"1", "3", "2"}}},
+	}
+	if err := migrateFavorites(&stored); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fav := range stored.Stations {
+		names = append(names, fav.Name)
+	}
+	if want := []string{"alpha", "Mike", "Zulu"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("migrated order = %v, want %v", names, want)
+	}
+	if want := []string{"2", "3", "1"}; !reflect.DeepEqual(stored.Groups[0].Stations, want) {
+		t.Errorf("migrated group order = %v, want %v", stored.Groups[0].Stations, want)
+	}
+}
+
+func TestFavorites_Add(t *testing.T) {
+	favs := newTestFavorites(t)
+	if _, err := favs.Toggle(radio.Station{UUID: "a", Name: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	added, err := favs.Add(radio.Station{UUID: "b", Name: "B"}, radio.Station{UUID: "a", Name: "Renamed"}, radio.Station{UUID: "c", Name: "C"})
+	if err != nil || added != 2 {
+		t.Fatalf("Add() = %d, %v, want 2 added", added, err)
+	}
+	list := favs.List()
+	if len(list) != 3 || list[0].Name != "A" || list[2].UUID != "c" {
+		t.Errorf("List() = %+v, want A kept first and c last", list)
+	}
+	if _, err := favs.Add(radio.Station{Name: "No UUID"}); err == nil {
+		t.Error("Add() accepted a station without a UUID")
+	}
+}
+
+func TestFavorites_CustomStations(t *testing.T) {
+	favs := newTestFavorites(t)
+	for _, bad := range []Favorite{
+		{URL: "http://example.com/live"},
+		{Name: "No URL"},
+		{Name: "Relative", URL: "/live.mp3"},
+		{Name: "Off the band", URL: "http://example.com/live", Frequency: 150},
+	} {
+		if _, err := favs.AddCustom(bad); err == nil {
+			t.Errorf("AddCustom(%+v) accepted an invalid station", bad)
+		}
+	}
+
+	fav, err := favs.AddCustom(Favorite{Name: " Office ", URL: "http://intranet.example/live ", Tags: "news", Frequency: 101.5})
+	if err != nil {
+		t.Fatalf("AddCustom() error = %v", err)
+	}
+	if !IsCustom(fav.UUID) || fav.Name != "Office" || fav.URL != "http://intranet.example/live" {
+		t.Fatalf("AddCustom() = %+v", fav)
+	}
+	if IsCustom("96062a7b-0601-11e8-ae97-52543be04c81") {
+		t.Error("IsCustom() took a Radio Browser UUID for a custom station")
+	}
+
+	fav.Name = "Office Radio"
+	if err := favs.UpdateCustom(fav); err != nil {
+		t.Fatalf("UpdateCustom() error = %v", err)
+	}
+	if err := favs.UpdateCustom(Favorite{UUID: "custom-gone", Name: "Gone", URL: "http://gone"}); err == nil {
+		t.Error("UpdateCustom() accepted an unknown station")
+	}
+	if _, err := favs.Toggle(radio.Station{UUID: "a", Name: "A", URL: "http://a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := favs.UpdateCustom(Favorite{UUID: "a", Name: "A", URL: "http://a"}); err == nil {
+		t.Error("UpdateCustom() edited a directory station")
+	}
+
+	reloaded := &Favorites{path: favs.path, items: map[string]Favorite{}}
+	data, err := os.ReadFile(favs.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored favoritesFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	for _, saved := range stored.Stations {
+		reloaded.items[saved.UUID] = saved
+	}
+	if got := reloaded.items[fav.UUID]; got != fav {
+		t.Errorf("saved custom station = %+v, want %+v", got, fav)
+	}
+	if got := reloaded.items["a"]; got.URL != "" {
+		t.Errorf("saved the stream URL of a directory station: %+v", got)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix names the copy of the last good version kept next to a
+// file: favorites.json.bak.
+const backupSuffix = ".bak"
+
+// writeFileAtomic replaces path with data so that readers, and a crash
+// halfway, see either the old or the new file, never a mix. The old file
+// is kept as path.bak when it holds valid JSON.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(path); err == nil && json.Valid(old) && !bytes.Equal(old, data) {
+		if err := replaceFile(path+backupSuffix, old); err != nil {
+			return fmt.Errorf("backing up %s: %w", filepath.Base(path), err)
+		}
+	}
+	return replaceFile(path, data)
+}
+
+// replaceFile writes data to a temporary file in the same directory,
+// flushes it to disk and renames it over path.
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir makes a rename durable where the platform allows it.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// parseJSONFile decodes path into v, leaving a damaged file alone.
+func parseJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readJSONFile decodes path into v. When the file does not parse, as after
+// a crash or a bad hand edit, it falls back to path.bak and puts the
+// backup back in place. It returns os.ErrNotExist when there is no file.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parseErr := json.Unmarshal(data, v)
+	if parseErr == nil {
+		return nil
+	}
+
+	backup, err := os.ReadFile(path + backupSuffix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, parseErr)
+	}
+	if err := json.Unmarshal(backup, v); err != nil {
+		return fmt.Errorf("%s: %w (the backup does not parse either: %v)", path, parseErr, err)
+	}
+	// Keep the damaged file for inspection, then restore the backup.
+	_ = os.WriteFile(path+".broken", data, 0o644)
+	if err := replaceFile(path, backup); err != nil {
+		return fmt.Errorf("restoring %s from its backup: %w", path, err)
+	}
+	return nil
+}
+
+// withFileLock runs fn holding an advisory lock on path, shared with other
+// valvefm processes. The lock lives on path.lock, since path itself is
+// replaced on every write.
+func withFileLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("locking %s: %w", filepath.Base(path), err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+	return fn()
+}
+
+// WithFileLock runs fn holding the lock on path shared with other valvefm
+// processes, for packages keeping their own files in the config directory.
+func WithFileLock(path string, fn func() error) error {
+	return withFileLock(path, fn)
+}
+
+// ReadJSONFile decodes path into v, falling back to its backup when it
+// does not parse. It returns os.ErrNotExist when there is no file.
+func ReadJSONFile(path string, v any) error {
+	return readJSONFile(path, v)
+}
+
+// WriteFileAtomic replaces path with data, keeping the old file as a
+// backup.
+func WriteFileAtomic(path string, data []byte) error {
+	return writeFileAtomic(path, data)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"radio-tui/internal/radio"
+)
+
+func TestWriteFileAtomic_KeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "valvefm", "settings.json")
+	for _, content := range []string{`{"n": 1}`, `{"n": 2}`, `{"n": 2}`} {
+		if err := writeFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("writeFileAtomic() error = %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"n": 2}` {
+		t.Errorf("file = %s", data)
+	}
+	if data, _ := os.ReadFile(path + backupSuffix); string(data) != `{"n": 1}` {
+		t.Errorf("backup = %s, want the previous version", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("directory holds %d files, want the file and its backup", len(entries))
+	}
+}
+
+func TestReadJSONFile_RecoversFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := writeFileAtomic(path, []byte(`{"n": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(`{"n": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	// A crash halfway through a write outside valvefm.
+	if err := os.WriteFile(path, []byte(`{"n": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct{ N int }
+	if err := readJSONFile(path, &got); err != nil || got.N != 1 {
+		t.Fatalf("readJSONFile() = %+v, %v, want the backup", got, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"n": 1}` {
+		t.Errorf("file = %s, want the backup restored", data)
+	}
+	if data, _ := os.ReadFile(path + ".broken"); string(data) != `{"n": ` {
+		t.Errorf("damaged copy = %s", data)
+	}
+
+	if err := os.WriteFile(path+backupSuffix, []byte("junk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("junk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := readJSONFile(path, &got); err == nil {
+		t.Error("readJSONFile() accepted a damaged file and backup")
+	}
+}
+
+func TestLoadFavorites_RecoversFromBackup(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	favs, err := LoadFavorites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uuid := range []string{"a", "b"} {
+		if _, err := favs.Toggle(radio.Station{UUID: uuid, Name: uuid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(favs.path, []byte(`{"version": 4, "stations": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadFavorites()
+	if err != nil {
+		t.Fatalf("LoadFavorites() error = %v", err)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].UUID != "a" {
+		t.Errorf("List() = %+v, want the backup with station a", list)
+	}
+}
+
+func TestFavorites_MergesEditsFromOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	first := &Favorites{path: path, items: map[string]Favorite{}}
+	second := &Favorites{path: path, items: map[string]Favorite{}}
+
+	if _, err := first.Toggle(radio.Station{UUID: "a", Name: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	// second has not seen a; its edit must keep it.
+	if _, err := second.Toggle(radio.Station{UUID: "b", Name: "B"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.CreateGroup("Mix"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SetInGroup("b", "Mix", true); err != nil {
+		t.Fatalf("SetInGroup() did not see the other edits: %v", err)
+	}
+
+	if list := first.List(); len(list) != 2 || list[0].UUID != "a" || list[1].UUID != "b" {
+		t.Errorf("List() = %+v, want a and b", list)
+	}
+	if !first.InGroup("b", "Mix") {
+		t.Error("b is not in Mix")
+	}
+}
+
+func TestFavorites_ConcurrentWritersKeepEveryEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	const writers, each = 4, 10
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each writer is a separate instance, as a separate process is.
+			favs := &Favorites{path: path, items: map[string]Favorite{}}
+			for i := range each {
+				uuid := fmt.Sprintf("w%d-%d", w, i)
+				if _, err := favs.Toggle(radio.Station{UUID: uuid, Name: uuid}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	favs := &Favorites{path: path, items: map[string]Favorite{}}
+	if err := favs.reloadLocked(); err != nil {
+		t.Fatal(err)
+	}
+	if got := favs.Count(); got != writers*each {
+		t.Errorf("Count() = %d, want %d", got, writers*each)
+	}
+}
+
+func TestSaveField_RefusesToOverwriteDamagedConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	path, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTheme("tokyo-night"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"theme": "tokyo-night", "hooks": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveAudio(AudioConfig{BufferMs: 200}); err == nil {
+		t.Error("SaveAudio() overwrote a config file it could not parse")
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"theme": "tokyo-night", "hooks": [` {
+		t.Errorf("config = %s, want it left alone", data)
+	}
+}
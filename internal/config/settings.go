@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envPrefix starts the environment variable of every setting:
+// VALVEFM_COUNTRY overrides startup.country.
+const envPrefix = "VALVEFM_"
+
+// setting is a config file field that a command-line flag and an
+// environment variable can override. Flags win over the environment, which
+// wins over the file.
+type setting struct {
+	name    string // flag name; the variable is VALVEFM_ and the name in capitals
+	field   string // where it lives in the config file
+	usage   string
+	boolean bool
+	set     func(cfg *AppConfig, value string) error
+}
+
+var settings = []setting{
+	stringSetting("theme", "theme", "color theme", func(c *AppConfig) *string { return &c.Theme }),
+	stringSetting("source", "startup.source", "list shown on startup: favorites, country or recent", func(c *AppConfig) *string { return &c.Startup.Source }),
+	stringSetting("country", "startup.country", "country listed on startup (ISO code)", func(c *AppConfig) *string { return &c.Startup.Country }),
+	stringSetting("station", "startup.station", "UUID or name of a station to play on startup", func(c *AppConfig) *string { return &c.Startup.Station }),
+	boolSetting("autoplay", "startup.autoplay", "play the startup station or resume the last one", func(c *AppConfig) **bool { return &c.Startup.Autoplay }),
+	negatedBoolSetting("no-autoplay", "startup.autoplay", "start stopped", func(c *AppConfig) **bool { return &c.Startup.Autoplay }),
+	optionalIntSetting("volume", "startup.volume", "volume on startup in percent", func(c *AppConfig) **int { return &c.Startup.Volume }),
+	stringSetting("player", "player.backend", "audio backend: auto, native, mpv or ffplay", func(c *AppConfig) *string { return &c.Player.Backend }),
+	stringSetting("mpv-path", "player.mpv_path", "mpv or ffplay executable", func(c *AppConfig) *string { return &c.Player.MPVPath }),
+	stringSetting("api-mirror", "api.mirror", "Radio Browser server URL", func(c *AppConfig) *string { return &c.API.Mirror }),
+	stringSetting("cache-dir", "cache_dir", "directory for cached icons", func(c *AppConfig) *string { return &c.CacheDir }),
+	intSetting("page-size", "ui.page_size", "stations per page", func(c *AppConfig) *int { return &c.UI.PageSize }),
+	boolSetting("tuning-static", "ui.tuning_static", "play static while tuning", func(c *AppConfig) **bool { return &c.UI.TuningStatic }),
+	boolSetting("key-hints", "ui.key_hints", "show the key hint line", func(c *AppConfig) **bool { return &c.UI.KeyHints }),
+}
+
+func stringSetting(name, field, usage string, ptr func(*AppConfig) *string) setting {
+	return setting{name: name, field: field, usage: usage, set: func(cfg *AppConfig, value string) error {
+		*ptr(cfg) = value
+		return nil
+	}}
+}
+
+func intSetting(name, field, usage string, ptr func(*AppConfig) *int) setting {
+	return setting{name: name, field: field, usage: usage, set: func(cfg *AppConfig, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*ptr(cfg) = n
+		return nil
+	}}
+}
+
+// optionalIntSetting sets a number whose zero is a value of its own, not
+// the default.
+func optionalIntSetting(name, field, usage string, ptr func(*AppConfig) **int) setting {
+	return setting{name: name, field: field, usage: usage, set: func(cfg *AppConfig, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*ptr(cfg) = &n
+		return nil
+	}}
+}
+
+func boolSetting(name, field, usage string, ptr func(*AppConfig) **bool) setting {
+	return setting{name: name, field: field, usage: usage, boolean: true, set: func(cfg *AppConfig, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*ptr(cfg) = &b
+		return nil
+	}}
+}
+
+// negatedBoolSetting is the "no-" form of a bool setting.
+func negatedBoolSetting(name, field, usage string, ptr func(*AppConfig) **bool) setting {
+	s := boolSetting(name, field, usage, ptr)
+	set := s.set
+	s.set = func(cfg *AppConfig, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		return set(cfg, strconv.FormatBool(!b))
+	}
+	return s
+}
+
+func (s setting) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+// override is a setting given on the command line.
+type override struct {
+	setting setting
+	value   string
+}
+
+var (
+	// pathFlag is the config file given with --config.
+	pathFlag string
+	// flagOverrides are the settings given as flags, in order.
+	flagOverrides []override
+)
+
+// RegisterFlags adds --config and a flag for every setting to fs. Parsed
+// flags apply to every config loaded afterwards, including reloads.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(pathValue{}, "config", "config file (env "+envPrefix+"CONFIG)")
+	for _, s := range settings {
+		fs.Var(settingValue{s}, s.name, fmt.Sprintf("%s (%s, env %s)", s.usage, s.field, s.env()))
+	}
+}
+
+// Args returns the config flags given so far, for passing them on to
+// another valvefm process.
+func Args() []string {
+	var args []string
+	if pathFlag != "" {
+		args = append(args, "--config="+pathFlag)
+	}
+	for _, o := range flagOverrides {
+		args = append(args, "--"+o.setting.name+"="+o.value)
+	}
+	return args
+}
+
+type pathValue struct{}
+
+func (pathValue) String() string { return pathFlag }
+
+func (pathValue) Set(path string) error {
+	if path == "" {
+		return errors.New("empty path")
+	}
+	pathFlag = path
+	return nil
+}
+
+type settingValue struct{ setting setting }
+
+func (v settingValue) String() string { return "" }
+
+func (v settingValue) IsBoolFlag() bool { return v.setting.boolean }
+
+// Set checks the value on its own, so that --volume=200 fails right away.
+func (v settingValue) Set(value string) error {
+	var probe AppConfig
+	if err := v.setting.set(&probe, value); err != nil {
+		return err
+	}
+	if err := probe.Validate(); err != nil {
+		return errors.New(strings.TrimPrefix(err.Error(), v.setting.field+": "))
+	}
+	flagOverrides = append(flagOverrides, override{v.setting, value})
+	return nil
+}
+
+// applyOverrides applies the environment, then the flags, to cfg. A
+// variable that does not parse is skipped and reported.
+func applyOverrides(cfg *AppConfig) error {
+	var errs []error
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env())
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env(), err))
+		}
+	}
+	for _, o := range flagOverrides {
+		// Flags were checked when they were parsed.
+		_ = o.setting.set(cfg, o.value)
+	}
+	return errors.Join(errs...)
+}
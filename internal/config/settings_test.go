@@ -0,0 +1,198 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useConfigFile points the config at a file holding content and forgets
+// flags parsed by earlier tests.
+func useConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("VALVEFM_CONFIG", path)
+	pathFlag, flagOverrides = "", nil
+	t.Cleanup(func() { pathFlag, flagOverrides = "", nil })
+	return path
+}
+
+func parseFlags(t *testing.T, args ...string) error {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	return fs.Parse(args)
+}
+
+func TestLoadConfig_FlagsOverrideEnvOverridesFile(t *testing.T) {
+	useConfigFile(t, `{"theme": "nord", "startup": {"country": "de", "volume": 40}, "ui": {"page_size": 50}}`)
+	t.Setenv("VALVEFM_COUNTRY", "fr")
+	t.Setenv("VALVEFM_VOLUME", "60")
+	if err := parseFlags(t, "--volume=80", "--key-hints=false", "--autoplay"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := LoadConfig()
+	if cfg.Theme != "nord" || cfg.UI.PageSize != 50 {
+		t.Errorf("file settings = %q, %d", cfg.Theme, cfg.UI.PageSize)
+	}
+	if cfg.Startup.Country != "fr" {
+		t.Errorf("country = %q, want the environment", cfg.Startup.Country)
+	}
+	if cfg.Startup.Volume == nil || *cfg.Startup.Volume != 80 {
+		t.Errorf("volume = %v, want the flag", cfg.Startup.Volume)
+	}
+	if cfg.UI.KeyHints == nil || *cfg.UI.KeyHints || cfg.Startup.Autoplay == nil || !*cfg.Startup.Autoplay {
+		t.Errorf("bool flags = %v, %v", cfg.UI.KeyHints, cfg.Startup.Autoplay)
+	}
+
+	if got := strings.Join(Args(), " "); got != "--volume=80 --key-hints=false --autoplay=true" {
+		t.Errorf("Args() = %q", got)
+	}
+}
+
+func TestRegisterFlags_RejectsBadValues(t *testing.T) {
+	useConfigFile(t, "")
+	for _, arg := range []string{"--volume=200", "--volume=loud", "--player=vlc", "--api-mirror=ftp://x", "--autoplay=maybe"} {
+		if err := parseFlags(t, arg); err == nil {
+			t.Errorf("%s was accepted", arg)
+		}
+	}
+	if len(flagOverrides) != 0 {
+		t.Errorf("rejected flags were kept: %+v", flagOverrides)
+	}
+}
+
+func TestConfigFlag_MovesTheFile(t *testing.T) {
+	useConfigFile(t, `{"theme": "nord"}`)
+	other := filepath.Join(t.TempDir(), "other.json")
+	if err := parseFlags(t, "--config", other); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTheme("tokyo-night"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(other); err != nil || !strings.Contains(string(data), "tokyo-night") {
+		t.Errorf("--config file = %s, %v", data, err)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	off := false
+	cfg := AppConfig{Startup: StartupConfig{Country: "de"}, UI: UIConfig{KeyHints: &off}}.WithDefaults()
+	if cfg.Startup.Source != "favorites" || cfg.Startup.Country != "DE" || *cfg.Startup.Volume != 100 {
+		t.Errorf("startup = %+v", cfg.Startup)
+	}
+	if cfg.Player.Backend != "auto" || cfg.UI.PageSize != 200 {
+		t.Errorf("player = %+v, ui = %+v", cfg.Player, cfg.UI)
+	}
+	if !*cfg.Startup.Autoplay || !*cfg.UI.TuningStatic || *cfg.UI.KeyHints {
+		t.Errorf("bools = %v %v %v", *cfg.Startup.Autoplay, *cfg.UI.TuningStatic, *cfg.UI.KeyHints)
+	}
+}
+
+func TestStartupVolume_Muted(t *testing.T) {
+	useConfigFile(t, `{"startup": {"volume": 0}}`)
+	if cfg := LoadConfig().WithDefaults(); *cfg.Startup.Volume != 0 {
+		t.Errorf("file volume 0 = %d after defaults, want 0", *cfg.Startup.Volume)
+	}
+
+	useConfigFile(t, `{"startup": {"volume": 40}}`)
+	if err := parseFlags(t, "--volume=0"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := LoadConfig().WithDefaults(); *cfg.Startup.Volume != 0 {
+		t.Errorf("--volume=0 = %d after defaults, want 0", *cfg.Startup.Volume)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (AppConfig{}).Validate(); err != nil {
+		t.Errorf("empty config: %v", err)
+	}
+	cfg := AppConfig{
+		Startup: StartupConfig{Source: "group", Country: "USA"},
+		UI:      UIConfig{PageSize: 1000},
+		Keys:    map[string][]string{"favorite": {"x"}, "search": {"x"}, "dance": {"d"}},
+		Hooks:   []Hook{{Event: "begin", Command: "true"}},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted a bad config")
+	}
+	for _, want := range []string{"startup.source", "startup.country", "ui.page_size", "keys.dance", `keys.search: "x" is already bound to favorite`, "hooks[0]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestDefaultKeys_CoverKeyActions(t *testing.T) {
+	actions := slices.Sorted(maps.Keys(DefaultKeys))
+	if want := slices.Sorted(slices.Values(KeyActions)); !slices.Equal(actions, want) {
+		t.Errorf("DefaultKeys has %v, KeyActions has %v", actions, want)
+	}
+}
+
+func TestValidate_KeysTakenByDefaults(t *testing.T) {
+	cfg := AppConfig{Keys: map[string][]string{"search": {"q"}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `keys.search: "q" is the default key of quit`) {
+		t.Errorf("Validate() = %v, want quit's q reported", err)
+	}
+
+	for _, keys := range []map[string][]string{
+		{"search": {"q"}, "quit": {"ctrl+q"}},
+		{"favorite": {"f", "x"}},
+	} {
+		if err := (AppConfig{Keys: keys}).Validate(); err != nil {
+			t.Errorf("Validate(%v) = %v", keys, err)
+		}
+	}
+}
+
+func TestRun_ValidateAndPrint(t *testing.T) {
+	path := useConfigFile(t, `{"theme": "nord", "startup": {"volume": 150}, "colour": "red"}`)
+
+	var stdout, stderr bytes.Buffer
+	if code := Run("valvefm config", []string{"validate"}, &stdout, &stderr); code != ExitError {
+		t.Fatalf("validate = %d, want %d", code, ExitError)
+	}
+	if !strings.Contains(stderr.String(), path+`: json: unknown field "colour"`) {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"theme": "nord", "startup": {"volume": 150}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := Run("valvefm config", []string{"validate"}, &stdout, &stderr); code != ExitError || !strings.Contains(stderr.String(), "startup.volume") {
+		t.Errorf("validate = %d, %q", code, stderr.String())
+	}
+
+	t.Setenv("VALVEFM_VOLUME", "30")
+	stdout.Reset()
+	if code := Run("valvefm config", []string{"validate"}, &stdout, &stderr); code != ExitOK {
+		t.Errorf("validate with VALVEFM_VOLUME = %d", code)
+	}
+	stdout.Reset()
+	if code := Run("valvefm config", []string{"print"}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("print = %d", code)
+	}
+	for _, want := range []string{`"theme": "nord"`, `"volume": 30`, `"page_size": 200`, `"backend": "auto"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("print output lacks %s:\n%s", want, stdout.String())
+		}
+	}
+}
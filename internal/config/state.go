@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// State is where valvefm left off: the list shown, the selected station
+// and whether it was playing. It lives in state.json, apart from the
+// settings, and is rewritten as it changes.
+type State struct {
+	Source  string   `json:"source,omitempty"` // "favorites", "country" or "recent"
+	Group   string   `json:"group,omitempty"`  // the favorites group shown
+	Country string   `json:"country,omitempty"`
+	Search  string   `json:"search,omitempty"`
+	Station Favorite `json:"station"` // the station playing, or else the one selected
+	Playing bool     `json:"playing,omitempty"`
+}
+
+// StateFile keeps State on disk. It is safe for concurrent use; saves are
+// written one at a time.
+type StateFile struct {
+	path string
+
+	mu    sync.Mutex
+	saved State
+}
+
+// LoadState reads state.json from the valvefm config directory. A missing
+// or damaged file is an empty state: it is only a convenience.
+func LoadState() (*StateFile, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+	f := &StateFile{path: path}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(data, &f.saved)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return f, err
+	}
+	return f, nil
+}
+
+// State returns the state last read or saved.
+func (f *StateFile) State() State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.saved
+}
+
+// Save writes the state unless it is what the file already holds.
+func (f *StateFile) Save(state State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if state == f.saved {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	if err := replaceFile(f.path, data); err != nil {
+		return err
+	}
+	f.saved = state
+	return nil
+}
+
+func statePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "valvefm", "state.json"), nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStateFile_SaveAndLoad(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	file, err := LoadState()
+	if err != nil || file.State() != (State{}) {
+		t.Fatalf("LoadState() = %+v, %v, want an empty state", file, err)
+	}
+	state := State{Source: "favorites", Group: "Jazz", Country: "DE", Station: Favorite{UUID: "a", Name: "A"}, Playing: true}
+	if err := file.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same state again does not touch the file.
+	if err := file.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(file.path); !after.ModTime().Equal(info.ModTime()) {
+		t.Error("Save() rewrote an unchanged state")
+	}
+
+	reloaded, err := LoadState()
+	if err != nil || reloaded.State() != state {
+		t.Errorf("LoadState() = %+v, %v, want %+v", reloaded.State(), err, state)
+	}
+}
+
+func TestLoadState_IgnoresDamagedFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	path, err := statePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(`{"source": `)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadState()
+	if err != nil || file.State() != (State{}) {
+		t.Errorf("LoadState() = %+v, %v", file.State(), err)
+	}
+}
+
+func TestNoAutoplayFlag(t *testing.T) {
+	useConfigFile(t, `{"startup": {"autoplay": true}}`)
+	if err := parseFlags(t, "--no-autoplay"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := LoadConfig(); cfg.Startup.Autoplay == nil || *cfg.Startup.Autoplay {
+		t.Errorf("autoplay = %v, want false", cfg.Startup.Autoplay)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Defaults for the settings whose zero value means "the default".
+const (
+	DefaultSource   = "favorites" // falls back to country without favorites
+	DefaultCountry  = "US"
+	DefaultVolume   = 100
+	DefaultBackend  = "auto"
+	DefaultPageSize = 200
+	MaxPageSize     = 500
+)
+
+// Sources and Backends list the accepted startup.source and
+// player.backend values.
+var (
+	Sources  = []string{"favorites", "country", "recent"}
+	Backends = []string{"auto", "native", "mpv", "ffplay"}
+)
+
+// KeyActions lists the actions the keys section can rebind.
+var KeyActions = []string{
+	"quit", "help", "play", "toggle", "next_page", "prev_page",
+	"country", "favorites", "recent", "stats", "bookmark", "bookmarks",
+	"search", "favorite", "groups", "next_group", "prev_group", "move_up",
+	"move_down", "custom", "edit", "theme", "audio", "sleep", "alarms",
+}
+
+// DefaultKeys are the keys of every action in KeyActions. The TUI key
+// handler switches on the first key of each action.
+var DefaultKeys = map[string][]string{
+	"quit":       {"q"},
+	"help":       {"?"},
+	"play":       {"enter"},
+	"toggle":     {" "},
+	"next_page":  {"]", "pgdown"},
+	"prev_page":  {"[", "pgup"},
+	"country":    {"l", "L"},
+	"favorites":  {"v", "V"},
+	"recent":     {"r", "R"},
+	"stats":      {"s", "S"},
+	"bookmark":   {"b"},
+	"bookmarks":  {"B"},
+	"search":     {"/"},
+	"favorite":   {"f", "F"},
+	"groups":     {"g", "G"},
+	"next_group": {"tab"},
+	"prev_group": {"shift+tab"},
+	"move_up":    {"shift+up", "K"},
+	"move_down":  {"shift+down", "J"},
+	"custom":     {"c", "C"},
+	"edit":       {"e", "E"},
+	"theme":      {"t", "T"},
+	"audio":      {"a", "A"},
+	"sleep":      {"z", "Z"},
+	"alarms":     {"w", "W"},
+}
+
+// hookEvents are the events a hook can run on.
+var hookEvents = []string{"start", "stop", "track", "error"}
+
+// WithDefaults returns the config with every unset setting that has a
+// default spelled out, as "valvefm config print" shows it.
+func (c AppConfig) WithDefaults() AppConfig {
+	if c.Startup.Source == "" {
+		c.Startup.Source = DefaultSource
+	}
+	if c.Startup.Country == "" {
+		c.Startup.Country = DefaultCountry
+	}
+	c.Startup.Country = strings.ToUpper(c.Startup.Country)
+	c.Startup.Autoplay = orTrue(c.Startup.Autoplay)
+	if c.Startup.Volume == nil {
+		volume := DefaultVolume
+		c.Startup.Volume = &volume
+	}
+	if c.Player.Backend == "" {
+		c.Player.Backend = DefaultBackend
+	}
+	if c.UI.PageSize == 0 {
+		c.UI.PageSize = DefaultPageSize
+	}
+	c.UI.TuningStatic = orTrue(c.UI.TuningStatic)
+	c.UI.KeyHints = orTrue(c.UI.KeyHints)
+	return c
+}
+
+// orTrue copies a setting that is on unless it says otherwise.
+func orTrue(b *bool) *bool {
+	on := b == nil || *b
+	return &on
+}
+
+// Validate reports every setting that is out of range or unknown, one
+// error per setting. The theme is not checked: an unknown one falls back
+// to the default theme.
+func (c AppConfig) Validate() error {
+	var errs []error
+	add := func(field string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if s := c.Startup.Source; s != "" && !slices.Contains(Sources, s) {
+		add("startup.source", "%q is not one of %s", s, strings.Join(Sources, ", "))
+	}
+	if country := c.Startup.Country; country != "" && !isCountryCode(country) {
+		add("startup.country", "%q is not a two-letter country code", country)
+	}
+	if v := c.Startup.Volume; v != nil && (*v < 0 || *v > 100) {
+		add("startup.volume", "%d is not between 0 and 100", *v)
+	}
+	if b := c.Player.Backend; b != "" && !slices.Contains(Backends, b) {
+		add("player.backend", "%q is not one of %s", b, strings.Join(Backends, ", "))
+	}
+	if m := c.API.Mirror; m != "" {
+		if u, err := url.Parse(m); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("api.mirror", "%q is not an http or https URL", m)
+		}
+	}
+	if n := c.UI.PageSize; n < 0 || n > MaxPageSize {
+		add("ui.page_size", "%d is not between 1 and %d", n, MaxPageSize)
+	}
+
+	boundTo := make(map[string]string)
+	for _, action := range sortedKeys(c.Keys) {
+		if !slices.Contains(KeyActions, action) {
+			add("keys."+action, "unknown action; use one of %s", strings.Join(KeyActions, ", "))
+			continue
+		}
+		if len(c.Keys[action]) == 0 {
+			add("keys."+action, "no keys given")
+		}
+		for _, key := range c.Keys[action] {
+			other, taken := boundTo[key]
+			owner := defaultAction(key, c.Keys)
+			switch {
+			case strings.TrimSpace(key) == "":
+				add("keys."+action, "empty key")
+			case taken:
+				add("keys."+action, "%q is already bound to %s", key, other)
+			case owner != "":
+				// It would leave owner without a key.
+				add("keys."+action, "%q is the default key of %s; rebind %s too", key, owner, owner)
+			default:
+				boundTo[key] = action
+			}
+		}
+	}
+
+	if r := c.Audio.SampleRate; r != 0 && (r < 8000 || r > 192000) {
+		add("audio.sample_rate", "%d Hz is not between 8000 and 192000", r)
+	}
+	if c.Audio.BufferMs < 0 {
+		add("audio.buffer_ms", "%d is negative", c.Audio.BufferMs)
+	}
+	if c.Notifications.MinInterval < 0 {
+		add("notifications.min_interval", "%d is negative", c.Notifications.MinInterval)
+	}
+	if addr := c.HTTP.Addr; addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			add("http.addr", "%v", err)
+		}
+	}
+	for i, alarm := range c.Alarms {
+		if err := alarm.Validate(); err != nil {
+			add(fmt.Sprintf("alarms[%d]", i), "%v", err)
+		}
+	}
+	for i, hook := range c.Hooks {
+		field := fmt.Sprintf("hooks[%d]", i)
+		if !slices.Contains(hookEvents, hook.Event) {
+			add(field, "unknown event %q; use one of %s", hook.Event, strings.Join(hookEvents, ", "))
+		}
+		if strings.TrimSpace(hook.Command) == "" {
+			add(field, "command is required")
+		}
+		if hook.Timeout < 0 {
+			add(field, "timeout %d is negative", hook.Timeout)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultAction returns the action that keeps key as its default key
+// because bindings do not rebind it, or "".
+func defaultAction(key string, bindings map[string][]string) string {
+	for _, action := range KeyActions {
+		if _, rebound := bindings[action]; !rebound && slices.Contains(DefaultKeys[action], key) {
+			return action
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
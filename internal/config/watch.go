@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// Watcher notices when config.json or favorites.json change on disk, as
+// after a hand edit or a dotfiles sync, by comparing their size and
+// modification time between calls to Changed.
+type Watcher struct {
+	configPath    string
+	favoritesPath string
+	config        fileState
+	favorites     fileState
+}
+
+// fileState is a file's size and modification time; the zero value stands
+// for a missing file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{size: info.Size(), modTime: info.ModTime()}
+}
+
+func (s fileState) same(other fileState) bool {
+	return s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+// NewWatcher starts from the files as they are now.
+func NewWatcher() (*Watcher, error) {
+	configPath, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	favoritesPath, err := favoritesPath()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{configPath: configPath, favoritesPath: favoritesPath}
+	w.config = statFile(configPath)
+	w.favorites = statFile(favoritesPath)
+	return w, nil
+}
+
+// Changed reports which files changed since the last call. Writes made by
+// this process count too; Favorites.Reload and comparing configs tell them
+// apart.
+func (w *Watcher) Changed() (configChanged, favoritesChanged bool) {
+	config, favorites := statFile(w.configPath), statFile(w.favoritesPath)
+	configChanged, favoritesChanged = !config.same(w.config), !favorites.same(w.favorites)
+	w.config, w.favorites = config, favorites
+	return configChanged, favoritesChanged
+}
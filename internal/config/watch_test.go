@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"radio-tui/internal/radio"
+)
+
+func TestWatcher_Changed(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	w, err := NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, f := w.Changed(); c || f {
+		t.Errorf("Changed() = %v, %v with no files", c, f)
+	}
+
+	if err := SaveTheme("nord"); err != nil {
+		t.Fatal(err)
+	}
+	if c, f := w.Changed(); !c || f {
+		t.Errorf("Changed() = %v, %v after saving the config", c, f)
+	}
+	if c, f := w.Changed(); c || f {
+		t.Errorf("Changed() = %v, %v twice for one change", c, f)
+	}
+
+	path, _ := favoritesPath()
+	if err := os.WriteFile(path, []byte(`{"version": 4, "stations": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Same size as before: only the modification time tells.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, f := w.Changed(); !f {
+		t.Error("Changed() missed the new favorites file")
+	}
+}
+
+func TestFavorites_Reload(t *testing.T) {
+	favs := newTestFavorites(t)
+	if _, err := favs.Toggle(radio.Station{UUID: "a", Name: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := favs.Reload(); err != nil || changed {
+		t.Errorf("Reload() = %v, %v after this process's own write", changed, err)
+	}
+
+	edited := `{"version": 4, "stations": [{"uuid": "a", "name": "A"}, {"uuid": "b", "name": "B"}], "groups": [{"name": "Mix", "stations": ["b"]}]}`
+	if err := os.WriteFile(favs.path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := favs.Reload(); err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v after a hand edit", changed, err)
+	}
+	if favs.Count() != 2 || !favs.InGroup("b", "Mix") {
+		t.Errorf("reloaded favorites = %+v, groups %+v", favs.List(), favs.Groups())
+	}
+
+	// Halfway through an edit: keep what was loaded, leave the file be.
+	if err := os.WriteFile(favs.path, []byte(`{"version": 4, "stations": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := favs.Reload(); err == nil {
+		t.Error("Reload() accepted a file that does not parse")
+	}
+	if favs.Count() != 2 {
+		t.Errorf("Count() = %d after a failed reload, want 2", favs.Count())
+	}
+	if data, _ := os.ReadFile(favs.path); string(data) != `{"version": 4, "stations": [` {
+		t.Errorf("Reload() rewrote the file: %s", data)
+	}
+}
+
+func TestReadConfig_ReportsParseErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if cfg, err := ReadConfig(); err != nil || cfg.Theme != "" {
+		t.Errorf("ReadConfig() = %+v, %v without a file", cfg, err)
+	}
+	if err := SaveTheme("nord"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := ReadConfig(); err != nil || cfg.Theme != "nord" {
+		t.Errorf("ReadConfig() = %+v, %v", cfg, err)
+	}
+	path, _ := configPath()
+	if err := os.WriteFile(path, []byte(`{"theme": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadConfig(); err == nil {
+		t.Error("ReadConfig() accepted a file that does not parse")
+	}
+}
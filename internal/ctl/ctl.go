@@ -0,0 +1,528 @@
+// Package ctl implements the command-line control client, a thin wrapper
+// over the IPC protocol meant for hotkeys, scripts and status bars.
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// Exit codes.
+const (
+	ExitOK         = 0
+	ExitError      = 1 // the session rejected or failed the command
+	ExitUsage      = 2 // the command line is wrong
+	ExitNotRunning = 3 // no valvefm session is listening
+)
+
+// conn is the part of ipc.Client the commands use.
+type conn interface {
+	Call(cmd string, args any, result any) error
+	Close() error
+}
+
+type runner struct {
+	name   string
+	dial   func() (conn, error)
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+}
+
+// Run executes the command line args (without the program name) against
+// the running session and returns the exit code. name is the program name
+// shown in messages.
+func Run(name string, args []string, stdout, stderr io.Writer) int {
+	r := &runner{
+		name:   name,
+		dial:   func() (conn, error) { return ipc.Dial() },
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return r.run(args)
+}
+
+func (r *runner) run(args []string) int {
+	flags := r.flags(r.name)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		r.usage()
+		return ExitUsage
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "play":
+		return r.play(args)
+	case "pause", "stop":
+		return r.simple(command, ipc.CmdStop, args)
+	case "toggle":
+		return r.simple(command, ipc.CmdPlayPause, args)
+	case "next":
+		return r.simple(command, ipc.CmdNext, args)
+	case "prev":
+		return r.simple(command, ipc.CmdPrev, args)
+	case "quit":
+		return r.simple(command, ipc.CmdQuit, args)
+	case "status":
+		return r.status(args)
+	case "fav":
+		return r.favorite(args)
+	case "volume":
+		return r.volume(args)
+	case "mute":
+		return r.mute(args)
+	case "recent":
+		return r.recent(args)
+	case "bookmark":
+		return r.bookmark(args)
+	case "preset":
+		return r.preset(args)
+	case "groups":
+		return r.groups(args)
+	case "group":
+		return r.group(args)
+	case "search":
+		return r.search(args)
+	case "help":
+		r.usage()
+		return ExitOK
+	default:
+		fmt.Fprintf(r.stderr, "%s: unknown command %q\n", r.name, command)
+		r.usage()
+		return ExitUsage
+	}
+}
+
+// flags returns a flag set understanding --json, which any command accepts.
+func (r *runner) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(r.stderr)
+	flags.BoolVar(&r.json, "json", r.json, "print machine-readable JSON")
+	flags.Usage = r.usage
+	return flags
+}
+
+func (r *runner) usage() {
+	fmt.Fprintf(r.stderr, `usage: %s [--json] <command> [args]
+
+commands:
+  play [uuid|name]         play a station, or resume the last one
+  pause                    stop playback
+  toggle                   play or stop
+  next, prev               tune to the next or previous station
+  status                   show what is playing
+  fav add|remove [uuid]    add or remove a favorite (the playing station by default)
+  volume [n|+n|-n]         show or set the volume (0-100)
+  mute [on|off]            mute or unmute, or toggle
+  recent                   list the stations played this session
+  bookmark                 bookmark the song playing
+  preset <1-9>             play a preset, one of the first nine favorites
+  groups                   list the favorite groups
+  group [name|all]         show or switch the favorites group next and prev use
+  search [--country CC] [--limit N] <query>
+                           search the station directory
+  quit                     stop the session
+
+exit codes: 0 ok, 1 command failed, 2 usage error, 3 valvefm not running
+`, r.name)
+}
+
+// call runs one request, reporting failures and mapping them to exit codes.
+func (r *runner) call(cmd string, args any, result any) int {
+	c, err := r.dial()
+	if err != nil {
+		r.fail(&ipc.Error{Code: ipc.ErrUnavailable, Message: "valvefm is not running"})
+		return ExitNotRunning
+	}
+	defer c.Close()
+
+	if err := c.Call(cmd, args, result); err != nil {
+		r.fail(ipc.AsError(err))
+		return ExitError
+	}
+	return ExitOK
+}
+
+func (r *runner) fail(err *ipc.Error) {
+	if r.json {
+		r.print(struct {
+			OK    bool       `json:"ok"`
+			Error *ipc.Error `json:"error"`
+		}{Error: err})
+		return
+	}
+	fmt.Fprintf(r.stderr, "%s: %s\n", r.name, err.Message)
+}
+
+// done reports a command without output of its own.
+func (r *runner) done() {
+	if r.json {
+		r.print(struct {
+			OK bool `json:"ok"`
+		}{OK: true})
+	}
+}
+
+func (r *runner) print(v any) {
+	encoder := json.NewEncoder(r.stdout)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+}
+
+// parse parses a command's flags and checks its argument count.
+func (r *runner) parse(flags *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, bool) {
+	if err := flags.Parse(args); err != nil {
+		return nil, false
+	}
+	args = flags.Args()
+	if len(args) < minArgs || maxArgs >= 0 && len(args) > maxArgs {
+		fmt.Fprintf(r.stderr, "%s: wrong number of arguments for %s\n", r.name, flags.Name())
+		return nil, false
+	}
+	return args, true
+}
+
+func (r *runner) simple(command, cmd string, args []string) int {
+	if _, ok := r.parse(r.flags(command), args, 0, 0); !ok {
+		return ExitUsage
+	}
+	code := r.call(cmd, nil, nil)
+	if code == ExitOK {
+		r.done()
+	}
+	return code
+}
+
+func (r *runner) play(args []string) int {
+	args, ok := r.parse(r.flags("play"), args, 0, -1)
+	if !ok {
+		return ExitUsage
+	}
+	var playArgs any
+	if target := PlayTarget(args); target != (ipc.PlayArgs{}) {
+		playArgs = target
+	}
+	code := r.call(ipc.CmdPlay, playArgs, nil)
+	if code == ExitOK {
+		r.done()
+	}
+	return code
+}
+
+// PlayTarget reads the arguments of "play" as a station UUID or custom
+// station id or, failing that, a name. No arguments give empty PlayArgs, which resume the last
+// station.
+func PlayTarget(args []string) ipc.PlayArgs {
+	target := strings.Join(args, " ")
+	if looksLikeUUID(target) || config.IsCustom(target) {
+		return ipc.PlayArgs{UUID: target}
+	}
+	return ipc.PlayArgs{Name: target}
+}
+
+// IsCommand reports whether name is a command Run understands, so that
+// the app can forward "valvefm play <uuid>" to a running session.
+func IsCommand(name string) bool {
+	switch name {
+	case "play", "pause", "stop", "toggle", "next", "prev", "quit", "status", "fav", "volume", "mute", "recent", "bookmark", "preset", "groups", "group", "search":
+		return true
+	}
+	return false
+}
+
+func (r *runner) status(args []string) int {
+	if _, ok := r.parse(r.flags("status"), args, 0, 0); !ok {
+		return ExitUsage
+	}
+	var status ipc.Status
+	if code := r.call(ipc.CmdStatus, nil, &status); code != ExitOK {
+		return code
+	}
+	if r.json {
+		r.print(status)
+		return ExitOK
+	}
+	fmt.Fprintln(r.stdout, StatusLine(status))
+	return ExitOK
+}
+
+// StatusLine renders a status as one line for status bars, such as
+// "▶ Jazz FM - Artist - Song" or "■ Jazz FM".
+func StatusLine(status ipc.Status) string {
+	if !status.Playing {
+		return "■ " + status.Station
+	}
+	line := "▶ " + status.Station
+	if status.Title != "" {
+		line += " - " + status.Title
+	}
+	return line
+}
+
+func (r *runner) favorite(args []string) int {
+	args, ok := r.parse(r.flags("fav"), args, 1, 2)
+	if !ok {
+		return ExitUsage
+	}
+	var favArgs ipc.FavoriteArgs
+	switch args[0] {
+	case "add":
+	case "remove":
+		favArgs.Remove = true
+	default:
+		fmt.Fprintf(r.stderr, "%s: fav takes add or remove, not %q\n", r.name, args[0])
+		return ExitUsage
+	}
+	if len(args) > 1 {
+		favArgs.UUID = args[1]
+	}
+	code := r.call(ipc.CmdFavorite, favArgs, nil)
+	if code == ExitOK {
+		r.done()
+	}
+	return code
+}
+
+func (r *runner) volume(args []string) int {
+	// "-5" is a step down, not a flag.
+	var levels, rest []string
+	for _, arg := range args {
+		if _, err := strconv.Atoi(arg); err == nil {
+			levels = append(levels, arg)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	rest, ok := r.parse(r.flags("volume"), rest, 0, -1)
+	if !ok {
+		return ExitUsage
+	}
+	levels = append(levels, rest...)
+	if len(levels) > 1 {
+		fmt.Fprintf(r.stderr, "%s: wrong number of arguments for volume\n", r.name)
+		return ExitUsage
+	}
+
+	var volumeArgs any
+	if len(levels) == 1 {
+		parsed, err := ipc.ParseVolume(levels[0])
+		if err != nil {
+			fmt.Fprintf(r.stderr, "%s: volume takes a level (0-100) or a step (+n, -n)\n", r.name)
+			return ExitUsage
+		}
+		volumeArgs = parsed
+	}
+	var volume ipc.VolumeStatus
+	if code := r.call(ipc.CmdVolume, volumeArgs, &volume); code != ExitOK {
+		return code
+	}
+	if r.json {
+		r.print(volume)
+		return ExitOK
+	}
+	fmt.Fprintln(r.stdout, volume.Level)
+	return ExitOK
+}
+
+func (r *runner) mute(args []string) int {
+	args, ok := r.parse(r.flags("mute"), args, 0, 1)
+	if !ok {
+		return ExitUsage
+	}
+	var muteArgs any
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			muteArgs = ipc.MuteArgs{Muted: true}
+		case "off":
+			muteArgs = ipc.MuteArgs{Muted: false}
+		default:
+			fmt.Fprintf(r.stderr, "%s: mute takes on or off, not %q\n", r.name, args[0])
+			return ExitUsage
+		}
+	}
+	var volume ipc.VolumeStatus
+	if code := r.call(ipc.CmdMute, muteArgs, &volume); code != ExitOK {
+		return code
+	}
+	if r.json {
+		r.print(volume)
+		return ExitOK
+	}
+	if volume.Muted {
+		fmt.Fprintln(r.stdout, "muted")
+	} else {
+		fmt.Fprintln(r.stdout, volume.Level)
+	}
+	return ExitOK
+}
+
+func (r *runner) recent(args []string) int {
+	if _, ok := r.parse(r.flags("recent"), args, 0, 0); !ok {
+		return ExitUsage
+	}
+	var stations []ipc.Station
+	if code := r.call(ipc.CmdRecent, nil, &stations); code != ExitOK {
+		return code
+	}
+	r.printStations(stations)
+	return ExitOK
+}
+
+func (r *runner) bookmark(args []string) int {
+	if _, ok := r.parse(r.flags("bookmark"), args, 0, 0); !ok {
+		return ExitUsage
+	}
+	var bookmark ipc.Bookmark
+	if code := r.call(ipc.CmdBookmark, nil, &bookmark); code != ExitOK {
+		return code
+	}
+	if r.json {
+		r.print(bookmark)
+		return ExitOK
+	}
+	fmt.Fprintf(r.stdout, "%s (%s)\n", bookmark.Title, bookmark.Station)
+	return ExitOK
+}
+
+func (r *runner) preset(args []string) int {
+	args, ok := r.parse(r.flags("preset"), args, 1, 1)
+	if !ok {
+		return ExitUsage
+	}
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(r.stderr, "%s: preset takes a number from 1 to 9, not %q\n", r.name, args[0])
+		return ExitUsage
+	}
+	code := r.call(ipc.CmdPreset, ipc.PresetArgs{Number: number}, nil)
+	if code == ExitOK {
+		r.done()
+	}
+	return code
+}
+
+func (r *runner) groups(args []string) int {
+	if _, ok := r.parse(r.flags("groups"), args, 0, 0); !ok {
+		return ExitUsage
+	}
+	var groups []ipc.Group
+	if code := r.call(ipc.CmdGroups, nil, &groups); code != ExitOK {
+		return code
+	}
+	if r.json {
+		if groups == nil {
+			groups = []ipc.Group{}
+		}
+		r.print(groups)
+		return ExitOK
+	}
+	for _, group := range groups {
+		marker := " "
+		if group.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(r.stdout, "%s %s\t%d\n", marker, group.Name, group.Stations)
+	}
+	return ExitOK
+}
+
+func (r *runner) group(args []string) int {
+	args, ok := r.parse(r.flags("group"), args, 0, -1)
+	if !ok {
+		return ExitUsage
+	}
+	if len(args) > 0 {
+		name := strings.Join(args, " ")
+		if strings.EqualFold(name, "all") {
+			name = ""
+		}
+		if code := r.call(ipc.CmdGroup, ipc.GroupArgs{Name: name}, nil); code != ExitOK {
+			return code
+		}
+		r.done()
+		return ExitOK
+	}
+	var current ipc.GroupArgs
+	if code := r.call(ipc.CmdGroup, nil, &current); code != ExitOK {
+		return code
+	}
+	if r.json {
+		r.print(current)
+		return ExitOK
+	}
+	if current.Name == "" {
+		current.Name = "all"
+	}
+	fmt.Fprintln(r.stdout, current.Name)
+	return ExitOK
+}
+
+func (r *runner) search(args []string) int {
+	flags := r.flags("search")
+	var searchArgs ipc.SearchArgs
+	flags.StringVar(&searchArgs.Country, "country", "", "country code, the session's by default")
+	flags.IntVar(&searchArgs.Limit, "limit", 0, "maximum number of results")
+	args, ok := r.parse(flags, args, 1, -1)
+	if !ok {
+		return ExitUsage
+	}
+	searchArgs.Query = strings.Join(args, " ")
+
+	var stations []ipc.Station
+	if code := r.call(ipc.CmdSearch, searchArgs, &stations); code != ExitOK {
+		return code
+	}
+	r.printStations(stations)
+	return ExitOK
+}
+
+// printStations lists stations one per line as UUID, name and country.
+func (r *runner) printStations(stations []ipc.Station) {
+	if r.json {
+		if stations == nil {
+			stations = []ipc.Station{}
+		}
+		r.print(stations)
+		return
+	}
+	for _, station := range stations {
+		fmt.Fprintf(r.stdout, "%s\t%s\t%s\n", station.UUID, station.Name, station.Country)
+	}
+}
+
+// looksLikeUUID reports whether s has the 8-4-4-4-12 hex layout of a
+// station UUID, so anything else can be treated as a name.
+func looksLikeUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"radio-tui/internal/ipc"
+)
+
+// fakeConn records calls and answers them from a table.
+type fakeConn struct {
+	calls   []string
+	args    []any
+	results map[string]any
+	errs    map[string]error
+}
+
+func (c *fakeConn) Call(cmd string, args any, result any) error {
+	c.calls = append(c.calls, cmd)
+	c.args = append(c.args, args)
+	if err := c.errs[cmd]; err != nil {
+		return err
+	}
+	if value, ok := c.results[cmd]; ok && result != nil {
+		data, _ := json.Marshal(value)
+		return json.Unmarshal(data, result)
+	}
+	return nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func runWith(c *fakeConn, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	r := &runner{
+		name:   "valvefm-ctl",
+		stdout: &stdout,
+		stderr: &stderr,
+		dial: func() (conn, error) {
+			if c == nil {
+				return nil, errors.New("connection refused")
+			}
+			return c, nil
+		},
+	}
+	code := r.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_SimpleCommands(t *testing.T) {
+	tests := map[string]string{
+		"pause":  ipc.CmdStop,
+		"toggle": ipc.CmdPlayPause,
+		"next":   ipc.CmdNext,
+		"prev":   ipc.CmdPrev,
+		"quit":   ipc.CmdQuit,
+	}
+	for command, cmd := range tests {
+		t.Run(command, func(t *testing.T) {
+			c := &fakeConn{}
+			code, stdout, _ := runWith(c, command)
+			if code != ExitOK || stdout != "" {
+				t.Errorf("exit = %d, stdout = %q, want 0 and no output", code, stdout)
+			}
+			if len(c.calls) != 1 || c.calls[0] != cmd {
+				t.Errorf("calls = %v, want [%s]", c.calls, cmd)
+			}
+		})
+	}
+}
+
+func TestRun_PlayByUUIDOrName(t *testing.T) {
+	c := &fakeConn{}
+	runWith(c, "play", "9617a958-0601-11e8-ae97-52543be04c81")
+	runWith(c, "play", "jazz", "fm")
+	runWith(c, "play")
+
+	if got := c.args[0].(ipc.PlayArgs); got.UUID != "9617a958-0601-11e8-ae97-52543be04c81" || got.Name != "" {
+		t.Errorf("play uuid args = %+v", got)
+	}
+	if got := c.args[1].(ipc.PlayArgs); got.Name != "jazz fm" || got.UUID != "" {
+		t.Errorf("play name args = %+v, want name \"jazz fm\"", got)
+	}
+	if c.args[2] != nil {
+		t.Errorf("bare play args = %+v, want none so the last station resumes", c.args[2])
+	}
+}
+
+func TestRun_Status(t *testing.T) {
+	c := &fakeConn{results: map[string]any{
+		ipc.CmdStatus: ipc.Status{Playing: true, Station: "Jazz FM", Title: "Artist - Song", Volume: 80},
+	}}
+
+	code, stdout, _ := runWith(c, "status")
+	if code != ExitOK || stdout != "▶ Jazz FM - Artist - Song\n" {
+		t.Errorf("status = %d %q, want the one-line form", code, stdout)
+	}
+
+	code, stdout, _ = runWith(c, "status", "--json")
+	var status ipc.Status
+	if err := json.Unmarshal([]byte(stdout), &status); code != ExitOK || err != nil || status.Station != "Jazz FM" || status.Volume != 80 {
+		t.Errorf("status --json = %d %q, want the status object", code, stdout)
+	}
+
+	if _, stdout, _ := runWith(c, "--json", "status"); !strings.HasPrefix(stdout, "{") {
+		t.Errorf("--json before the command should work too, got %q", stdout)
+	}
+}
+
+func TestRun_Volume(t *testing.T) {
+	c := &fakeConn{results: map[string]any{ipc.CmdVolume: ipc.VolumeStatus{Level: 45}}}
+
+	code, stdout, _ := runWith(c, "volume", "-5")
+	if code != ExitOK || stdout != "45\n" {
+		t.Errorf("volume -5 = %d %q, want 0 and the new level", code, stdout)
+	}
+	if got := c.args[0].(ipc.VolumeArgs); got.Delta != -5 || got.Level != nil {
+		t.Errorf("volume -5 args = %+v, want a step of -5", got)
+	}
+
+	runWith(c, "volume", "30")
+	if got := c.args[1].(ipc.VolumeArgs); got.Level == nil || *got.Level != 30 {
+		t.Errorf("volume 30 args = %+v, want level 30", got)
+	}
+
+	if code, _, _ := runWith(c, "volume", "loud"); code != ExitUsage {
+		t.Errorf("volume loud exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestRun_Mute(t *testing.T) {
+	c := &fakeConn{results: map[string]any{ipc.CmdMute: ipc.VolumeStatus{Level: 45, Muted: true}}}
+
+	code, stdout, _ := runWith(c, "mute")
+	if code != ExitOK || stdout != "muted\n" || c.args[0] != nil {
+		t.Errorf("mute = %d %q with %v, want a toggle", code, stdout, c.args[0])
+	}
+	runWith(c, "mute", "off")
+	if got := c.args[1].(ipc.MuteArgs); got.Muted {
+		t.Errorf("mute off args = %+v, want unmuted", got)
+	}
+	if code, _, _ := runWith(c, "mute", "loud"); code != ExitUsage {
+		t.Errorf("mute loud exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestRun_Favorite(t *testing.T) {
+	c := &fakeConn{}
+	runWith(c, "fav", "remove", "abc")
+	if got := c.args[0].(ipc.FavoriteArgs); !got.Remove || got.UUID != "abc" {
+		t.Errorf("fav remove args = %+v", got)
+	}
+	if code, _, _ := runWith(c, "fav", "toggle"); code != ExitUsage {
+		t.Errorf("fav toggle exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestRun_Bookmark(t *testing.T) {
+	c := &fakeConn{results: map[string]any{ipc.CmdBookmark: ipc.Bookmark{Title: "Artist - Song", Station: "Jazz FM", Added: true}}}
+	code, stdout, _ := runWith(c, "bookmark")
+	if code != ExitOK || stdout != "Artist - Song (Jazz FM)\n" {
+		t.Errorf("bookmark = %d %q", code, stdout)
+	}
+
+	c = &fakeConn{errs: map[string]error{ipc.CmdBookmark: ipc.Errorf(ipc.ErrUnavailable, "nothing is playing")}}
+	if code, _, stderr := runWith(c, "bookmark"); code != ExitError || !strings.Contains(stderr, "nothing is playing") {
+		t.Errorf("bookmark while stopped = %d %q", code, stderr)
+	}
+}
+
+func TestRun_Preset(t *testing.T) {
+	c := &fakeConn{}
+	if code, _, _ := runWith(c, "preset", "3"); code != ExitOK {
+		t.Fatalf("preset 3 exit = %d", code)
+	}
+	if got := c.args[0].(ipc.PresetArgs); got.Number != 3 {
+		t.Errorf("preset args = %+v, want 3", got)
+	}
+	if code, _, _ := runWith(c, "preset", "jazz"); code != ExitUsage {
+		t.Errorf("preset jazz exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestRun_Groups(t *testing.T) {
+	c := &fakeConn{results: map[string]any{
+		ipc.CmdGroups: []ipc.Group{{Name: "Jazz", Stations: 3, Current: true}, {Name: "News", Stations: 1}},
+		ipc.CmdGroup:  ipc.GroupArgs{},
+	}}
+	code, stdout, _ := runWith(c, "groups")
+	if code != ExitOK || stdout != "* Jazz\t3\n  News\t1\n" {
+		t.Errorf("groups = %d %q", code, stdout)
+	}
+
+	if code, stdout, _ = runWith(c, "group"); code != ExitOK || stdout != "all\n" || c.args[1] != nil {
+		t.Errorf("group = %d %q with %v, want the current group", code, stdout, c.args[1])
+	}
+	runWith(c, "group", "Work", "focus")
+	if got := c.args[2].(ipc.GroupArgs); got.Name != "Work focus" {
+		t.Errorf("group args = %+v, want Work focus", got)
+	}
+	runWith(c, "group", "all")
+	if got := c.args[3].(ipc.GroupArgs); got.Name != "" {
+		t.Errorf("group all args = %+v, want all favorites", got)
+	}
+}
+
+func TestRun_Search(t *testing.T) {
+	c := &fakeConn{results: map[string]any{
+		ipc.CmdSearch: []ipc.Station{{UUID: "u1", Name: "Jazz FM", Country: "US"}},
+	}}
+	code, stdout, _ := runWith(c, "search", "--country", "us", "smooth", "jazz")
+	if code != ExitOK || stdout != "u1\tJazz FM\tUS\n" {
+		t.Errorf("search = %d %q, want one tab-separated line", code, stdout)
+	}
+	if got := c.args[0].(ipc.SearchArgs); got.Query != "smooth jazz" || got.Country != "us" {
+		t.Errorf("search args = %+v", got)
+	}
+	if code, _, _ := runWith(c, "search"); code != ExitUsage {
+		t.Errorf("search without a query exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	if code, _, stderr := runWith(nil, "status"); code != ExitNotRunning || !strings.Contains(stderr, "not running") {
+		t.Errorf("without a session = %d %q, want %d", code, stderr, ExitNotRunning)
+	}
+
+	c := &fakeConn{errs: map[string]error{ipc.CmdNext: ipc.Errorf(ipc.ErrNotFound, "no stations available")}}
+	code, stdout, _ := runWith(c, "--json", "next")
+	if code != ExitError || !strings.Contains(stdout, `"code":"not_found"`) {
+		t.Errorf("failed next = %d %q, want %d and a JSON error", code, stdout, ExitError)
+	}
+
+	if code, _, _ := runWith(c, "dance"); code != ExitUsage {
+		t.Errorf("unknown command exit = %d, want %d", code, ExitUsage)
+	}
+	if code, _, _ := runWith(c, "next", "now"); code != ExitUsage {
+		t.Errorf("extra argument exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestLooksLikeUUID(t *testing.T) {
+	if !looksLikeUUID("9617a958-0601-11e8-ae97-52543be04c81") {
+		t.Error("a station UUID should be recognised")
+	}
+	for _, s := range []string{"", "jazz", "9617a958x0601-11e8-ae97-52543be04c81", "9617a958-0601-11e8-ae97-52543be04c8g"} {
+		if looksLikeUUID(s) {
+			t.Errorf("looksLikeUUID(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestPlayTarget(t *testing.T) {
+	uuid := "9617a958-0601-11e8-ae97-52543be04c81"
+	if got := PlayTarget([]string{uuid}); got.UUID != uuid || got.Name != "" {
+		t.Errorf("PlayTarget(uuid) = %+v, want the UUID", got)
+	}
+	if got := PlayTarget([]string{"custom-3f2a"}); got.UUID != "custom-3f2a" || got.Name != "" {
+		t.Errorf("PlayTarget(custom id) = %+v, want the id", got)
+	}
+	if got := PlayTarget([]string{"jazz", "fm"}); got.Name != "jazz fm" {
+		t.Errorf("PlayTarget(jazz fm) = %+v, want the name", got)
+	}
+	if got := PlayTarget(nil); got != (ipc.PlayArgs{}) {
+		t.Errorf("PlayTarget() = %+v, want empty args", got)
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	if !IsCommand("play") || !IsCommand("volume") {
+		t.Error("IsCommand() should accept control commands")
+	}
+	if IsCommand("daemon") || IsCommand("--json") {
+		t.Error("IsCommand() should refuse anything else")
+	}
+}
@@ -0,0 +1,446 @@
+// Package favorites implements "valvefm favorites", which exports the
+// favorites as playlists other players understand and imports playlists
+// and Radio Browser UUID lists back.
+package favorites
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/playlist"
+	"radio-tui/internal/radio"
+)
+
+// Exit codes, as for ctl.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// lookupTimeout bounds the directory lookups of one import or export.
+const lookupTimeout = 60 * time.Second
+
+// directory is the part of the radio client the command uses.
+type directory interface {
+	StationsByUUIDs(ctx context.Context, uuids []string) ([]radio.Station, error)
+	StationsByURL(ctx context.Context, streamURL string) ([]radio.Station, error)
+}
+
+type command struct {
+	name      string
+	directory func() (directory, error)
+	load      func() (*config.Favorites, error)
+	reload    func() // tells a running session to re-read the favorites
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+}
+
+// Run executes "favorites <args>" and returns the exit code. name is the
+// program name shown in messages.
+func Run(name string, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &command{
+		name: name,
+		directory: func() (directory, error) {
+			return radio.NewClientAt("ValveFM/1.0 (terminal radio)", config.LoadConfig().API.Mirror)
+		},
+		load:   config.LoadFavorites,
+		reload: func() { _ = ipc.Call(ipc.CmdReload, nil, nil) },
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return c.run(args)
+}
+
+func (c *command) run(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return ExitUsage
+	}
+	switch args[0] {
+	case "export":
+		return c.export(args[1:])
+	case "import":
+		return c.importFile(args[1:])
+	case "help", "-h", "--help":
+		c.usage()
+		return ExitOK
+	}
+	fmt.Fprintf(c.stderr, "%s: unknown command %q\n", c.name, args[0])
+	c.usage()
+	return ExitUsage
+}
+
+func (c *command) usage() {
+	fmt.Fprintf(c.stderr, `usage: %s <command> [flags]
+
+commands:
+  export [--format m3u|pls|json|csv] [--group NAME] [-o FILE]
+                   write the favorites, or one group, as a playlist
+  import [--format m3u|pls|json|csv|uuids] [--group NAME] [--dry-run] [FILE|-]
+                   add the stations of a playlist to the favorites
+
+The format defaults to the file extension, then to the content for
+imports and to json for exports.
+`, c.name)
+}
+
+func (c *command) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = c.usage
+	return flags
+}
+
+func (c *command) fail(err error) int {
+	fmt.Fprintf(c.stderr, "%s: %v\n", c.name, err)
+	return ExitError
+}
+
+func (c *command) export(args []string) int {
+	flags := c.flags("export")
+	formatName := flags.String("format", "", "playlist format")
+	group := flags.String("group", "", "export only this group")
+	output := flags.String("o", "", "write to this file instead of standard output")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return ExitUsage
+	}
+
+	format := playlist.JSON
+	switch {
+	case *formatName != "":
+		var err error
+		if format, err = playlist.ParseFormat(*formatName); err != nil || format == playlist.UUIDs {
+			fmt.Fprintf(c.stderr, "%s: cannot export %q; use m3u, pls, json or csv\n", c.name, *formatName)
+			return ExitUsage
+		}
+	case *output != "":
+		if guessed := playlist.FormatOf(*output, nil); guessed != playlist.UUIDs {
+			format = guessed
+		}
+	}
+
+	favs, err := c.load()
+	if err != nil {
+		return c.fail(err)
+	}
+	list := favs.List()
+	if *group != "" {
+		if list, err = favs.ListGroup(*group); err != nil {
+			return c.fail(fmt.Errorf("%s: %w", *group, err))
+		}
+	}
+
+	entries, missing, err := c.exportEntries(list, format)
+	if err != nil {
+		return c.fail(err)
+	}
+	for _, fav := range missing {
+		fmt.Fprintf(c.stderr, "%s: left out %s: no stream URL\n", c.name, fav.Name)
+	}
+
+	var buf bytes.Buffer
+	if err := playlist.Write(&buf, format, entries); err != nil {
+		return c.fail(err)
+	}
+	if *output == "" {
+		_, err = c.stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	return ExitOK
+}
+
+// exportEntries looks up the stream URLs of favorites. M3U and PLS are
+// only stream URLs, so favorites without one are left out of those; JSON
+// and CSV keep every favorite and export without URLs when the directory
+// is unreachable.
+func (c *command) exportEntries(list []config.Favorite, format playlist.Format) ([]playlist.Entry, []config.Favorite, error) {
+	needURL := format == playlist.M3U || format == playlist.PLS
+	urls := map[string]string{}
+	var uuids []string
+	for _, fav := range list {
+		if config.IsCustom(fav.UUID) {
+			// Custom stations carry their own stream URL.
+			urls[fav.UUID] = fav.URL
+			continue
+		}
+		uuids = append(uuids, fav.UUID)
+	}
+	if len(uuids) > 0 {
+		stations, err := c.lookupUUIDs(uuids)
+		if err != nil && needURL {
+			return nil, nil, fmt.Errorf("stream URLs come from the station directory: %w", err)
+		}
+		if err != nil {
+			fmt.Fprintf(c.stderr, "%s: exporting without stream URLs: %v\n", c.name, err)
+		}
+		for _, station := range stations {
+			urls[station.UUID] = streamURL(station)
+		}
+	}
+
+	entries := make([]playlist.Entry, 0, len(list))
+	var missing []config.Favorite
+	for _, fav := range list {
+		entry := playlist.Entry{UUID: fav.UUID, Name: fav.Name, URL: urls[fav.UUID], Country: fav.Country, Tags: fav.Tags}
+		if entry.URL == "" && needURL {
+			missing = append(missing, fav)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, missing, nil
+}
+
+func (c *command) lookupUUIDs(uuids []string) ([]radio.Station, error) {
+	api, err := c.directory()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	return api.StationsByUUIDs(ctx, uuids)
+}
+
+func streamURL(station radio.Station) string {
+	return strings.TrimSpace(fallback(station.URLResolved, station.URL))
+}
+
+func (c *command) importFile(args []string) int {
+	flags := c.flags("import")
+	formatName := flags.String("format", "", "playlist format")
+	group := flags.String("group", "", "also put the imported stations in this group, creating it if needed")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without changing anything")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return ExitUsage
+	}
+
+	path := flags.Arg(0)
+	var (
+		data []byte
+		err  error
+	)
+	if path == "" || path == "-" {
+		path = ""
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+
+	format := playlist.FormatOf(path, data)
+	if *formatName != "" {
+		if format, err = playlist.ParseFormat(*formatName); err != nil {
+			fmt.Fprintf(c.stderr, "%s: %v\n", c.name, err)
+			return ExitUsage
+		}
+	}
+	entries, err := playlist.Read(bytes.NewReader(data), format)
+	if err != nil {
+		return c.fail(fmt.Errorf("reading %s: %w", fallback(path, "standard input"), err))
+	}
+
+	favs, err := c.load()
+	if err != nil {
+		return c.fail(err)
+	}
+	plan := c.plan(entries, favs)
+	plan.print(c.stdout, *dryRun)
+	if *dryRun {
+		return ExitOK
+	}
+
+	if _, err := favs.Add(plan.add...); err != nil {
+		return c.fail(err)
+	}
+	if *group != "" {
+		if err := addToGroup(favs, *group, plan.stations()); err != nil {
+			return c.fail(err)
+		}
+	}
+	if len(plan.add) > 0 || *group != "" {
+		c.reload()
+	}
+	return ExitOK
+}
+
+// importPlan sorts the stations of a playlist into new favorites, existing
+// ones and ones the directory does not know.
+type importPlan struct {
+	add        []radio.Station
+	existing   []radio.Station
+	conflicts  []conflict
+	unmatched  []playlist.Entry
+	duplicates int
+}
+
+// conflict is a station already saved under another name; the saved name
+// is kept.
+type conflict struct {
+	uuid, saved, imported string
+}
+
+func (p importPlan) stations() []radio.Station {
+	return append(append([]radio.Station(nil), p.add...), p.existing...)
+}
+
+func (p importPlan) print(w io.Writer, dryRun bool) {
+	verb := "added"
+	if dryRun {
+		verb = "would add"
+	}
+	for _, station := range p.add {
+		fmt.Fprintf(w, "%s: %s\n", verb, station.Name)
+	}
+	for _, c := range p.conflicts {
+		fmt.Fprintf(w, "conflict: %s is already a favorite as %q; kept instead of %q\n", c.uuid, c.saved, c.imported)
+	}
+	for _, entry := range p.unmatched {
+		fmt.Fprintf(w, "not found: %s\n", strings.TrimSpace(entry.Name+" "+fallback(entry.URL, entry.UUID)))
+	}
+	fmt.Fprintf(w, "%d %s, %d already favorites, %d conflicts, %d not found, %d duplicates\n",
+		len(p.add), verb, len(p.existing), len(p.conflicts), len(p.unmatched), p.duplicates)
+}
+
+// plan matches entries to directory stations and against the favorites.
+func (c *command) plan(entries []playlist.Entry, favs *config.Favorites) importPlan {
+	var plan importPlan
+	saved := map[string]config.Favorite{}
+	for _, fav := range favs.List() {
+		saved[fav.UUID] = fav
+	}
+
+	seen := map[string]bool{}
+	for _, match := range c.match(entries) {
+		if match.station.UUID == "" {
+			plan.unmatched = append(plan.unmatched, match.entry)
+			continue
+		}
+		if seen[match.station.UUID] {
+			plan.duplicates++
+			continue
+		}
+		seen[match.station.UUID] = true
+
+		fav, ok := saved[match.station.UUID]
+		if !ok {
+			plan.add = append(plan.add, match.station)
+			continue
+		}
+		plan.existing = append(plan.existing, match.station)
+		if imported := match.entry.Name; imported != "" && !strings.EqualFold(strings.TrimSpace(imported), strings.TrimSpace(fav.Name)) {
+			plan.conflicts = append(plan.conflicts, conflict{uuid: fav.UUID, saved: fav.Name, imported: imported})
+		}
+	}
+	return plan
+}
+
+type match struct {
+	entry   playlist.Entry
+	station radio.Station // zero when nothing matched
+}
+
+// match looks entries up in the directory: by UUID in batches, and by
+// stream URL one at a time. Without the directory, entries that carry a
+// UUID and a name, such as valvefm's own exports, are taken as they are.
+// Custom stations never go to the directory.
+func (c *command) match(entries []playlist.Entry) []match {
+	matches := make([]match, len(entries))
+	var uuids []string
+	for i, entry := range entries {
+		matches[i].entry = entry
+		if entry.UUID != "" && !config.IsCustom(entry.UUID) {
+			uuids = append(uuids, entry.UUID)
+		}
+	}
+
+	api, apiErr := c.directory()
+	if apiErr != nil {
+		fmt.Fprintf(c.stderr, "%s: station directory unavailable: %v\n", c.name, apiErr)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	byUUID := map[string]radio.Station{}
+	lookupErr := apiErr
+	if apiErr == nil && len(uuids) > 0 {
+		var stations []radio.Station
+		if stations, lookupErr = api.StationsByUUIDs(ctx, uuids); lookupErr != nil {
+			fmt.Fprintf(c.stderr, "%s: looking up UUIDs: %v\n", c.name, lookupErr)
+		}
+		for _, station := range stations {
+			byUUID[strings.ToLower(station.UUID)] = station
+		}
+	}
+
+	for i, entry := range entries {
+		switch {
+		case config.IsCustom(entry.UUID):
+			if entry.URL != "" {
+				matches[i].station = radio.Station{UUID: entry.UUID, Name: entry.Name, URL: entry.URL, Country: entry.Country, Tags: entry.Tags}
+			}
+		case entry.UUID != "":
+			if station, ok := byUUID[entry.UUID]; ok {
+				matches[i].station = station
+			} else if lookupErr != nil && entry.Name != "" {
+				matches[i].station = radio.Station{UUID: entry.UUID, Name: entry.Name, Country: entry.Country, Tags: entry.Tags}
+			}
+		case apiErr == nil:
+			matches[i].station = byURL(ctx, api, entry.URL)
+		}
+	}
+	return matches
+}
+
+// byURL finds the station streaming from streamURL, preferring an exact
+// match of its URL.
+func byURL(ctx context.Context, api directory, streamURL string) radio.Station {
+	stations, err := api.StationsByURL(ctx, streamURL)
+	if err != nil || len(stations) == 0 {
+		return radio.Station{}
+	}
+	for _, station := range stations {
+		if station.URL == streamURL || station.URLResolved == streamURL {
+			return station
+		}
+	}
+	return stations[0]
+}
+
+func addToGroup(favs *config.Favorites, group string, stations []radio.Station) error {
+	if !favs.HasGroup(group) {
+		if err := favs.CreateGroup(group); err != nil && !errors.Is(err, config.ErrGroupExists) {
+			return err
+		}
+	}
+	for _, station := range stations {
+		if err := favs.SetInGroup(station.UUID, group, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fallback(value, alt string) string {
+	if strings.TrimSpace(value) == "" {
+		return alt
+	}
+	return value
+}
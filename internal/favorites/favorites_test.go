@@ -0,0 +1,213 @@
+package favorites
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/radio"
+)
+
+const (
+	jazzUUID = "96062a7b-0601-11e8-ae97-52543be04c81"
+	newsUUID = "a1b2c3d4-0601-11e8-ae97-52543be04c81"
+)
+
+// fakeDirectory knows stations by UUID and by stream URL.
+type fakeDirectory struct {
+	stations []radio.Station
+	err      error
+}
+
+func (d fakeDirectory) StationsByUUIDs(_ context.Context, uuids []string) ([]radio.Station, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	var found []radio.Station
+	for _, station := range d.stations {
+		for _, uuid := range uuids {
+			if station.UUID == uuid {
+				found = append(found, station)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (d fakeDirectory) StationsByURL(_ context.Context, streamURL string) ([]radio.Station, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	var found []radio.Station
+	for _, station := range d.stations {
+		if station.URL == streamURL {
+			found = append(found, station)
+		}
+	}
+	return found, nil
+}
+
+var directoryStations = []radio.Station{
+	{UUID: jazzUUID, Name: "Jazz FM", URL: "http://jazz.example/stream", Country: "France"},
+	{UUID: newsUUID, Name: "News 24", URL: "http://news.example/stream", URLResolved: "http://news.example/live"},
+}
+
+type harness struct {
+	favs     *config.Favorites
+	reloaded bool
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	favs, err := config.LoadFavorites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &harness{favs: favs}
+}
+
+func (h *harness) run(dir fakeDirectory, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	c := &command{
+		name:      "valvefm favorites",
+		directory: func() (directory, error) { return dir, nil },
+		load:      func() (*config.Favorites, error) { return h.favs, nil },
+		reload:    func() { h.reloaded = true },
+		stdin:     strings.NewReader(stdin),
+		stdout:    &stdout,
+		stderr:    &stderr,
+	}
+	code := c.run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExport_Formats(t *testing.T) {
+	h := newHarness(t)
+	if _, err := h.favs.Add(directoryStations[1], radio.Station{UUID: "gone", Name: "Gone FM"}); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := h.run(fakeDirectory{stations: directoryStations}, "", "export", "--format", "m3u")
+	if code != ExitOK {
+		t.Fatalf("export m3u exit = %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "#EXTINF:-1 radiobrowser-uuid=\""+newsUUID+"\",News 24\nhttp://news.example/live\n") {
+		t.Errorf("m3u export = %q", stdout)
+	}
+	if strings.Contains(stdout, "Gone FM") || !strings.Contains(stderr, "left out Gone FM") {
+		t.Errorf("station without a URL: stdout %q, stderr %q", stdout, stderr)
+	}
+
+	// JSON keeps every favorite, even without the directory.
+	path := filepath.Join(t.TempDir(), "team.json")
+	code, _, stderr = h.run(fakeDirectory{err: errors.New("offline")}, "", "export", "-o", path)
+	if code != ExitOK {
+		t.Fatalf("offline json export exit = %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "Gone FM") || !strings.Contains(string(data), newsUUID) {
+		t.Errorf("json export = %s, %v", data, err)
+	}
+
+	if code, _, _ := h.run(fakeDirectory{err: errors.New("offline")}, "", "export", "--format", "pls"); code != ExitError {
+		t.Errorf("offline pls export exit = %d, want %d", code, ExitError)
+	}
+	if code, _, _ := h.run(fakeDirectory{}, "", "export", "--format", "uuids"); code != ExitUsage {
+		t.Errorf("uuids export exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestImport_MatchesDeduplicatesAndReportsConflicts(t *testing.T) {
+	h := newHarness(t)
+	if _, err := h.favs.Add(radio.Station{UUID: jazzUUID, Name: "Jazz FM"}); err != nil {
+		t.Fatal(err)
+	}
+
+	playlist := "#EXTM3U\n" +
+		"#EXTINF:-1,Smooth Jazz\nhttp://jazz.example/stream\n" + // an existing favorite under another name
+		"#EXTINF:-1,News\nhttp://news.example/stream\n" + // matched by URL
+		"#EXTINF:-1,News again\nhttp://news.example/stream\n" + // the same station twice
+		"#EXTINF:-1,Pirate\nhttp://pirate.example/stream\n" // unknown to the directory
+	code, stdout, stderr := h.run(fakeDirectory{stations: directoryStations}, playlist, "import", "--group", "Team", "-")
+	if code != ExitOK {
+		t.Fatalf("import exit = %d: %s", code, stderr)
+	}
+	for _, want := range []string{
+		"added: News 24\n",
+		"conflict: " + jazzUUID + ` is already a favorite as "Jazz FM"; kept instead of "Smooth Jazz"`,
+		"not found: Pirate http://pirate.example/stream\n",
+		"1 added, 1 already favorites, 1 conflicts, 1 not found, 1 duplicates\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("report lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	list := h.favs.List()
+	if len(list) != 2 || list[0].Name != "Jazz FM" || list[1].UUID != newsUUID {
+		t.Errorf("favorites = %+v", list)
+	}
+	if group, _ := h.favs.ListGroup("Team"); len(group) != 2 {
+		t.Errorf("Team group = %+v, want both stations", group)
+	}
+	if !h.reloaded {
+		t.Error("the running session was not told to reload")
+	}
+}
+
+func TestImport_DryRunAndOffline(t *testing.T) {
+	h := newHarness(t)
+	uuids := jazzUUID + "\n"
+	code, stdout, _ := h.run(fakeDirectory{stations: directoryStations}, uuids, "import", "--dry-run")
+	if code != ExitOK || !strings.Contains(stdout, "would add: Jazz FM") || h.favs.Count() != 0 || h.reloaded {
+		t.Errorf("dry run = %d %q, %d favorites", code, stdout, h.favs.Count())
+	}
+
+	// Offline, a JSON export still imports with its own names; bare UUIDs
+	// and URLs cannot be matched.
+	export := `{"stations": [{"uuid": "` + jazzUUID + `", "name": "Jazz FM"}, {"uuid": "` + newsUUID + `"}, {"name": "X", "url": "http://x"}]}`
+	code, stdout, _ = h.run(fakeDirectory{err: errors.New("offline")}, export, "import", "--format", "json")
+	if code != ExitOK || h.favs.Count() != 1 || !strings.Contains(stdout, "2 not found") {
+		t.Errorf("offline import = %d %q, %d favorites", code, stdout, h.favs.Count())
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	h := newHarness(t)
+	if code, _, _ := h.run(fakeDirectory{}, ""); code != ExitUsage {
+		t.Errorf("no command exit = %d, want %d", code, ExitUsage)
+	}
+	if code, _, _ := h.run(fakeDirectory{}, "", "sync"); code != ExitUsage {
+		t.Errorf("unknown command exit = %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestExportImport_CustomStations(t *testing.T) {
+	h := newHarness(t)
+	custom, err := h.favs.AddCustom(config.Favorite{Name: "Office Radio", URL: "http://intranet.example/live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Custom stations export with their own URL, without the directory.
+	code, stdout, stderr := h.run(fakeDirectory{err: errors.New("offline")}, "", "export", "--format", "m3u")
+	if code != ExitOK || !strings.Contains(stdout, "Office Radio\nhttp://intranet.example/live\n") {
+		t.Fatalf("export = %d %q: %s", code, stdout, stderr)
+	}
+
+	other := newHarness(t)
+	code, stdout, _ = other.run(fakeDirectory{stations: directoryStations}, stdout, "import", "--format", "m3u")
+	if code != ExitOK || !strings.Contains(stdout, "added: Office Radio") {
+		t.Fatalf("import = %d %q", code, stdout)
+	}
+	if fav, ok := other.favs.Get(custom.UUID); !ok || fav.URL != custom.URL {
+		t.Errorf("imported custom station = %+v", fav)
+	}
+}
@@ -0,0 +1,165 @@
+// Package history keeps a log of what was listened to: each station
+// played, from when to when, and the song titles it announced. The log is
+// history.jsonl in the valvefm config directory, one JSON entry per line,
+// rotated into history.1.jsonl and on when it grows large.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// maxFileSize is the size at which the log is rotated.
+	maxFileSize = 1 << 20
+	// keepRotated is how many rotated logs are kept.
+	keepRotated = 3
+	// MinListen is the shortest play worth logging; shorter ones are
+	// stations tuned past.
+	MinListen = 10 * time.Second
+)
+
+// Entry is one station listened to without a break.
+type Entry struct {
+	UUID     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	Country  string    `json:"country,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Start    time.Time `json:"start"`
+	Stop     time.Time `json:"stop"`
+	Duration int64     `json:"duration"` // seconds
+	Titles   []string  `json:"titles,omitempty"`
+}
+
+// Length is how long the station played.
+func (e Entry) Length() time.Duration {
+	return time.Duration(e.Duration) * time.Second
+}
+
+// Log appends entries to the history file.
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open returns the log in the valvefm config directory.
+func Open() (*Log, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenFile(filepath.Join(configDir, "valvefm", "history.jsonl")), nil
+}
+
+// OpenFile returns the log kept at path.
+func OpenFile(path string) *Log {
+	return &Log{path: path}
+}
+
+// Add ends entry at stop and appends it, unless it is shorter than
+// MinListen.
+func (l *Log) Add(entry Entry, stop time.Time) error {
+	entry.Stop = stop
+	entry.Duration = int64(stop.Sub(entry.Start) / time.Second)
+	if entry.Length() < MinListen {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(line)) >= maxFileSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("rotating %s: %w", filepath.Base(l.path), err)
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate shifts history.jsonl to history.1.jsonl, that to history.2.jsonl
+// and so on, dropping the oldest.
+func (l *Log) rotate() error {
+	for i := keepRotated; i > 1; i-- {
+		if err := os.Rename(l.rotated(i-1), l.rotated(i)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(l.path, l.rotated(1))
+}
+
+func (l *Log) rotated(n int) string {
+	ext := filepath.Ext(l.path)
+	return fmt.Sprintf("%s.%d%s", l.path[:len(l.path)-len(ext)], n, ext)
+}
+
+// Entries returns every entry kept, oldest first. Lines that do not parse,
+// as one cut short by a crash, are skipped.
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []Entry
+	for i := keepRotated; i >= 0; i-- {
+		path := l.path
+		if i > 0 {
+			path = l.rotated(i)
+		}
+		read, err := readEntries(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+	return entries, nil
+}
+
+func readEntries(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil && entry.UUID != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Recent returns the stations of entries, most recently played first,
+// each once.
+func Recent(entries []Entry) []Entry {
+	seen := make(map[string]bool)
+	var recent []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !seen[entries[i].UUID] {
+			seen[entries[i].UUID] = true
+			recent = append(recent, entries[i])
+		}
+	}
+	return recent
+}
@@ -0,0 +1,144 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC) // a Monday, ISO week 42
+
+func entry(uuid, country string, at time.Time, minutes int) (Entry, time.Time) {
+	return Entry{UUID: uuid, Name: "Station " + uuid, Country: country, Start: at}, at.Add(time.Duration(minutes) * time.Minute)
+}
+
+func TestLog_AddAndEntries(t *testing.T) {
+	log := OpenFile(filepath.Join(t.TempDir(), "valvefm", "history.jsonl"))
+	if entries, err := log.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("Entries() = %v, %v on a new log", entries, err)
+	}
+
+	first, stop := entry("a", "Germany", start, 30)
+	first.Titles = []string{"Song 1", "Song 2"}
+	if err := log.Add(first, stop); err != nil {
+		t.Fatal(err)
+	}
+	// Tuned past: not logged.
+	if err := log.Add(Entry{UUID: "b", Start: stop}, stop.Add(3*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	second, stop := entry("c", "France", stop, 5)
+	if err := log.Add(second, stop); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].UUID != "a" || entries[1].UUID != "c" {
+		t.Fatalf("Entries() = %+v, want a then c", entries)
+	}
+	if entries[0].Duration != 1800 || !entries[0].Stop.Equal(start.Add(30*time.Minute)) || len(entries[0].Titles) != 2 {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+}
+
+func TestLog_RotatesAndSkipsDamagedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, append(bytes.Repeat([]byte("x"), maxFileSize-10), '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log := OpenFile(path)
+	e, stop := entry("a", "", start, 20)
+	if err := log.Add(e, stop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "history.1.jsonl")); err != nil {
+		t.Errorf("the full log was not rotated: %v", err)
+	}
+	entries, err := log.Entries()
+	if err != nil || len(entries) != 1 || entries[0].UUID != "a" {
+		t.Errorf("Entries() = %+v, %v", entries, err)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	a, _ := entry("a", "", start, 10)
+	b, _ := entry("b", "", start, 10)
+	recent := Recent([]Entry{a, b, a})
+	if len(recent) != 2 || recent[0].UUID != "a" || recent[1].UUID != "b" {
+		t.Errorf("Recent() = %+v, want a then b", recent)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var entries []Entry
+	for _, e := range []struct {
+		uuid, country string
+		day, minutes  int
+	}{
+		{"a", "Germany", 0, 30},
+		{"b", "France", 1, 90},
+		{"a", "Germany", 7, 45},
+		{"c", "", 8, 5},
+	} {
+		entry, stop := entry(e.uuid, e.country, start.AddDate(0, 0, e.day), e.minutes)
+		entry.Stop, entry.Duration = stop, int64(stop.Sub(entry.Start)/time.Second)
+		entries = append(entries, entry)
+	}
+
+	top := TopStations(entries)
+	if len(top) != 3 || top[0].Key != "b" || top[1].Key != "a" || top[1].Time != 75*time.Minute || top[1].Plays != 2 {
+		t.Errorf("TopStations() = %+v", top)
+	}
+	countries := ByCountry(entries)
+	if len(countries) != 3 || countries[0].Label != "France" || countries[2].Label != "Unknown" {
+		t.Errorf("ByCountry() = %+v", countries)
+	}
+	weeks := ByWeek(entries)
+	if len(weeks) != 2 || weeks[0].Key != "2026-W43" || weeks[1].Time != 120*time.Minute {
+		t.Errorf("ByWeek() = %+v", weeks)
+	}
+}
+
+func TestRun_Export(t *testing.T) {
+	dir := t.TempDir()
+	log := OpenFile(filepath.Join(dir, "history.jsonl"))
+	e, stop := entry("a", "Germany", start, 30)
+	e.Titles = []string{"Song 1", "Song 2"}
+	if err := log.Add(e, stop); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		c := &command{name: "valvefm history", open: func() (*Log, error) { return log, nil }, stdout: &stdout, stderr: &stderr}
+		return c.run(args), stdout.String(), stderr.String()
+	}
+
+	code, out, _ := run("export")
+	var exported []Entry
+	if err := json.Unmarshal([]byte(out), &exported); code != ExitOK || err != nil || len(exported) != 1 || exported[0].Titles[1] != "Song 2" {
+		t.Errorf("json export = %d, %q", code, out)
+	}
+
+	csvPath := filepath.Join(dir, "history.csv")
+	if code, _, errOut := run("export", "-o", csvPath); code != ExitOK {
+		t.Fatalf("csv export = %d, %s", code, errOut)
+	}
+	data, _ := os.ReadFile(csvPath)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][0] != "a" || rows[1][6] != "1800" || rows[1][7] != "Song 1\nSong 2" {
+		t.Errorf("csv export = %q, %v", data, err)
+	}
+
+	if code, _, errOut := run("export", "--format", "xml"); code != ExitUsage || !strings.Contains(errOut, "csv or json") {
+		t.Errorf("xml export = %d, %q", code, errOut)
+	}
+}
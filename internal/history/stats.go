@@ -0,0 +1,70 @@
+package history
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Total is the listening time summed over a station, a week or a country.
+type Total struct {
+	Key   string // station UUID, week ("2026-W42") or country
+	Label string // what to show for Key
+	Time  time.Duration
+	Plays int
+}
+
+// TopStations sums listening time per station, longest first.
+func TopStations(entries []Entry) []Total {
+	totals := sum(entries, func(e Entry) (string, string) { return e.UUID, e.Name })
+	sortByTime(totals)
+	return totals
+}
+
+// ByCountry sums listening time per station country, longest first.
+func ByCountry(entries []Entry) []Total {
+	totals := sum(entries, func(e Entry) (string, string) {
+		if e.Country == "" {
+			return "", "Unknown"
+		}
+		return e.Country, e.Country
+	})
+	sortByTime(totals)
+	return totals
+}
+
+// ByWeek sums listening time per ISO week of the start, in local time,
+// latest week first.
+func ByWeek(entries []Entry) []Total {
+	totals := sum(entries, func(e Entry) (string, string) {
+		year, week := e.Start.Local().ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		return key, key
+	})
+	slices.SortFunc(totals, func(a, b Total) int { return cmp.Compare(b.Key, a.Key) })
+	return totals
+}
+
+func sum(entries []Entry, keyOf func(Entry) (string, string)) []Total {
+	index := make(map[string]int)
+	var totals []Total
+	for _, entry := range entries {
+		key, label := keyOf(entry)
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, Total{Key: key, Label: label})
+		}
+		// The latest name wins for a renamed station.
+		totals[i].Label = label
+		totals[i].Time += entry.Length()
+		totals[i].Plays++
+	}
+	return totals
+}
+
+func sortByTime(totals []Total) {
+	slices.SortStableFunc(totals, func(a, b Total) int { return cmp.Compare(b.Time, a.Time) })
+}
@@ -0,0 +1,202 @@
+// Package hooks runs user commands on playback events, handing them the
+// event as VALVEFM_* environment variables and as JSON on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"radio-tui/internal/config"
+)
+
+// Events hooks can run on.
+const (
+	EventStart = "start" // a station started playing
+	EventStop  = "stop"  // playback stopped
+	EventTrack = "track" // the stream announced a new song
+	EventError = "error" // a station failed to play
+)
+
+var events = []string{EventStart, EventStop, EventTrack, EventError}
+
+const (
+	// DefaultTimeout is how long a hook may run unless it says otherwise.
+	DefaultTimeout = 10 * time.Second
+	// MaxRunning caps the hooks running at once; the others wait for a
+	// free slot.
+	MaxRunning = 4
+	// maxQueued is how many events may wait for one hook. Each hook runs
+	// its events one at a time, in order; events beyond this are skipped.
+	maxQueued = 16
+	// maxOutput is how much of a failed hook's output is reported.
+	maxOutput = 200
+)
+
+// Event is what a hook is told about.
+type Event struct {
+	Event   string `json:"event"`
+	Time    string `json:"time"` // RFC 3339
+	UUID    string `json:"uuid,omitempty"`
+	Station string `json:"station,omitempty"`
+	Country string `json:"country,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// env renders the event as environment variables.
+func (e Event) env() []string {
+	return []string{
+		"VALVEFM_EVENT=" + e.Event,
+		"VALVEFM_TIME=" + e.Time,
+		"VALVEFM_UUID=" + e.UUID,
+		"VALVEFM_STATION=" + e.Station,
+		"VALVEFM_COUNTRY=" + e.Country,
+		"VALVEFM_TITLE=" + e.Title,
+		"VALVEFM_ERROR=" + e.Error,
+	}
+}
+
+// Runner runs the configured hooks in the background.
+type Runner struct {
+	hooks    []*queue
+	slots    chan struct{}
+	failures chan error
+}
+
+// queue holds the events waiting for one hook.
+type queue struct {
+	hook    config.Hook
+	mu      sync.Mutex
+	pending []Event
+	running bool // a goroutine is working through pending
+}
+
+// New returns a runner for hooks. Hooks on unknown events or without a
+// command are left out and reported on Failures; the rest still run.
+func New(hooks []config.Hook) *Runner {
+	r := &Runner{
+		slots:    make(chan struct{}, MaxRunning),
+		failures: make(chan error, 8),
+	}
+	var problems []string
+	for _, hook := range hooks {
+		switch {
+		case !slices.Contains(events, hook.Event):
+			problems = append(problems, fmt.Sprintf("unknown event %q", hook.Event))
+		case strings.TrimSpace(hook.Command) == "":
+			problems = append(problems, fmt.Sprintf("%s hook has no command", hook.Event))
+		default:
+			r.hooks = append(r.hooks, &queue{hook: hook})
+		}
+	}
+	if len(problems) > 0 {
+		r.fail(fmt.Errorf("hooks: %s", strings.Join(problems, "; ")))
+	}
+	return r
+}
+
+// Fire queues ev for its hooks without waiting for them.
+func (r *Runner) Fire(ev Event) {
+	if ev.Time == "" {
+		ev.Time = time.Now().Format(time.RFC3339)
+	}
+	for _, q := range r.hooks {
+		if q.hook.Event != ev.Event {
+			continue
+		}
+		q.mu.Lock()
+		if len(q.pending) >= maxQueued {
+			q.mu.Unlock()
+			r.fail(fmt.Errorf("%s hook skipped: %d events already waiting for it", ev.Event, maxQueued))
+			continue
+		}
+		q.pending = append(q.pending, ev)
+		start := !q.running
+		q.running = true
+		q.mu.Unlock()
+		if start {
+			go r.drain(q)
+		}
+	}
+}
+
+// drain runs the events queued for a hook until none are left, each once
+// a slot is free.
+func (r *Runner) drain(q *queue) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		ev := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		r.slots <- struct{}{}
+		err := run(q.hook, ev)
+		<-r.slots
+		if err != nil {
+			r.fail(err)
+		}
+	}
+}
+
+// Failures reports hooks that failed, timed out or were skipped.
+func (r *Runner) Failures() <-chan error {
+	return r.failures
+}
+
+func (r *Runner) fail(err error) {
+	select {
+	case r.failures <- err:
+	default:
+	}
+}
+
+func run(hook config.Hook, ev Event) error {
+	timeout := DefaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cmd := shellCommand(ctx, hook.Command)
+	cmd.Env = append(os.Environ(), ev.env()...)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Output pipes held open by a killed command's children must not keep
+	// the hook running past its timeout.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %s", ev.Event, timeout)
+	}
+	if err != nil {
+		out := strings.TrimSpace(output.String())
+		if len(out) > maxOutput {
+			out = out[:maxOutput] + "…"
+		}
+		if out != "" {
+			return fmt.Errorf("%s hook failed: %v: %s", ev.Event, err, out)
+		}
+		return fmt.Errorf("%s hook failed: %v", ev.Event, err)
+	}
+	return nil
+}
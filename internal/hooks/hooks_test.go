@@ -0,0 +1,140 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"radio-tui/internal/config"
+)
+
+// waitForFile waits for a hook to write path.
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && len(data) > 0 && strings.HasSuffix(string(data), "\n") {
+			return string(data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("hook did not write %s", path)
+	return ""
+}
+
+func TestRunner_PassesEnvAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env")
+	jsonFile := filepath.Join(dir, "json")
+	r := New([]config.Hook{
+		{Event: EventTrack, Command: `echo "$VALVEFM_STATION|$VALVEFM_TITLE" > ` + envFile},
+		{Event: EventTrack, Command: "cat > " + jsonFile},
+		{Event: EventStop, Command: "echo stopped > " + filepath.Join(dir, "stop")},
+	})
+
+	r.Fire(Event{Event: EventTrack, Station: "Jazz FM", Title: "Artist - Song"})
+	if got := waitForFile(t, envFile); got != "Jazz FM|Artist - Song\n" {
+		t.Errorf("environment = %q", got)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(waitForFile(t, jsonFile)), &ev); err != nil || ev.Title != "Artist - Song" || ev.Time == "" {
+		t.Errorf("stdin event = %+v (err %v), want the track with a time", ev, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stop")); err == nil {
+		t.Error("the stop hook ran on a track event")
+	}
+}
+
+func TestRunner_ReportsFailuresAndTimeouts(t *testing.T) {
+	r := New([]config.Hook{
+		{Event: EventStart, Command: "echo nope >&2; exit 3"},
+		{Event: EventStop, Command: "sleep 5", Timeout: 1},
+	})
+
+	r.Fire(Event{Event: EventStart})
+	if err := <-r.Failures(); !strings.Contains(err.Error(), "exit status 3: nope") {
+		t.Errorf("failure = %v, want the exit status and output", err)
+	}
+
+	started := time.Now()
+	r.Fire(Event{Event: EventStop})
+	if err := <-r.Failures(); !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("failure = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("timed-out hook took %s to stop", elapsed)
+	}
+}
+
+func TestRunner_LimitsConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	hooks := make([]config.Hook, MaxRunning+1)
+	for i := range hooks {
+		hooks[i] = config.Hook{Event: EventStart, Command: "sleep 0.5; echo done >> " + filepath.Join(dir, "ran")}
+	}
+	r := New(hooks)
+
+	started := time.Now()
+	r.Fire(Event{Event: EventStart})
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, _ := os.ReadFile(filepath.Join(dir, "ran")); strings.Count(string(data), "done") == len(hooks) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "ran")); strings.Count(string(data), "done") != len(hooks) {
+		t.Fatalf("%d of %d hooks ran", strings.Count(string(data), "done"), len(hooks))
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("all hooks finished after %s; the one beyond the limit should wait for a slot", elapsed)
+	}
+	select {
+	case err := <-r.Failures():
+		t.Errorf("unexpected failure %v", err)
+	default:
+	}
+}
+
+func TestRunner_QueuesEventsInOrder(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "titles")
+	r := New([]config.Hook{{Event: EventTrack, Command: `sleep 0.1; echo "$VALVEFM_TITLE" >> ` + out}})
+
+	titles := []string{"one", "two", "three", "four", "five", "six"}
+	for _, title := range titles {
+		r.Fire(Event{Event: EventTrack, Title: title})
+	}
+	want := strings.Join(titles, "\n") + "\n"
+	deadline := time.Now().Add(5 * time.Second)
+	var got string
+	for time.Now().Before(deadline) {
+		data, _ := os.ReadFile(out)
+		if got = string(data); len(got) >= len(want) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got != want {
+		t.Errorf("hook ran with %q, want every title in order", got)
+	}
+}
+
+func TestNew_RejectsBadHooks(t *testing.T) {
+	r := New([]config.Hook{
+		{Event: "song", Command: "true"},
+		{Event: EventStop, Command: " "},
+		{Event: EventStart, Command: "true"},
+	})
+	err := <-r.Failures()
+	if !strings.Contains(err.Error(), `unknown event "song"`) || !strings.Contains(err.Error(), "no command") {
+		t.Errorf("New() error = %v, want both problems", err)
+	}
+	if len(r.hooks) != 1 {
+		t.Errorf("kept %d hooks, want the valid one", len(r.hooks))
+	}
+}
@@ -0,0 +1,11 @@
+package hooks
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand runs command with cmd.exe.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
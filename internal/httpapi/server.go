@@ -0,0 +1,297 @@
+// Package httpapi serves the remote-control web API: REST endpoints for the
+// IPC commands, a WebSocket event stream and a small remote page for
+// phones.
+package httpapi
+
+import (
+	"bytes"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// DefaultAddr is where the API listens unless configured otherwise.
+const DefaultAddr = "127.0.0.1:8765"
+
+const (
+	// maxBody caps request bodies.
+	maxBody = 64 * 1024
+	// writeTimeout drops WebSocket clients that stop reading.
+	writeTimeout = 5 * time.Second
+)
+
+//go:embed static/index.html
+var indexHTML []byte
+
+// Backend runs commands and streams events; the app implements it with
+// the same dispatcher as the IPC socket.
+type Backend interface {
+	Dispatch(req ipc.Request) ipc.Response
+	Subscribe(events []string, write func(line []byte) error, hangup func()) (unsubscribe func(), err error)
+}
+
+// Server is a running web API.
+type Server struct {
+	listener net.Listener
+	http     *http.Server
+	token    string
+	backend  Backend
+}
+
+// Start checks cfg, listens and serves in the background. Listening
+// anywhere but localhost requires a token.
+func Start(cfg config.HTTPConfig, backend Backend) (*Server, error) {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("http addr %q: %w", addr, err)
+	}
+	if cfg.Token == "" && !isLoopback(host) {
+		return nil, fmt.Errorf("http: set http.token to listen on %s", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: listener, token: cfg.Token, backend: backend}
+	s.http = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = s.http.Serve(listener) }()
+	return s, nil
+}
+
+// Addr is the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server. Event streams end when the backend hangs up on
+// its subscribers.
+func (s *Server) Close() error {
+	return s.http.Close()
+}
+
+// Handler routes the API. The page itself is public; everything else
+// needs the token when one is set.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.page)
+	mux.HandleFunc("GET /status", s.command(ipc.CmdStatus))
+	mux.HandleFunc("POST /play", s.command(ipc.CmdPlay))
+	mux.HandleFunc("POST /stop", s.command(ipc.CmdStop))
+	mux.HandleFunc("POST /toggle", s.command(ipc.CmdPlayPause))
+	mux.HandleFunc("POST /next", s.command(ipc.CmdNext))
+	mux.HandleFunc("POST /prev", s.command(ipc.CmdPrev))
+	mux.HandleFunc("GET /favorites", s.command(ipc.CmdFavorites))
+	mux.HandleFunc("POST /favorites", s.command(ipc.CmdFavorite))
+	mux.HandleFunc("POST /search", s.command(ipc.CmdSearch))
+	mux.HandleFunc("GET /volume", s.command(ipc.CmdVolume))
+	mux.HandleFunc("POST /volume", s.command(ipc.CmdVolume))
+	mux.HandleFunc("GET /events", s.events)
+	return s.guard(mux)
+}
+
+// guard refuses cross-origin requests, which a web page could otherwise
+// send to a localhost API, and requests without the token. Without a token
+// the API only answers requests addressed to localhost: a page whose own
+// name was rebound to 127.0.0.1 passes the origin check, but not this one.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			if s.token == "" && !isLoopback(requestHost(r)) {
+				writeError(w, http.StatusForbidden, ipc.Errorf(ipc.ErrUnauthorized, "requests must address localhost unless a token is set"))
+				return
+			}
+			if !sameOrigin(r) {
+				writeError(w, http.StatusForbidden, ipc.Errorf(ipc.ErrUnauthorized, "cross-origin request refused"))
+				return
+			}
+			if !s.authorized(r) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, ipc.Errorf(ipc.ErrUnauthorized, "missing or wrong token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized accepts the token as a bearer token or, for WebSockets which
+// cannot set headers from a browser, a token query parameter.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+// command runs cmd with the JSON request body, if any, as its arguments
+// and answers with the IPC response.
+func (s *Server) command(cmd string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ipc.Errorf(ipc.ErrBadRequest, "%v", err))
+			return
+		}
+		if len(body) > maxBody {
+			writeError(w, http.StatusRequestEntityTooLarge, ipc.Errorf(ipc.ErrBadRequest, "request body too large"))
+			return
+		}
+
+		req := ipc.Request{V: ipc.ProtocolVersion, Cmd: cmd}
+		if body = bytes.TrimSpace(body); len(body) > 0 {
+			if !json.Valid(body) {
+				writeError(w, http.StatusBadRequest, ipc.Errorf(ipc.ErrBadRequest, "request body is not JSON"))
+				return
+			}
+			req.Args = body
+		}
+		resp := s.backend.Dispatch(req)
+		status := http.StatusOK
+		if !resp.OK {
+			status = httpStatus(resp.Error)
+		}
+		writeJSON(w, status, resp)
+	}
+}
+
+var upgrader = websocket.Upgrader{}
+
+// events streams IPC events over a WebSocket, starting with the current
+// status as a station event. ?events=station,title picks the events.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	var events []string
+	if list := r.URL.Query().Get("events"); list != "" {
+		events = strings.Split(list, ",")
+		for _, event := range events {
+			if !ipc.IsEvent(event) {
+				writeError(w, http.StatusBadRequest, ipc.Errorf(ipc.ErrBadRequest, "unknown event %s", event))
+				return
+			}
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has answered
+	}
+	defer conn.Close()
+
+	var mu sync.Mutex
+	write := func(line []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteMessage(websocket.TextMessage, line)
+	}
+	unsubscribe, err := s.backend.Subscribe(events, write, func() { _ = conn.Close() })
+	if err != nil {
+		message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, ipc.AsError(err).Message)
+		_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeTimeout))
+		return
+	}
+	defer unsubscribe()
+
+	if len(events) == 0 || slices.Contains(events, ipc.EventStation) {
+		if status := s.backend.Dispatch(ipc.Request{V: ipc.ProtocolVersion, Cmd: ipc.CmdStatus}); status.OK {
+			if ev, err := ipc.NewEvent(ipc.EventStation, status.Data); err == nil {
+				if line, err := json.Marshal(ev); err == nil {
+					_ = write(line)
+				}
+			}
+		}
+	}
+
+	// Clients only listen; reading notices when they go away.
+	conn.SetReadLimit(maxBody)
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// httpStatus maps an IPC error code onto an HTTP status.
+func httpStatus(err *ipc.Error) int {
+	if err == nil {
+		return http.StatusInternalServerError
+	}
+	switch err.Code {
+	case ipc.ErrBadRequest, ipc.ErrUnsupportedVersion:
+		return http.StatusBadRequest
+	case ipc.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ipc.ErrUnknownCommand, ipc.ErrNotFound:
+		return http.StatusNotFound
+	case ipc.ErrUnavailable, ipc.ErrBusy, ipc.ErrShuttingDown:
+		return http.StatusServiceUnavailable
+	case ipc.ErrTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err *ipc.Error) {
+	writeJSON(w, status, ipc.Response{V: ipc.ProtocolVersion, Error: err})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// requestHost is the host name the request was addressed to, without the
+// port.
+func requestHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	return strings.Trim(host, "[]")
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
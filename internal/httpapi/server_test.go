@@ -0,0 +1,227 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// fakeBackend answers STATUS, fails NEXT and records everything else.
+type fakeBackend struct {
+	mu       sync.Mutex
+	requests []ipc.Request
+	write    func([]byte) error
+	events   []string
+	ready    chan struct{}
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{ready: make(chan struct{})}
+}
+
+func (b *fakeBackend) Dispatch(req ipc.Request) ipc.Response {
+	b.mu.Lock()
+	b.requests = append(b.requests, req)
+	b.mu.Unlock()
+	switch req.Cmd {
+	case ipc.CmdStatus:
+		data, _ := json.Marshal(ipc.Status{Playing: true, Station: "Jazz FM"})
+		return ipc.Response{V: 1, OK: true, Data: data}
+	case ipc.CmdNext:
+		return ipc.Response{V: 1, Error: ipc.Errorf(ipc.ErrNotFound, "no stations available")}
+	}
+	return ipc.Response{V: 1, OK: true}
+}
+
+func (b *fakeBackend) Subscribe(events []string, write func([]byte) error, hangup func()) (func(), error) {
+	b.mu.Lock()
+	b.write, b.events = write, events
+	b.mu.Unlock()
+	close(b.ready)
+	return func() {}, nil
+}
+
+func (b *fakeBackend) lastRequest() ipc.Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requests[len(b.requests)-1]
+}
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, *fakeBackend) {
+	t.Helper()
+	backend := newFakeBackend()
+	s := &Server{token: token, backend: backend}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server, backend
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) (*http.Response, ipc.Response) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer res.Body.Close()
+	var reply ipc.Response
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+			t.Fatalf("decode reply: %v", err)
+		}
+	}
+	return res, reply
+}
+
+func TestServer_Commands(t *testing.T) {
+	server, backend := newTestServer(t, "")
+
+	res, reply := do(t, "GET", server.URL+"/status", "", nil)
+	var status ipc.Status
+	_ = json.Unmarshal(reply.Data, &status)
+	if res.StatusCode != http.StatusOK || !reply.OK || status.Station != "Jazz FM" {
+		t.Errorf("GET /status = %d %+v, want the status", res.StatusCode, reply)
+	}
+
+	res, _ = do(t, "POST", server.URL+"/play", `{"uuid":"abc"}`, nil)
+	req := backend.lastRequest()
+	if res.StatusCode != http.StatusOK || req.Cmd != ipc.CmdPlay || string(req.Args) != `{"uuid":"abc"}` {
+		t.Errorf("POST /play = %d, dispatched %s %s", res.StatusCode, req.Cmd, req.Args)
+	}
+
+	if do(t, "POST", server.URL+"/stop", "", nil); backend.lastRequest().Args != nil {
+		t.Error("an empty body should dispatch without arguments")
+	}
+
+	res, reply = do(t, "POST", server.URL+"/next", "", nil)
+	if res.StatusCode != http.StatusNotFound || reply.Error == nil || reply.Error.Code != ipc.ErrNotFound {
+		t.Errorf("POST /next = %d %+v, want 404 not_found", res.StatusCode, reply)
+	}
+
+	if res, _ := do(t, "POST", server.URL+"/play", "{oops", nil); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /play with bad JSON = %d, want 400", res.StatusCode)
+	}
+	if res, _ := do(t, "GET", server.URL+"/play", "", nil); res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /play = %d, want 405", res.StatusCode)
+	}
+}
+
+func TestServer_TokenAndOrigin(t *testing.T) {
+	server, _ := newTestServer(t, "s3cret")
+
+	if res, reply := do(t, "GET", server.URL+"/status", "", nil); res.StatusCode != http.StatusUnauthorized || reply.Error.Code != ipc.ErrUnauthorized {
+		t.Errorf("GET /status without token = %d, want 401", res.StatusCode)
+	}
+	bearer := http.Header{"Authorization": {"Bearer s3cret"}}
+	if res, _ := do(t, "GET", server.URL+"/status", "", bearer); res.StatusCode != http.StatusOK {
+		t.Errorf("GET /status with bearer token = %d, want 200", res.StatusCode)
+	}
+	if res, _ := do(t, "GET", server.URL+"/status?token=wrong", "", nil); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /status with wrong token = %d, want 401", res.StatusCode)
+	}
+
+	// The page is public so a phone can load it before it knows the token.
+	if res, _ := do(t, "GET", server.URL+"/", "", nil); res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %s, want the page", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	crossOrigin := http.Header{"Authorization": {"Bearer s3cret"}, "Origin": {"https://evil.example"}}
+	if res, _ := do(t, "POST", server.URL+"/stop", "", crossOrigin); res.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-origin POST /stop = %d, want 403", res.StatusCode)
+	}
+}
+
+func TestServer_RefusesRebindingWithoutToken(t *testing.T) {
+	server, _ := newTestServer(t, "")
+
+	// A page on evil.example whose name now resolves to 127.0.0.1 sends
+	// its own host in both Host and Origin.
+	req, _ := http.NewRequest("POST", server.URL+"/stop", nil)
+	req.Host = "evil.example"
+	req.Header.Set("Origin", "http://evil.example")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("POST /stop for evil.example = %d, want 403", res.StatusCode)
+	}
+
+	for _, host := range []string{"localhost:8080", "[::1]:8080"} {
+		req, _ := http.NewRequest("GET", server.URL+"/status", nil)
+		req.Host = host
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("GET /status for %s = %d, want 200", host, res.StatusCode)
+		}
+	}
+}
+
+func TestServer_EventStream(t *testing.T) {
+	server, backend := newTestServer(t, "s3cret")
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events?events=station,title&token=s3cret"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var ev ipc.Event
+	if err := conn.ReadJSON(&ev); err != nil || ev.Event != ipc.EventStation || !strings.Contains(string(ev.Data), "Jazz FM") {
+		t.Fatalf("first event = %+v (err %v), want the current status", ev, err)
+	}
+
+	<-backend.ready
+	if len(backend.events) != 2 || backend.events[1] != ipc.EventTitle {
+		t.Errorf("subscribed events = %v, want [station title]", backend.events)
+	}
+	line, _ := json.Marshal(ipc.Event{V: 1, Event: ipc.EventTitle, Data: json.RawMessage(`{"title":"Artist - Song"}`)})
+	if err := backend.write(line); err != nil {
+		t.Fatalf("write event: %v", err)
+	}
+	if err := conn.ReadJSON(&ev); err != nil || ev.Event != ipc.EventTitle {
+		t.Errorf("pushed event = %+v (err %v), want title", ev, err)
+	}
+
+	if _, res, err := websocket.DefaultDialer.Dial(strings.Replace(url, "events=station,title", "events=weather", 1), nil); err == nil || res.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown event stream should be refused with 400")
+	}
+}
+
+func TestStart_RequiresTokenOffLocalhost(t *testing.T) {
+	if _, err := Start(config.HTTPConfig{Addr: "0.0.0.0:0"}, newFakeBackend()); err == nil {
+		t.Error("Start() on all interfaces without a token should fail")
+	}
+
+	s, err := Start(config.HTTPConfig{Addr: "127.0.0.1:0"}, newFakeBackend())
+	if err != nil {
+		t.Fatalf("Start() on localhost error = %v", err)
+	}
+	defer s.Close()
+	res, err := http.Get("http://" + s.Addr() + "/status")
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("GET /status on a started server = %v (err %v)", res, err)
+	}
+}
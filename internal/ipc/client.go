@@ -0,0 +1,201 @@
+package ipc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// dialTimeout bounds connecting to the server.
+	dialTimeout = 500 * time.Millisecond
+	// callTimeout bounds waiting for a reply; SEARCH goes out to the
+	// station directory and may take a while.
+	callTimeout = 15 * time.Second
+	// pingTimeout bounds a PING, which a session answers at once.
+	pingTimeout = 2 * time.Second
+)
+
+// Client holds a long-lived JSON-lines connection to the server. It is
+// safe for concurrent use; calls are serialized.
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	token  string
+	nextID int64
+}
+
+// Dial connects to the running server.
+func Dial() (*Client, error) {
+	ep, err := ResolveEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return DialEndpoint(ep)
+}
+
+// DialEndpoint connects to the server at the given endpoint.
+func DialEndpoint(ep Endpoint) (*Client, error) {
+	conn, err := net.DialTimeout(ep.Network, ep.Address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn), token: ep.Token}, nil
+}
+
+// Close hangs up.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call sends cmd with args and decodes the reply's data into result, which
+// may be nil. Failed requests return an *Error.
+func (c *Client) Call(cmd string, args any, result any) error {
+	return c.call(cmd, args, result, callTimeout)
+}
+
+func (c *Client) call(cmd string, args any, result any, timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	req, err := NewRequest(c.nextID, cmd, args)
+	if err != nil {
+		return err
+	}
+	req.Token = c.token
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_ = c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	if _, err := c.conn.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	for {
+		raw, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var resp Response
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return err
+		}
+		// Skip anything that is not the answer to this request.
+		if !bytes.Equal(resp.ID, req.ID) {
+			continue
+		}
+		if !resp.OK {
+			if resp.Error == nil {
+				return errors.New("request failed")
+			}
+			return resp.Error
+		}
+		if result == nil || len(resp.Data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Data, result)
+	}
+}
+
+// Call connects, performs a single call and hangs up.
+func Call(cmd string, args any, result any) error {
+	client, err := Dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call(cmd, args, result)
+}
+
+// Ping reports whether a session is running, without waiting long for one
+// that has hung.
+func Ping() error {
+	ep, err := ResolveEndpoint()
+	if err != nil {
+		return err
+	}
+	return PingEndpoint(ep)
+}
+
+// PingEndpoint pings the server at the given endpoint.
+func PingEndpoint(ep Endpoint) error {
+	client, err := DialEndpoint(ep)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.call(CmdPing, nil, nil, pingTimeout)
+}
+
+// Subscription is a connection that receives pushed events.
+type Subscription struct {
+	client *Client
+	events chan Event
+}
+
+// Subscribe opens a connection and subscribes it to the given events, or
+// to all of them when none are named.
+func Subscribe(events ...string) (*Subscription, error) {
+	client, err := Dial()
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(client, events)
+}
+
+// SubscribeEndpoint subscribes over a connection to the given endpoint.
+func SubscribeEndpoint(ep Endpoint, events ...string) (*Subscription, error) {
+	client, err := DialEndpoint(ep)
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(client, events)
+}
+
+func subscribe(client *Client, events []string) (*Subscription, error) {
+	var args any
+	if len(events) > 0 {
+		args = SubscribeArgs{Events: events}
+	}
+	if err := client.Call(CmdSubscribe, args, nil); err != nil {
+		client.Close()
+		return nil, err
+	}
+	sub := &Subscription{client: client, events: make(chan Event, 16)}
+	go sub.readLoop()
+	return sub, nil
+}
+
+// Events delivers pushed events. It is closed when the connection drops.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() error {
+	return s.client.Close()
+}
+
+func (s *Subscription) readLoop() {
+	defer close(s.events)
+	for {
+		raw, err := s.client.reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var ev Event
+		if err := json.Unmarshal(raw, &ev); err != nil || ev.Event == "" {
+			continue
+		}
+		s.events <- ev
+	}
+}
@@ -0,0 +1,90 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// serveOnce answers a single request with reply, given the request's id,
+// and returns the request it received.
+func serveOnce(t *testing.T, reply func(id json.RawMessage) string) (Endpoint, <-chan Request) {
+	t.Helper()
+	ep := Endpoint{Network: "unix", Address: filepath.Join(t.TempDir(), "ctl.sock"), Token: "test-token"}
+	listener, err := net.Listen(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan Request, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		req, _, _ := ParseRequest(line)
+		received <- req
+		fmt.Fprintln(conn, reply(req.ID))
+	}()
+	return ep, received
+}
+
+func TestClient_Call(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    string
+		wantErr string
+	}{
+		{name: "ok", reply: `{"v":1,"id":%s,"ok":true}`, want: ""},
+		{name: "data", reply: `{"v":1,"id":%s,"ok":true,"data":"playing"}`, want: "playing"},
+		{name: "error", reply: `{"v":1,"id":%s,"ok":false,"error":{"code":"unknown_command","message":"unknown command"}}`, wantErr: "unknown command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, received := serveOnce(t, func(id json.RawMessage) string { return fmt.Sprintf(tt.reply, id) })
+			client, err := DialEndpoint(ep)
+			if err != nil {
+				t.Fatalf("DialEndpoint() error = %v", err)
+			}
+			defer client.Close()
+
+			var got string
+			err = client.Call(CmdStatus, nil, &got)
+			if req := <-received; req.Cmd != CmdStatus || req.Token != "test-token" {
+				t.Errorf("server received %+v, want STATUS with the token", req)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Call() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Call() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialEndpoint_NoServer(t *testing.T) {
+	ep := Endpoint{Network: "unix", Address: filepath.Join(t.TempDir(), "missing.sock")}
+	if _, err := DialEndpoint(ep); err == nil {
+		t.Error("DialEndpoint() should fail when nothing is listening")
+	}
+	if err := PingEndpoint(ep); err == nil {
+		t.Error("PingEndpoint() should fail when nothing is listening")
+	}
+}
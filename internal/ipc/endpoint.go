@@ -1,7 +1,72 @@
 package ipc
 
-// Endpoint describes how to reach the IPC server.
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrAlreadyRunning is returned by Listen when another session is serving
+// the endpoint.
+var ErrAlreadyRunning = errors.New("valvefm is already running")
+
+// tokenFile holds the session token next to the socket (or ctl.addr). It
+// is readable only by the user running the session.
+const tokenFile = "ctl.token"
+
+// Endpoint describes how to reach the IPC server. Token is the session
+// token every request must carry.
 type Endpoint struct {
 	Network string
 	Address string
+	Token   string
+}
+
+// Authorized reports whether token matches the session token. An endpoint
+// without a token authorizes nothing.
+func (ep Endpoint) Authorized(token string) bool {
+	return ep.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(ep.Token)) == 1
+}
+
+// newToken returns a random session token.
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// writeToken writes a fresh token to path, replacing any old file so a
+// file left with wider permissions, or a planted symlink, is not reused.
+func writeToken(path string) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteString(token); err != nil {
+		file.Close()
+		return "", err
+	}
+	return token, file.Close()
+}
+
+// readToken reads the session token. A missing or unreadable file leaves
+// the token empty, and the server will refuse the requests.
+func readToken(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
 }
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -187,3 +188,151 @@ func TestSocketPermissions(t *testing.T) {
 		t.Errorf("Socket permissions = %o, want %o", perm, 0o600)
 	}
 }
+
+func TestListen_RestrictsDirectoryAndWritesToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatalf("UserConfigDir() error = %v", err)
+	}
+	// A directory created by an older version is tightened.
+	if err := os.MkdirAll(filepath.Join(dir, "valvefm"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, ep, err := Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(filepath.Dir(ep.Address))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("directory permissions = %o, want %o", perm, 0o700)
+	}
+
+	tokenPath := filepath.Join(filepath.Dir(ep.Address), tokenFile)
+	info, err = os.Stat(tokenPath)
+	if err != nil {
+		t.Fatalf("Stat(token) error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token permissions = %o, want %o", perm, 0o600)
+	}
+	if len(ep.Token) != 64 {
+		t.Errorf("token = %q, want 64 hex characters", ep.Token)
+	}
+	resolved, _ := ResolveEndpoint()
+	if resolved.Token != ep.Token {
+		t.Errorf("ResolveEndpoint() token = %q, want the session token", resolved.Token)
+	}
+
+	if err := Cleanup(ep); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if _, err := os.Stat(tokenPath); err == nil {
+		t.Error("token should be removed after cleanup")
+	}
+}
+
+func TestEndpoint_Authorized(t *testing.T) {
+	ep := Endpoint{Token: "secret"}
+	if !ep.Authorized("secret") {
+		t.Error("Authorized() should accept the session token")
+	}
+	if ep.Authorized("") || ep.Authorized("secreT") {
+		t.Error("Authorized() should refuse a missing or wrong token")
+	}
+	if (Endpoint{}).Authorized("") {
+		t.Error("an endpoint without a token should authorize nothing")
+	}
+}
+
+func TestCheckPeer_SameUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+	client, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+	conn := <-accepted
+	if conn == nil {
+		t.Fatal("Accept() failed")
+	}
+	defer conn.Close()
+
+	verified, err := CheckPeer(conn)
+	if err != nil {
+		t.Errorf("CheckPeer() error = %v, want a connection from this user to pass", err)
+	}
+	if runtime.GOOS == "linux" && !verified {
+		t.Error("CheckPeer() should vouch for a connection from this user")
+	}
+}
+
+func TestListen_RefusesLiveSession(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	listener, ep, err := Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+	defer Cleanup(ep)
+
+	// The first session accepts but never answers, as if busy.
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	if _, _, err := Listen(); err != ErrAlreadyRunning {
+		t.Fatalf("second Listen() error = %v, want ErrAlreadyRunning", err)
+	}
+	if _, err := os.Stat(ep.Address); err != nil {
+		t.Errorf("the live session's socket should be left alone: %v", err)
+	}
+}
+
+func TestListen_RemovesStaleSocket(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	listener, _, err := Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	// A crashed session leaves its socket file behind.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, ep, err := Listen()
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket error = %v", err)
+	}
+	defer listener.Close()
+	defer Cleanup(ep)
+}
@@ -3,6 +3,7 @@
 package ipc
 
 import (
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
@@ -13,8 +14,31 @@ func ResolveEndpoint() (Endpoint, error) {
 	if err != nil {
 		return Endpoint{}, err
 	}
-	path := filepath.Join(configDir, "valvefm", "ctl.sock")
-	return Endpoint{Network: "unix", Address: path}, nil
+	dir := filepath.Join(configDir, "valvefm")
+	return Endpoint{
+		Network: "unix",
+		Address: filepath.Join(dir, "ctl.sock"),
+		Token:   readToken(filepath.Join(dir, tokenFile)),
+	}, nil
+}
+
+// PrivateDir returns the directory holding the control socket and its
+// token, creating it if needed. Only the owner may enter it, so other
+// sockets of the session belong there too.
+func PrivateDir() (string, error) {
+	ep, err := ResolveEndpoint()
+	if err != nil {
+		return "", err
+	}
+	// MkdirAll leaves an existing directory alone, so tighten it explicitly.
+	dir := filepath.Dir(ep.Address)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
 }
 
 func Listen() (net.Listener, Endpoint, error) {
@@ -22,10 +46,19 @@ func Listen() (net.Listener, Endpoint, error) {
 	if err != nil {
 		return nil, Endpoint{}, err
 	}
+	if live(ep) {
+		return nil, Endpoint{}, ErrAlreadyRunning
+	}
 
-	if err := os.MkdirAll(filepath.Dir(ep.Address), 0o755); err != nil {
+	dir, err := PrivateDir()
+	if err != nil {
+		return nil, Endpoint{}, err
+	}
+	ep.Token, err = writeToken(filepath.Join(dir, tokenFile))
+	if err != nil {
 		return nil, Endpoint{}, err
 	}
+	// Nothing answers, so the socket was left behind by a crashed session.
 	_ = os.Remove(ep.Address)
 
 	listener, err := net.Listen(ep.Network, ep.Address)
@@ -36,10 +69,24 @@ func Listen() (net.Listener, Endpoint, error) {
 	return listener, ep, nil
 }
 
+// live reports whether a session listens at ep. A socket left behind by a
+// crashed session refuses connections; one that accepts belongs to a
+// running session, even if it is too busy to answer a PING in time.
+func live(ep Endpoint) bool {
+	err := PingEndpoint(ep)
+	var protoErr *Error
+	if err == nil || errors.As(err, &protoErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func Cleanup(ep Endpoint) error {
 	if ep.Address == "" {
 		return nil
 	}
 	_ = os.Remove(ep.Address)
+	_ = os.Remove(filepath.Join(filepath.Dir(ep.Address), tokenFile))
 	return nil
 }
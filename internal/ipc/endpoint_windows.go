@@ -23,15 +23,28 @@ func ResolveEndpoint() (Endpoint, error) {
 	if addr == "" {
 		return Endpoint{}, errors.New("empty ipc address")
 	}
-	return Endpoint{Network: "tcp", Address: addr}, nil
+	return Endpoint{
+		Network: "tcp",
+		Address: addr,
+		Token:   readToken(filepath.Join(filepath.Dir(path), tokenFile)),
+	}, nil
 }
 
+// Listen serves on a loopback port, which every local user can reach; the
+// token, stored in the user's profile, is what keeps them out.
 func Listen() (net.Listener, Endpoint, error) {
 	path, err := endpointFilePath()
 	if err != nil {
 		return nil, Endpoint{}, err
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	if ep, err := ResolveEndpoint(); err == nil && live(ep) {
+		return nil, Endpoint{}, ErrAlreadyRunning
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, Endpoint{}, err
+	}
+	token, err := writeToken(filepath.Join(filepath.Dir(path), tokenFile))
+	if err != nil {
 		return nil, Endpoint{}, err
 	}
 
@@ -46,7 +59,16 @@ func Listen() (net.Listener, Endpoint, error) {
 		return nil, Endpoint{}, err
 	}
 
-	return listener, Endpoint{Network: "tcp", Address: addr}, nil
+	return listener, Endpoint{Network: "tcp", Address: addr, Token: token}, nil
+}
+
+// live reports whether a session answers at ep. The port in ctl.addr may
+// have been reused by another program since a crash, so only a protocol
+// reply counts.
+func live(ep Endpoint) bool {
+	err := PingEndpoint(ep)
+	var protoErr *Error
+	return err == nil || errors.As(err, &protoErr)
 }
 
 func Cleanup(ep Endpoint) error {
@@ -55,6 +77,7 @@ func Cleanup(ep Endpoint) error {
 		return err
 	}
 	_ = os.Remove(path)
+	_ = os.Remove(filepath.Join(filepath.Dir(path), tokenFile))
 	return nil
 }
 
@@ -0,0 +1,36 @@
+package ipc
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// CheckPeer refuses unix socket connections from other users, using the
+// LOCAL_PEERCRED credentials the kernel recorded at connect time. Other
+// connections pass.
+func CheckPeer(conn net.Conn) (verified bool, err error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return false, nil
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	var cred *unix.Xucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil {
+		return false, err
+	}
+	if credErr != nil {
+		return false, credErr
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return false, Errorf(ErrUnauthorized, "connection from uid %d refused", cred.Uid)
+	}
+	return true, nil
+}
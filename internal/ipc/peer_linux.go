@@ -0,0 +1,36 @@
+package ipc
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// CheckPeer refuses unix socket connections from other users, using the
+// SO_PEERCRED credentials the kernel recorded at connect time. Other
+// connections pass.
+func CheckPeer(conn net.Conn) (verified bool, err error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return false, nil
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return false, err
+	}
+	if credErr != nil {
+		return false, credErr
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return false, Errorf(ErrUnauthorized, "connection from uid %d refused", cred.Uid)
+	}
+	return true, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package ipc
+
+import "net"
+
+// CheckPeer cannot read peer credentials on this platform; the socket and
+// directory permissions and the session token still apply.
+func CheckPeer(conn net.Conn) (verified bool, err error) {
+	return false, nil
+}
@@ -0,0 +1,487 @@
+package ipc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ProtocolVersion is the version of the JSON-lines protocol spoken by the
+// server. Requests may carry it in "v"; newer versions are rejected.
+const ProtocolVersion = 1
+
+// Commands understood by the server. Legacy clients send them as bare
+// "VERB arg..." lines.
+const (
+	CmdPing      = "PING"
+	CmdStatus    = "STATUS"
+	CmdPlay      = "PLAY"
+	CmdStop      = "STOP"
+	CmdPlayPause = "PLAY_PAUSE"
+	CmdNext      = "NEXT"
+	CmdPrev      = "PREV"
+	CmdSearch    = "SEARCH"
+	CmdFavorite  = "FAV"
+	CmdFavorites = "FAVORITES"
+	CmdVolume    = "VOLUME"
+	CmdMute      = "MUTE"
+	CmdRecent    = "RECENT"
+	CmdBookmark  = "BOOKMARK"
+	CmdPreset    = "PRESET"
+	CmdGroups    = "GROUPS"
+	CmdGroup     = "GROUP"
+	CmdSleep     = "SLEEP"
+	CmdAlarm     = "ALARM"
+	CmdReload    = "RELOAD"
+	CmdSubscribe = "SUBSCRIBE"
+	CmdQuit      = "QUIT"
+)
+
+// Events pushed to subscribers.
+const (
+	EventStation   = "station"    // the current station changed; data is a Status
+	EventPlayState = "play_state" // playback started or stopped; data is a Status
+	EventTitle     = "title"      // the now-playing title changed; data is a TitleEvent
+	EventFavorites = "favorites"  // favorites were added, removed or reloaded; data is a FavoritesEvent
+	EventTimers    = "timers"     // the sleep timer or next alarm changed; data is a Status
+	EventVolume    = "volume"     // the volume or mute changed; data is a VolumeStatus
+	EventError     = "error"      // something went wrong; data is an ErrorEvent
+	EventDropped   = "dropped"    // events were lost because the subscriber fell behind; data is a DroppedEvent
+)
+
+// Events lists the event names a client may subscribe to.
+var Events = []string{EventStation, EventPlayState, EventTitle, EventFavorites, EventTimers, EventVolume, EventError}
+
+// ErrorCode classifies failed requests.
+type ErrorCode string
+
+const (
+	ErrBadRequest         ErrorCode = "bad_request"
+	ErrUnknownCommand     ErrorCode = "unknown_command"
+	ErrUnsupportedVersion ErrorCode = "unsupported_version"
+	ErrUnauthorized       ErrorCode = "unauthorized"
+	ErrNotFound           ErrorCode = "not_found"
+	ErrUnavailable        ErrorCode = "unavailable"
+	ErrBusy               ErrorCode = "busy"
+	ErrTimeout            ErrorCode = "timeout"
+	ErrShuttingDown       ErrorCode = "shutting_down"
+	ErrInternal           ErrorCode = "internal"
+)
+
+// Error is the error member of a failed Response.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+// Errorf builds an Error with a formatted message.
+func Errorf(code ErrorCode, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Request is one line sent by a client. ID is echoed back verbatim in the
+// matching Response and may be any JSON value. Token is the session token
+// from ctl.token; the socket refuses requests without it.
+type Request struct {
+	V     int             `json:"v,omitempty"`
+	ID    json.RawMessage `json:"id,omitempty"`
+	Token string          `json:"token,omitempty"`
+	Cmd   string          `json:"cmd"`
+	Args  json.RawMessage `json:"args,omitempty"`
+}
+
+// Response answers a Request. Data holds the command's result, if any.
+type Response struct {
+	V     int             `json:"v"`
+	ID    json.RawMessage `json:"id,omitempty"`
+	OK    bool            `json:"ok"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error *Error          `json:"error,omitempty"`
+}
+
+// Argument payloads.
+
+// PlayArgs selects the station for PLAY by UUID, or by name among the
+// loaded stations and favorites; without either the last station resumes.
+type PlayArgs struct {
+	UUID string `json:"uuid,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// SearchArgs queries stations in a country, the session's by default.
+type SearchArgs struct {
+	Query   string `json:"query"`
+	Country string `json:"country,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// SleepArgs sets the sleep timer; zero minutes cancels it. Without
+// arguments SLEEP reports the remaining time.
+type SleepArgs struct {
+	Minutes int `json:"minutes"`
+}
+
+// AlarmArgs adds an alarm at Time for a favorite (the playing station by
+// default), or disables all alarms when Off is set. Without arguments
+// ALARM reports the next alarm.
+type AlarmArgs struct {
+	Time string `json:"time,omitempty"`
+	UUID string `json:"uuid,omitempty"`
+	Off  bool   `json:"off,omitempty"`
+}
+
+// FavoriteArgs adds a station (the playing one by default) to favorites,
+// or removes it when Remove is set.
+type FavoriteArgs struct {
+	UUID   string `json:"uuid,omitempty"`
+	Remove bool   `json:"remove,omitempty"`
+}
+
+// VolumeArgs sets the volume to Level percent, or moves it by Delta.
+// Without arguments VOLUME reports the volume.
+type VolumeArgs struct {
+	Level *int `json:"level,omitempty"`
+	Delta int  `json:"delta,omitempty"`
+}
+
+// MuteArgs mutes or unmutes; without arguments MUTE toggles.
+type MuteArgs struct {
+	Muted bool `json:"muted"`
+}
+
+// PresetArgs plays a preset, the first nine favorites in their order.
+type PresetArgs struct {
+	Number int `json:"number"`
+}
+
+// GroupArgs picks the favorites group that the favorites view, NEXT and
+// PREV go through; an empty name means all favorites. Without arguments
+// GROUP reports the current group.
+type GroupArgs struct {
+	Name string `json:"name"`
+}
+
+// SubscribeArgs picks the events to receive; none means all of them.
+type SubscribeArgs struct {
+	Events []string `json:"events,omitempty"`
+}
+
+// Result payloads.
+
+// Hello answers PING.
+type Hello struct {
+	Version int `json:"version"`
+}
+
+// Status answers STATUS.
+type Status struct {
+	Playing        bool   `json:"playing"`
+	Station        string `json:"station"`
+	UUID           string `json:"uuid"`
+	Country        string `json:"country"`
+	Title          string `json:"title"`
+	Volume         int    `json:"volume"`
+	Muted          bool   `json:"muted"`
+	SleepRemaining int    `json:"sleep_remaining"`
+	NextAlarm      string `json:"next_alarm"`
+}
+
+// VolumeStatus answers VOLUME and MUTE. Level is kept while muted.
+type VolumeStatus struct {
+	Level int  `json:"level"`
+	Muted bool `json:"muted"`
+}
+
+// Station is a search result, favorite or recently played station.
+type Station struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+	Tags    string `json:"tags,omitempty"`
+}
+
+// Group describes a favorites group in GROUPS.
+type Group struct {
+	Name     string `json:"name"`
+	Stations int    `json:"stations"`
+	Current  bool   `json:"current,omitempty"`
+}
+
+// Bookmark answers BOOKMARK with the song saved.
+type Bookmark struct {
+	Title   string `json:"title"`
+	Station string `json:"station"`
+	UUID    string `json:"uuid"`
+	URL     string `json:"url,omitempty"`
+	Time    string `json:"time"`  // RFC 3339
+	Added   bool   `json:"added"` // false when the song was already the last bookmark
+}
+
+// SleepStatus answers SLEEP without arguments.
+type SleepStatus struct {
+	Remaining int `json:"remaining"` // seconds
+}
+
+// AlarmInfo answers ALARM without arguments.
+type AlarmInfo struct {
+	Time string `json:"time"`
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// Event is pushed to subscribed connections between responses. It has no
+// id; clients tell it apart by the "event" member.
+type Event struct {
+	V     int             `json:"v"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// TitleEvent carries the now-playing title of a station.
+type TitleEvent struct {
+	UUID  string `json:"uuid"`
+	Title string `json:"title"`
+}
+
+// FavoritesEvent reports the number of favorites after a change.
+type FavoritesEvent struct {
+	Count int `json:"count"`
+}
+
+// ErrorEvent carries an error shown to the user.
+type ErrorEvent struct {
+	Message string `json:"message"`
+}
+
+// DroppedEvent tells a slow subscriber how many events it missed; it
+// should resynchronize with STATUS.
+type DroppedEvent struct {
+	Count int64 `json:"count"`
+}
+
+// NewEvent builds an event with marshalled data.
+func NewEvent(name string, data any) (Event, error) {
+	ev := Event{V: ProtocolVersion, Event: name}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, err
+		}
+		ev.Data = raw
+	}
+	return ev, nil
+}
+
+// IsEvent reports whether name is an event clients may subscribe to.
+func IsEvent(name string) bool {
+	for _, event := range Events {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRequest builds a request with marshalled arguments.
+func NewRequest(id int64, cmd string, args any) (Request, error) {
+	req := Request{V: ProtocolVersion, ID: json.RawMessage(strconv.FormatInt(id, 10)), Cmd: cmd}
+	if args != nil {
+		raw, err := json.Marshal(args)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Args = raw
+	}
+	return req, nil
+}
+
+// HasArgs reports whether the request carries an argument payload.
+func (r Request) HasArgs() bool {
+	trimmed := bytes.TrimSpace(r.Args)
+	return len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null"))
+}
+
+// DecodeArgs unmarshals the argument payload into v, rejecting unknown
+// fields. A missing payload leaves v untouched.
+func (r Request) DecodeArgs(v any) error {
+	if !r.HasArgs() {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(r.Args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return Errorf(ErrBadRequest, "invalid %s arguments: %v", r.Cmd, err)
+	}
+	return nil
+}
+
+// ParseRequest decodes a request line. Lines starting with "{" are JSON;
+// anything else is a legacy "VERB arg..." command. legacy reports which
+// form was used so the reply can be written the same way.
+func ParseRequest(line string) (req Request, legacy bool, err error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		req, err = ParseLegacy(line)
+		return req, true, err
+	}
+
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return Request{}, false, Errorf(ErrBadRequest, "invalid request: %v", err)
+	}
+	req.Cmd = strings.ToUpper(strings.TrimSpace(req.Cmd))
+	if req.Cmd == "" {
+		return req, false, Errorf(ErrBadRequest, "missing cmd")
+	}
+	if req.V > ProtocolVersion {
+		return req, false, Errorf(ErrUnsupportedVersion, "protocol version %d is not supported (server speaks %d)", req.V, ProtocolVersion)
+	}
+	return req, false, nil
+}
+
+// ParseLegacy maps a legacy "VERB arg..." line onto a Request with typed
+// arguments. The line may start with "AUTH <token>" to carry the session
+// token.
+func ParseLegacy(line string) (Request, error) {
+	fields := strings.Fields(line)
+	var token string
+	if len(fields) > 0 && strings.EqualFold(fields[0], "AUTH") {
+		if len(fields) < 2 {
+			return Request{}, Errorf(ErrBadRequest, "AUTH needs a token")
+		}
+		token, fields = fields[1], fields[2:]
+	}
+	if len(fields) == 0 {
+		return Request{}, Errorf(ErrBadRequest, "empty command")
+	}
+	req := Request{Token: token, Cmd: strings.ToUpper(fields[0])}
+	rest := fields[1:]
+
+	var args any
+	switch req.Cmd {
+	case CmdPlay:
+		if len(rest) > 0 {
+			args = PlayArgs{UUID: rest[0]}
+		}
+	case CmdFavorite:
+		if len(rest) == 0 || !strings.EqualFold(rest[0], "ADD") && !strings.EqualFold(rest[0], "REMOVE") {
+			return req, Errorf(ErrBadRequest, "usage: FAV ADD|REMOVE [uuid]")
+		}
+		fav := FavoriteArgs{Remove: strings.EqualFold(rest[0], "REMOVE")}
+		if len(rest) > 1 {
+			fav.UUID = rest[1]
+		}
+		args = fav
+	case CmdVolume:
+		if len(rest) > 0 {
+			volume, err := ParseVolume(rest[0])
+			if err != nil {
+				return req, err
+			}
+			args = volume
+		}
+	case CmdMute:
+		if len(rest) > 0 {
+			switch strings.ToUpper(rest[0]) {
+			case "ON":
+				args = MuteArgs{Muted: true}
+			case "OFF":
+				args = MuteArgs{Muted: false}
+			default:
+				return req, Errorf(ErrBadRequest, "MUTE takes ON or OFF")
+			}
+		}
+	case CmdSubscribe:
+		if len(rest) > 0 {
+			events := make([]string, 0, len(rest))
+			for _, event := range rest {
+				events = append(events, strings.ToLower(event))
+			}
+			args = SubscribeArgs{Events: events}
+		}
+	case CmdPreset:
+		if len(rest) != 1 {
+			return req, Errorf(ErrBadRequest, "usage: PRESET <1-9>")
+		}
+		number, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return req, Errorf(ErrBadRequest, "usage: PRESET <1-9>")
+		}
+		args = PresetArgs{Number: number}
+	case CmdGroup:
+		if len(rest) > 0 {
+			name := strings.Join(rest, " ")
+			if strings.EqualFold(name, "ALL") {
+				name = ""
+			}
+			args = GroupArgs{Name: name}
+		}
+	case CmdSearch:
+		if len(rest) == 0 {
+			return req, Errorf(ErrBadRequest, "usage: SEARCH <query>")
+		}
+		args = SearchArgs{Query: strings.Join(rest, " ")}
+	case CmdSleep:
+		if len(rest) > 0 {
+			if strings.EqualFold(rest[0], "OFF") {
+				args = SleepArgs{}
+				break
+			}
+			minutes, err := strconv.Atoi(rest[0])
+			if err != nil || minutes < 0 {
+				return req, Errorf(ErrBadRequest, "usage: SLEEP <minutes>|OFF")
+			}
+			args = SleepArgs{Minutes: minutes}
+		}
+	case CmdAlarm:
+		switch {
+		case len(rest) == 0:
+		case strings.EqualFold(rest[0], "OFF"):
+			args = AlarmArgs{Off: true}
+		case len(rest) > 1:
+			args = AlarmArgs{Time: rest[0], UUID: rest[1]}
+		default:
+			args = AlarmArgs{Time: rest[0]}
+		}
+	}
+
+	if args != nil {
+		raw, err := json.Marshal(args)
+		if err != nil {
+			return req, Errorf(ErrInternal, "%v", err)
+		}
+		req.Args = raw
+	}
+	return req, nil
+}
+
+// ParseVolume reads "50" as a level and "+5" or "-5" as a change.
+func ParseVolume(arg string) (VolumeArgs, error) {
+	value, err := strconv.Atoi(arg)
+	if err != nil {
+		return VolumeArgs{}, Errorf(ErrBadRequest, "usage: VOLUME <0-100>|+n|-n")
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		return VolumeArgs{Delta: value}, nil
+	}
+	return VolumeArgs{Level: &value}, nil
+}
+
+// AsError converts err into a protocol Error, keeping its code when it
+// already is one.
+func AsError(err error) *Error {
+	var protoErr *Error
+	if errors.As(err, &protoErr) {
+		return protoErr
+	}
+	return &Error{Code: ErrInternal, Message: err.Error()}
+}
@@ -0,0 +1,271 @@
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseLegacy_Verb(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		// Valid commands
+		{"simple command", "play", "PLAY", false},
+		{"uppercase command", "STOP", "STOP", false},
+		{"mixed case", "PlAy", "PLAY", false},
+		{"with leading space", "  play", "PLAY", false},
+		{"with trailing space", "stop  ", "STOP", false},
+		{"with both spaces", "  toggle  ", "TOGGLE", false},
+
+		// Invalid commands
+		{"empty string", "", "", true},
+		{"whitespace only", "   ", "", true},
+		{"tabs only", "\t\t", "", true},
+		{"newlines only", "\n\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseLegacy(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ParseLegacy() should return error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseLegacy() error = %v", err)
+			}
+			if req.Cmd != tt.expected {
+				t.Errorf("ParseLegacy(%q).Cmd = %q, want %q", tt.input, req.Cmd, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLegacy_CommonCommands(t *testing.T) {
+	// Test common IPC commands that the app might receive
+	commands := []string{
+		CmdPlay,
+		CmdStop,
+		CmdPlayPause,
+		CmdNext,
+		CmdPrev,
+		CmdStatus,
+		CmdQuit,
+	}
+
+	for _, cmd := range commands {
+		t.Run(cmd, func(t *testing.T) {
+			req, err := ParseLegacy(cmd)
+			if err != nil {
+				t.Fatalf("ParseLegacy(%q) error = %v", cmd, err)
+			}
+			if req.Cmd != cmd {
+				t.Errorf("ParseLegacy(%q).Cmd = %q, want %q", cmd, req.Cmd, cmd)
+			}
+			if req.HasArgs() {
+				t.Errorf("ParseLegacy(%q) should carry no arguments", cmd)
+			}
+		})
+	}
+}
+
+func TestParseLegacy_Arguments(t *testing.T) {
+	req, err := ParseLegacy("  alarm 07:30 a1b2-C3  ")
+	if err != nil {
+		t.Fatalf("ParseLegacy() error = %v", err)
+	}
+	var alarm AlarmArgs
+	if err := req.DecodeArgs(&alarm); err != nil {
+		t.Fatalf("DecodeArgs() error = %v", err)
+	}
+	if alarm.Time != "07:30" || alarm.UUID != "a1b2-C3" {
+		t.Errorf("alarm args = %+v, want 07:30 a1b2-C3 with case preserved", alarm)
+	}
+
+	req, _ = ParseLegacy("PLAY abc")
+	var play PlayArgs
+	_ = req.DecodeArgs(&play)
+	if play.UUID != "abc" {
+		t.Errorf("play args = %+v, want uuid abc", play)
+	}
+
+	req, _ = ParseLegacy("search smooth jazz")
+	var search SearchArgs
+	_ = req.DecodeArgs(&search)
+	if search.Query != "smooth jazz" {
+		t.Errorf("search args = %+v, want query \"smooth jazz\"", search)
+	}
+
+	req, _ = ParseLegacy("SUBSCRIBE Station title")
+	var subscribe SubscribeArgs
+	_ = req.DecodeArgs(&subscribe)
+	if len(subscribe.Events) != 2 || subscribe.Events[0] != EventStation || subscribe.Events[1] != EventTitle {
+		t.Errorf("subscribe args = %+v, want [station title]", subscribe)
+	}
+
+	req, _ = ParseLegacy("fav remove abc")
+	var fav FavoriteArgs
+	_ = req.DecodeArgs(&fav)
+	if !fav.Remove || fav.UUID != "abc" {
+		t.Errorf("fav args = %+v, want remove abc", fav)
+	}
+	if _, err := ParseLegacy("FAV"); err == nil {
+		t.Error("ParseLegacy() should require FAV ADD or REMOVE")
+	}
+
+	req, _ = ParseLegacy("auth s3cret play abc")
+	if req.Token != "s3cret" || req.Cmd != CmdPlay {
+		t.Errorf("AUTH request = %+v, want PLAY with token s3cret", req)
+	}
+	if _, err := ParseLegacy("AUTH"); err == nil {
+		t.Error("ParseLegacy() should require a token after AUTH")
+	}
+	if _, err := ParseLegacy("AUTH s3cret"); err == nil {
+		t.Error("ParseLegacy() should require a command after the token")
+	}
+
+	req, _ = ParseLegacy("mute on")
+	var mute MuteArgs
+	_ = req.DecodeArgs(&mute)
+	if !mute.Muted {
+		t.Errorf("mute args = %+v, want muted", mute)
+	}
+	if req, _ = ParseLegacy("MUTE"); req.HasArgs() {
+		t.Error("MUTE without arguments should toggle")
+	}
+	if _, err := ParseLegacy("MUTE loud"); err == nil {
+		t.Error("ParseLegacy() should reject bad MUTE arguments")
+	}
+
+	req, _ = ParseLegacy("preset 4")
+	var preset PresetArgs
+	_ = req.DecodeArgs(&preset)
+	if preset.Number != 4 {
+		t.Errorf("preset args = %+v, want 4", preset)
+	}
+	if _, err := ParseLegacy("PRESET"); err == nil {
+		t.Error("ParseLegacy() should require a preset number")
+	}
+
+	req, _ = ParseLegacy("group Work focus")
+	var group GroupArgs
+	_ = req.DecodeArgs(&group)
+	if group.Name != "Work focus" {
+		t.Errorf("group args = %+v, want Work focus", group)
+	}
+	req, _ = ParseLegacy("GROUP all")
+	group = GroupArgs{Name: "unset"}
+	_ = req.DecodeArgs(&group)
+	if !req.HasArgs() || group.Name != "" {
+		t.Errorf("GROUP ALL args = %+v, want all favorites", group)
+	}
+
+	req, _ = ParseLegacy("SLEEP off")
+	var sleep SleepArgs
+	if !req.HasArgs() {
+		t.Fatal("SLEEP OFF should carry arguments")
+	}
+	_ = req.DecodeArgs(&sleep)
+	if sleep.Minutes != 0 {
+		t.Errorf("sleep args = %+v, want 0 minutes", sleep)
+	}
+
+	if _, err := ParseLegacy("SLEEP soon"); err == nil {
+		t.Error("ParseLegacy() should reject bad SLEEP arguments")
+	}
+	if _, err := ParseLegacy("SEARCH"); err == nil {
+		t.Error("ParseLegacy() should require a SEARCH query")
+	}
+}
+
+func TestParseRequest_JSON(t *testing.T) {
+	req, legacy, err := ParseRequest(`{"v":1,"id":"a","cmd":"play","args":{"uuid":"x"}}`)
+	if err != nil {
+		t.Fatalf("ParseRequest() error = %v", err)
+	}
+	if legacy {
+		t.Error("JSON requests should not be reported as legacy")
+	}
+	if req.Cmd != CmdPlay || string(req.ID) != `"a"` {
+		t.Errorf("request = %+v, want PLAY with id \"a\"", req)
+	}
+
+	_, _, err = ParseRequest(`{"id":1}`)
+	var protoErr *Error
+	if !errors.As(err, &protoErr) || protoErr.Code != ErrBadRequest {
+		t.Errorf("missing cmd error = %v, want bad_request", err)
+	}
+
+	_, _, err = ParseRequest(`{"v":2,"cmd":"PING"}`)
+	if !errors.As(err, &protoErr) || protoErr.Code != ErrUnsupportedVersion {
+		t.Errorf("future version error = %v, want unsupported_version", err)
+	}
+
+	_, legacy, _ = ParseRequest("STATUS")
+	if !legacy {
+		t.Error("bare verbs should be parsed as legacy commands")
+	}
+}
+
+func TestRequest_DecodeArgs_RejectsUnknownFields(t *testing.T) {
+	req := Request{Cmd: CmdPlay, Args: json.RawMessage(`{"station":"x"}`)}
+	var args PlayArgs
+	err := req.DecodeArgs(&args)
+	var protoErr *Error
+	if !errors.As(err, &protoErr) || protoErr.Code != ErrBadRequest {
+		t.Errorf("DecodeArgs() error = %v, want bad_request", err)
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	req, err := NewRequest(3, CmdSleep, SleepArgs{Minutes: 15})
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	line, _ := json.Marshal(req)
+	want := `{"v":1,"id":3,"cmd":"SLEEP","args":{"minutes":15}}`
+	if string(line) != want {
+		t.Errorf("request = %s, want %s", line, want)
+	}
+}
+
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		arg       string
+		wantLevel int
+		absolute  bool
+		wantDelta int
+		wantErr   bool
+	}{
+		{arg: "50", wantLevel: 50, absolute: true},
+		{arg: "0", wantLevel: 0, absolute: true},
+		{arg: "+5", wantDelta: 5},
+		{arg: "-10", wantDelta: -10},
+		{arg: "loud", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseVolume(tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ParseVolume() should return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVolume() error = %v", err)
+			}
+			if (got.Level != nil) != tt.absolute || got.Level != nil && *got.Level != tt.wantLevel || got.Delta != tt.wantDelta {
+				t.Errorf("ParseVolume(%q) = %+v, want level %d (absolute %v) delta %d", tt.arg, got, tt.wantLevel, tt.absolute, tt.wantDelta)
+			}
+		})
+	}
+}
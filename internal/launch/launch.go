@@ -0,0 +1,8 @@
+// Package launch opens commands in a new terminal window, so the tray can
+// start a TUI when it was itself started without one.
+package launch
+
+import "errors"
+
+// ErrNoTerminal is returned when no terminal emulator can be found.
+var ErrNoTerminal = errors.New("no terminal emulator found; set $TERMINAL")
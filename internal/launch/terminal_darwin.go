@@ -0,0 +1,26 @@
+package launch
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Terminal returns a command that opens Terminal.app running exe with
+// args.
+func Terminal(exe string, args ...string) (*exec.Cmd, error) {
+	words := []string{shellQuote(exe)}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	script := `tell application "Terminal"
+	do script ` + strconv.Quote(strings.Join(words, " ")) + `
+	activate
+end tell`
+	return exec.Command("osascript", "-e", script), nil
+}
+
+// shellQuote quotes s for the shell Terminal.app runs the command in.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,55 @@
+//go:build !windows && !darwin
+
+package launch
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// terminals are tried in order after $TERMINAL, each with the arguments
+// that make it run a command.
+var terminals = []struct {
+	name string
+	args []string
+}{
+	{"x-terminal-emulator", []string{"-e"}},
+	{"gnome-terminal", []string{"--"}},
+	{"konsole", []string{"-e"}},
+	{"xfce4-terminal", []string{"-x"}},
+	{"kitty", nil},
+	{"alacritty", []string{"-e"}},
+	{"foot", nil},
+	{"wezterm", []string{"start", "--"}},
+	{"xterm", []string{"-e"}},
+}
+
+// Terminal returns a command running exe with args in a new terminal
+// window, preferring $TERMINAL.
+func Terminal(exe string, args ...string) (*exec.Cmd, error) {
+	command := append([]string{exe}, args...)
+	if preferred := os.Getenv("TERMINAL"); preferred != "" {
+		if path, err := exec.LookPath(preferred); err == nil {
+			return exec.Command(path, slices.Concat(execArgs(filepath.Base(preferred)), command)...), nil
+		}
+	}
+	for _, term := range terminals {
+		if path, err := exec.LookPath(term.name); err == nil {
+			return exec.Command(path, slices.Concat(term.args, command)...), nil
+		}
+	}
+	return nil, ErrNoTerminal
+}
+
+// execArgs returns how a known terminal runs a command; unknown ones get
+// the common -e.
+func execArgs(name string) []string {
+	for _, term := range terminals {
+		if term.name == name {
+			return term.args
+		}
+	}
+	return []string{"-e"}
+}
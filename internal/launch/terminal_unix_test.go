@@ -0,0 +1,52 @@
+//go:build !windows && !darwin
+
+package launch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeTerminals puts executables with the given names on an otherwise
+// empty PATH.
+func fakeTerminals(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("TERMINAL", "")
+	return dir
+}
+
+func TestTerminal(t *testing.T) {
+	dir := fakeTerminals(t, "xterm", "gnome-terminal", "myterm")
+
+	cmd, err := Terminal("/usr/bin/valvefm", "attach")
+	if err != nil {
+		t.Fatalf("Terminal() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "gnome-terminal"), "--", "/usr/bin/valvefm", "attach"}
+	if !slices.Equal(cmd.Args, want) {
+		t.Errorf("Terminal() = %v, want %v", cmd.Args, want)
+	}
+
+	t.Setenv("TERMINAL", "myterm")
+	cmd, _ = Terminal("/usr/bin/valvefm", "attach")
+	want = []string{filepath.Join(dir, "myterm"), "-e", "/usr/bin/valvefm", "attach"}
+	if !slices.Equal(cmd.Args, want) {
+		t.Errorf("Terminal() with $TERMINAL = %v, want %v", cmd.Args, want)
+	}
+}
+
+func TestTerminal_NoneFound(t *testing.T) {
+	fakeTerminals(t)
+	if _, err := Terminal("valvefm", "attach"); err != ErrNoTerminal {
+		t.Errorf("Terminal() error = %v, want ErrNoTerminal", err)
+	}
+}
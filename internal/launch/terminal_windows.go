@@ -0,0 +1,9 @@
+package launch
+
+import "os/exec"
+
+// Terminal returns a command that runs exe with args in a new console
+// window.
+func Terminal(exe string, args ...string) (*exec.Cmd, error) {
+	return exec.Command("cmd", append([]string{"/c", "start", "Valve FM", exe}, args...)...), nil
+}
@@ -0,0 +1,23 @@
+// Package mpris exposes the player on the D-Bus session bus through the
+// MPRIS interfaces, so media keys, desktop widgets and playerctl can
+// control it.
+package mpris
+
+import "errors"
+
+// State is what MPRIS clients see of the player.
+type State struct {
+	Playing bool
+	UUID    string
+	Station string
+	Title   string // now-playing title announced by the stream
+	ArtURL  string
+	Volume  int // percent
+}
+
+// Dispatcher runs an IPC command in the app, exactly as if it had arrived
+// on the control socket.
+type Dispatcher func(cmd string, args any) error
+
+// ErrUnsupported is returned by Start on platforms without MPRIS.
+var ErrUnsupported = errors.New("mpris is only available on linux")
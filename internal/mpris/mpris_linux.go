@@ -0,0 +1,273 @@
+package mpris
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+
+	"radio-tui/internal/ipc"
+)
+
+const (
+	busName     = "org.mpris.MediaPlayer2.valvefm"
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+
+	// noTrack is the MPRIS track ID for "nothing selected".
+	noTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+)
+
+// Server owns the MPRIS bus name and mirrors the player's state onto it.
+type Server struct {
+	conn     *dbus.Conn
+	props    *prop.Properties
+	dispatch Dispatcher
+
+	mu    sync.Mutex
+	state State
+}
+
+// Start connects to the session bus and exports the MPRIS interfaces.
+// Commands from MPRIS clients go through dispatch.
+func Start(dispatch Dispatcher) (*Server, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{conn: conn, dispatch: dispatch, state: State{Volume: 100}}
+	if err := s.export(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := s.requestName(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// requestName takes the well-known name, or a per-instance one as the
+// MPRIS spec suggests when another valvefm already has it.
+func (s *Server) requestName() error {
+	for _, name := range []string{busName, fmt.Sprintf("%s.instance%d", busName, os.Getpid())} {
+		reply, err := s.conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			return err
+		}
+		if reply == dbus.RequestNameReplyPrimaryOwner {
+			return nil
+		}
+	}
+	return fmt.Errorf("mpris: bus name %s is taken", busName)
+}
+
+func (s *Server) export() error {
+	root := &rootObject{s}
+	player := &playerObject{s}
+	if err := s.conn.Export(root, objectPath, rootIface); err != nil {
+		return err
+	}
+	if err := s.conn.ExportWithMap(player, playerMethods, objectPath, playerIface); err != nil {
+		return err
+	}
+	playerIntrospection := introspect.Methods(player)
+	for i, method := range playerIntrospection {
+		if name, ok := playerMethods[method.Name]; ok {
+			playerIntrospection[i].Name = name
+		}
+	}
+
+	props, err := prop.Export(s.conn, objectPath, prop.Map{
+		rootIface: {
+			"CanQuit":             {Value: true, Emit: prop.EmitConst},
+			"CanRaise":            {Value: false, Emit: prop.EmitConst},
+			"HasTrackList":        {Value: false, Emit: prop.EmitConst},
+			"Identity":            {Value: "Valve FM", Emit: prop.EmitConst},
+			"SupportedUriSchemes": {Value: []string{}, Emit: prop.EmitConst},
+			"SupportedMimeTypes":  {Value: []string{}, Emit: prop.EmitConst},
+		},
+		playerIface: {
+			"PlaybackStatus": {Value: playbackStatus(false), Emit: prop.EmitTrue},
+			"Rate":           {Value: 1.0, Emit: prop.EmitConst},
+			"MinimumRate":    {Value: 1.0, Emit: prop.EmitConst},
+			"MaximumRate":    {Value: 1.0, Emit: prop.EmitConst},
+			"Metadata":       {Value: metadata(State{}), Emit: prop.EmitTrue},
+			"Volume":         {Value: 1.0, Emit: prop.EmitTrue, Writable: true, Callback: s.setVolume},
+			"Position":       {Value: int64(0), Emit: prop.EmitFalse},
+			"CanGoNext":      {Value: true, Emit: prop.EmitConst},
+			"CanGoPrevious":  {Value: true, Emit: prop.EmitConst},
+			"CanPlay":        {Value: true, Emit: prop.EmitConst},
+			"CanPause":       {Value: true, Emit: prop.EmitConst},
+			"CanSeek":        {Value: false, Emit: prop.EmitConst},
+			"CanControl":     {Value: true, Emit: prop.EmitConst},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.props = props
+
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: rootIface, Methods: introspect.Methods(root), Properties: props.Introspection(rootIface)},
+			{Name: playerIface, Methods: playerIntrospection, Properties: props.Introspection(playerIface)},
+		},
+	}
+	return s.conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// Update publishes what changed since the last state.
+func (s *Server) Update(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.state
+	if state == prev {
+		return
+	}
+	s.state = state
+
+	if state.Playing != prev.Playing {
+		s.props.SetMust(playerIface, "PlaybackStatus", playbackStatus(state.Playing))
+	}
+	if state.UUID != prev.UUID || state.Station != prev.Station || state.Title != prev.Title || state.ArtURL != prev.ArtURL {
+		s.props.SetMust(playerIface, "Metadata", metadata(state))
+	}
+	if state.Volume != prev.Volume {
+		s.props.SetMust(playerIface, "Volume", float64(state.Volume)/100)
+	}
+}
+
+// Close releases the bus name.
+func (s *Server) Close() {
+	_ = s.conn.Close()
+}
+
+func (s *Server) playing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Playing
+}
+
+func (s *Server) call(cmd string, args any) *dbus.Error {
+	if err := s.dispatch(cmd, args); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// setVolume handles writes to the Volume property. The properties stay
+// locked while it runs and the app answers by updating them, so the
+// command is handed over rather than waited for.
+func (s *Server) setVolume(change *prop.Change) *dbus.Error {
+	level := int(math.Round(change.Value.(float64) * 100))
+	level = max(0, min(100, level))
+	go func() {
+		if err := s.dispatch(ipc.CmdVolume, ipc.VolumeArgs{Level: &level}); err != nil {
+			// Put back the volume the player really has.
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.props.SetMust(playerIface, "Volume", float64(s.state.Volume)/100)
+		}
+	}()
+	return nil
+}
+
+// rootObject implements org.mpris.MediaPlayer2.
+type rootObject struct{ s *Server }
+
+func (r *rootObject) Raise() *dbus.Error { return nil }
+
+func (r *rootObject) Quit() *dbus.Error { return r.s.call(ipc.CmdQuit, nil) }
+
+// playerObject implements org.mpris.MediaPlayer2.Player.
+type playerObject struct{ s *Server }
+
+// playerMethods renames Go methods whose MPRIS names would clash with
+// well-known Go signatures.
+var playerMethods = map[string]string{"SeekBy": "Seek"}
+
+func (p *playerObject) Next() *dbus.Error { return p.s.call(ipc.CmdNext, nil) }
+
+func (p *playerObject) Previous() *dbus.Error { return p.s.call(ipc.CmdPrev, nil) }
+
+func (p *playerObject) PlayPause() *dbus.Error { return p.s.call(ipc.CmdPlayPause, nil) }
+
+func (p *playerObject) Stop() *dbus.Error { return p.s.call(ipc.CmdStop, nil) }
+
+// Pause stops the stream; live radio cannot be held.
+func (p *playerObject) Pause() *dbus.Error {
+	if !p.s.playing() {
+		return nil
+	}
+	return p.s.call(ipc.CmdStop, nil)
+}
+
+// Play resumes the last station.
+func (p *playerObject) Play() *dbus.Error {
+	if p.s.playing() {
+		return nil
+	}
+	return p.s.call(ipc.CmdPlay, nil)
+}
+
+func (p *playerObject) SeekBy(offset int64) *dbus.Error { return nil }
+
+func (p *playerObject) SetPosition(track dbus.ObjectPath, position int64) *dbus.Error { return nil }
+
+func (p *playerObject) OpenUri(uri string) *dbus.Error {
+	return dbus.MakeFailedError(fmt.Errorf("opening URIs is not supported"))
+}
+
+func playbackStatus(playing bool) string {
+	if playing {
+		return "Playing"
+	}
+	return "Stopped"
+}
+
+// metadata describes the station as an MPRIS track. A stream title of the
+// usual "Artist - Song" form is split, with the station as the album.
+func metadata(state State) map[string]dbus.Variant {
+	if state.UUID == "" {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)}
+	}
+	md := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackID(state.UUID)),
+		"xesam:title":   dbus.MakeVariant(state.Station),
+	}
+	if state.Title != "" {
+		title := state.Title
+		if artist, song, ok := strings.Cut(title, " - "); ok {
+			md["xesam:artist"] = dbus.MakeVariant([]string{strings.TrimSpace(artist)})
+			title = strings.TrimSpace(song)
+		}
+		md["xesam:title"] = dbus.MakeVariant(title)
+		md["xesam:album"] = dbus.MakeVariant(state.Station)
+	}
+	if state.ArtURL != "" {
+		md["mpris:artUrl"] = dbus.MakeVariant(state.ArtURL)
+	}
+	return md
+}
+
+// trackID turns a station UUID into a D-Bus object path, which only allows
+// letters, digits and underscores in each element.
+func trackID(uuid string) dbus.ObjectPath {
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, uuid)
+	return dbus.ObjectPath("/org/valvefm/station/s" + id)
+}
@@ -0,0 +1,146 @@
+package mpris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"radio-tui/internal/dbustest"
+	"radio-tui/internal/ipc"
+)
+
+type dispatched struct {
+	cmd  string
+	args any
+}
+
+func startServer(t *testing.T) (*Server, chan dispatched, dbus.BusObject) {
+	t.Helper()
+	address := dbustest.StartBus(t)
+	calls := make(chan dispatched, 8)
+	server, err := Start(func(cmd string, args any) error {
+		calls <- dispatched{cmd, args}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	client, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return server, calls, client.Object(busName, objectPath)
+}
+
+func nextCall(t *testing.T, calls chan dispatched) dispatched {
+	t.Helper()
+	select {
+	case call := <-calls:
+		return call
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing dispatched")
+		return dispatched{}
+	}
+}
+
+func TestServer_MethodsDispatchIPCCommands(t *testing.T) {
+	server, calls, player := startServer(t)
+
+	for method, want := range map[string]string{
+		"PlayPause": ipc.CmdPlayPause,
+		"Next":      ipc.CmdNext,
+		"Previous":  ipc.CmdPrev,
+		"Play":      ipc.CmdPlay,
+	} {
+		if err := player.Call(playerIface+"."+method, 0).Err; err != nil {
+			t.Fatalf("%s() error = %v", method, err)
+		}
+		if call := nextCall(t, calls); call.cmd != want {
+			t.Errorf("%s dispatched %s, want %s", method, call.cmd, want)
+		}
+	}
+
+	// Pause only has something to do while playing.
+	if err := player.Call(playerIface+".Pause", 0).Err; err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	server.Update(State{Playing: true, UUID: "abc", Station: "Jazz FM", Volume: 100})
+	_ = player.Call(playerIface+".Pause", 0).Err
+	if call := nextCall(t, calls); call.cmd != ipc.CmdStop {
+		t.Errorf("Pause while playing dispatched %s, want STOP", call.cmd)
+	}
+
+	if err := player.Call(playerIface+".Seek", 0, int64(1000)).Err; err != nil {
+		t.Errorf("Seek() error = %v, want it accepted and ignored", err)
+	}
+}
+
+func TestServer_PublishesState(t *testing.T) {
+	server, _, player := startServer(t)
+	server.Update(State{
+		Playing: true,
+		UUID:    "9617a958-0601-11e8-ae97-52543be04c81",
+		Station: "Jazz FM",
+		Title:   "Miles Davis - So What",
+		ArtURL:  "https://example.com/jazz.png",
+		Volume:  80,
+	})
+
+	status, err := player.GetProperty(playerIface + ".PlaybackStatus")
+	if err != nil || status.Value() != "Playing" {
+		t.Errorf("PlaybackStatus = %v (err %v), want Playing", status, err)
+	}
+	volume, _ := player.GetProperty(playerIface + ".Volume")
+	if volume.Value() != 0.8 {
+		t.Errorf("Volume = %v, want 0.8", volume)
+	}
+
+	variant, err := player.GetProperty(playerIface + ".Metadata")
+	if err != nil {
+		t.Fatalf("Metadata error = %v", err)
+	}
+	md := variant.Value().(map[string]dbus.Variant)
+	if md["xesam:title"].Value() != "So What" || md["xesam:album"].Value() != "Jazz FM" {
+		t.Errorf("metadata = %v, want the song with the station as album", md)
+	}
+	if artists, _ := md["xesam:artist"].Value().([]string); len(artists) != 1 || artists[0] != "Miles Davis" {
+		t.Errorf("xesam:artist = %v, want [Miles Davis]", md["xesam:artist"])
+	}
+	if md["mpris:artUrl"].Value() != "https://example.com/jazz.png" {
+		t.Errorf("mpris:artUrl = %v", md["mpris:artUrl"])
+	}
+}
+
+func TestServer_SetVolume(t *testing.T) {
+	_, calls, player := startServer(t)
+	if err := player.SetProperty(playerIface+".Volume", dbus.MakeVariant(0.4)); err != nil {
+		t.Fatalf("SetProperty(Volume) error = %v", err)
+	}
+	call := nextCall(t, calls)
+	args, ok := call.args.(ipc.VolumeArgs)
+	if call.cmd != ipc.CmdVolume || !ok || args.Level == nil || *args.Level != 40 {
+		t.Errorf("dispatched %s %+v, want VOLUME level 40", call.cmd, call.args)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	md := metadata(State{})
+	if md["mpris:trackid"].Value() != noTrack || len(md) != 1 {
+		t.Errorf("metadata without a station = %v, want only NoTrack", md)
+	}
+
+	md = metadata(State{UUID: "a-b", Station: "Jazz FM", Title: "Live from the club"})
+	if md["xesam:title"].Value() != "Live from the club" || md["xesam:album"].Value() != "Jazz FM" {
+		t.Errorf("metadata = %v, want the unsplit title", md)
+	}
+	if _, ok := md["xesam:artist"]; ok {
+		t.Error("a title without \" - \" should not name an artist")
+	}
+	if id := md["mpris:trackid"].Value().(dbus.ObjectPath); !id.IsValid() {
+		t.Errorf("track id %q is not a valid object path", id)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package mpris
+
+// Server is a no-op outside Linux.
+type Server struct{}
+
+// Start reports that MPRIS is not available here.
+func Start(dispatch Dispatcher) (*Server, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Server) Update(state State) {}
+
+func (s *Server) Close() {}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxIconSize caps a downloaded favicon.
+	maxIconSize = 512 * 1024
+	// iconTimeout bounds fetching one favicon.
+	iconTimeout = 5 * time.Second
+)
+
+// IconCache keeps station favicons on disk, where notification daemons
+// can read them.
+type IconCache struct {
+	dir    string
+	client *http.Client
+}
+
+// NewIconCache caches icons in the user cache directory.
+func NewIconCache() (*IconCache, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewIconCacheIn(filepath.Join(cacheDir, "valvefm", "icons")), nil
+}
+
+// NewIconCacheIn caches icons in dir.
+func NewIconCacheIn(dir string) *IconCache {
+	return &IconCache{dir: dir, client: &http.Client{Timeout: iconTimeout}}
+}
+
+// Path returns the cached file for url, downloading it the first time.
+func (c *IconCache) Path(url string) (string, error) {
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(c.dir, hex.EncodeToString(sum[:16]))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), iconTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("icon %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIconSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxIconSize {
+		return "", errors.New("icon too large")
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", err
+	}
+	// Write aside and rename so a notification never sees half an icon.
+	tmp, err := os.CreateTemp(c.dir, "icon-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, nil
+}
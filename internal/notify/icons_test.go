@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIconCache_Path(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/logo.png":
+			_, _ = w.Write([]byte("png"))
+		case "/huge.png":
+			_, _ = w.Write([]byte(strings.Repeat("x", maxIconSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	cache := NewIconCacheIn(t.TempDir())
+
+	path, err := cache.Path(server.URL + "/logo.png")
+	if err != nil {
+		t.Fatalf("Path() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "png" {
+		t.Errorf("cached icon = %q, want the download", data)
+	}
+	if again, _ := cache.Path(server.URL + "/logo.png"); again != path || requests != 1 {
+		t.Errorf("second Path() = %q after %d requests, want the cached file", again, requests)
+	}
+
+	if _, err := cache.Path(server.URL + "/missing.png"); err == nil {
+		t.Error("Path() should fail for a missing icon")
+	}
+	if _, err := cache.Path(server.URL + "/huge.png"); err == nil {
+		t.Error("Path() should refuse an oversized icon")
+	}
+}
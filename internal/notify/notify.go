@@ -0,0 +1,122 @@
+// Package notify shows desktop notifications when the station or song
+// changes, through a Notifier for each platform.
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is the least time between two notifications.
+const DefaultInterval = 5 * time.Second
+
+// Notification is one desktop notification.
+type Notification struct {
+	Summary string
+	Body    string
+	IconURL string // station favicon, fetched and cached before showing
+	Icon    string // local icon file, filled in from IconURL
+}
+
+// Notifier shows notifications on one platform.
+type Notifier interface {
+	Notify(n Notification) error
+	Close() error
+}
+
+// ErrUnsupported is returned by New on platforms without a notifier.
+var ErrUnsupported = errors.New("desktop notifications are not available on this platform")
+
+// Service delivers notifications in the background. Notifications that
+// come faster than its interval are coalesced, so skipping through
+// stations shows only where the dial stopped.
+type Service struct {
+	notifier Notifier
+	icons    *IconCache
+	interval time.Duration
+	posts    chan Notification
+	done     chan struct{}
+	stopped  chan struct{}
+	close    sync.Once
+	closeErr error
+}
+
+// NewService starts delivering to notifier, looking icons up in icons,
+// which may be nil.
+func NewService(notifier Notifier, icons *IconCache, interval time.Duration) *Service {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	s := &Service{
+		notifier: notifier,
+		icons:    icons,
+		interval: interval,
+		posts:    make(chan Notification, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Post queues n, replacing any notification still waiting. It never
+// blocks.
+func (s *Service) Post(n Notification) {
+	for {
+		select {
+		case s.posts <- n:
+			return
+		default:
+		}
+		select {
+		case <-s.posts:
+		default:
+		}
+	}
+}
+
+// Close drops anything waiting and closes the notifier. Closing again
+// does nothing.
+func (s *Service) Close() error {
+	s.close.Do(func() {
+		close(s.done)
+		<-s.stopped
+		s.closeErr = s.notifier.Close()
+	})
+	return s.closeErr
+}
+
+func (s *Service) run() {
+	defer close(s.stopped)
+	var last time.Time
+	for {
+		var n Notification
+		select {
+		case n = <-s.posts:
+		case <-s.done:
+			return
+		}
+
+		if wait := s.interval - time.Since(last); wait > 0 {
+			timer := time.NewTimer(wait)
+		coalesce:
+			for {
+				select {
+				case n = <-s.posts:
+				case <-timer.C:
+					break coalesce
+				case <-s.done:
+					timer.Stop()
+					return
+				}
+			}
+		}
+
+		if n.IconURL != "" && s.icons != nil {
+			n.Icon, _ = s.icons.Path(n.IconURL)
+		}
+		_ = s.notifier.Notify(n)
+		last = time.Now()
+	}
+}
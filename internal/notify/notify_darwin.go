@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// scriptNotifier posts through Notification Center with osascript, which
+// has no way to set an icon.
+type scriptNotifier struct{}
+
+// New returns a Notification Center notifier.
+func New() (Notifier, error) {
+	if _, err := exec.LookPath("osascript"); err != nil {
+		return nil, ErrUnsupported
+	}
+	return scriptNotifier{}, nil
+}
+
+func (scriptNotifier) Notify(n Notification) error {
+	script := "display notification " + strconv.Quote(n.Body) + " with title \"Valve FM\" subtitle " + strconv.Quote(n.Summary)
+	return exec.Command("osascript", "-e", script).Run()
+}
+
+func (scriptNotifier) Close() error { return nil }
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsName = "org.freedesktop.Notifications"
+	notificationsPath = "/org/freedesktop/Notifications"
+)
+
+// desktopNotifier talks to the freedesktop notification daemon. Each
+// notification replaces the previous one instead of stacking up.
+type desktopNotifier struct {
+	conn *dbus.Conn
+	obj  dbus.BusObject
+
+	mu     sync.Mutex
+	lastID uint32
+}
+
+// New connects to the notification daemon on the session bus.
+func New() (Notifier, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return &desktopNotifier{conn: conn, obj: conn.Object(notificationsName, notificationsPath)}, nil
+}
+
+func (d *desktopNotifier) Notify(n Notification) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hints := map[string]dbus.Variant{
+		"category":      dbus.MakeVariant("x-valvefm.station"),
+		"desktop-entry": dbus.MakeVariant("valvefm"),
+	}
+	call := d.obj.Call(notificationsName+".Notify", 0,
+		"Valve FM", d.lastID, n.Icon, n.Summary, n.Body, []string{}, hints, int32(-1))
+	if call.Err != nil {
+		return call.Err
+	}
+	return call.Store(&d.lastID)
+}
+
+func (d *desktopNotifier) Close() error {
+	return d.conn.Close()
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"radio-tui/internal/dbustest"
+)
+
+// notificationDaemon stands in for the desktop's notification daemon.
+type notificationDaemon struct {
+	calls chan []any
+	next  uint32
+}
+
+func (d *notificationDaemon) Notify(app string, replaces uint32, icon, summary, body string, actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	d.calls <- []any{app, replaces, icon, summary, body}
+	if replaces != 0 {
+		return replaces, nil
+	}
+	d.next++
+	return d.next, nil
+}
+
+func TestDesktopNotifier(t *testing.T) {
+	address := dbustest.StartBus(t)
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer conn.Close()
+	daemon := &notificationDaemon{calls: make(chan []any, 2), next: 41}
+	if err := conn.Export(daemon, notificationsPath, notificationsName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.RequestName(notificationsName, dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer notifier.Close()
+
+	if err := notifier.Notify(Notification{Summary: "Jazz Station", Body: "Artist - Song", Icon: "/tmp/logo"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	got := <-daemon.calls
+	if got[0] != "Valve FM" || got[1] != uint32(0) || got[2] != "/tmp/logo" || got[3] != "Jazz Station" || got[4] != "Artist - Song" {
+		t.Errorf("Notify call = %v", got)
+	}
+
+	// The next notification replaces the first.
+	_ = notifier.Notify(Notification{Summary: "Next Song"})
+	if got := <-daemon.calls; got[1] != uint32(42) {
+		t.Errorf("replaces id = %v, want 42", got[1])
+	}
+}
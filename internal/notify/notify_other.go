@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package notify
+
+// New reports that there is no notifier for this platform yet.
+func New() (Notifier, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNotifier records notifications.
+type fakeNotifier struct {
+	mu     sync.Mutex
+	shown  []Notification
+	closed bool
+	seen   chan struct{}
+}
+
+func newFakeNotifier() *fakeNotifier {
+	return &fakeNotifier{seen: make(chan struct{}, 16)}
+}
+
+func (f *fakeNotifier) Notify(n Notification) error {
+	f.mu.Lock()
+	f.shown = append(f.shown, n)
+	f.mu.Unlock()
+	f.seen <- struct{}{}
+	return nil
+}
+
+func (f *fakeNotifier) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeNotifier) wait(t *testing.T) Notification {
+	t.Helper()
+	select {
+	case <-f.seen:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification shown")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.shown[len(f.shown)-1]
+}
+
+func TestService_CoalescesBursts(t *testing.T) {
+	notifier := newFakeNotifier()
+	s := NewService(notifier, nil, 100*time.Millisecond)
+
+	s.Post(Notification{Summary: "Rock FM"})
+	if got := notifier.wait(t); got.Summary != "Rock FM" {
+		t.Errorf("first notification = %q, want it shown at once", got.Summary)
+	}
+
+	// Skipping through stations shows only the last one.
+	for _, name := range []string{"Pop Radio", "Jazz Station", "News Talk"} {
+		s.Post(Notification{Summary: name})
+	}
+	if got := notifier.wait(t); got.Summary != "News Talk" {
+		t.Errorf("coalesced notification = %q, want News Talk", got.Summary)
+	}
+
+	if err := s.Close(); err != nil || !notifier.closed {
+		t.Errorf("Close() = %v, want the notifier closed", err)
+	}
+	if len(notifier.shown) != 2 {
+		t.Errorf("shown %d notifications, want 2", len(notifier.shown))
+	}
+}
+
+func TestService_FetchesIcon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	notifier := newFakeNotifier()
+	s := NewService(notifier, NewIconCacheIn(t.TempDir()), time.Millisecond)
+	defer s.Close()
+
+	s.Post(Notification{Summary: "Jazz Station", IconURL: server.URL + "/logo.png"})
+	if got := notifier.wait(t); got.Icon == "" {
+		t.Error("the notification should carry the cached favicon")
+	}
+}
+
+func TestService_CloseTwice(t *testing.T) {
+	notifier := newFakeNotifier()
+	s := NewService(notifier, nil, time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// A daemon shuts down from its quit handler and again on exit.
+	if err := s.Close(); err != nil || !notifier.closed {
+		t.Errorf("second Close() = %v", err)
+	}
+}
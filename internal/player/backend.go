@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Backend is the common interface for all audio player backends.
@@ -75,6 +76,54 @@ func (c *CompositeBackend) Stop() error {
 	return nil
 }
 
+// FadeTo glides the output level of both backends, including stations
+// they start later.
+func (c *CompositeBackend) FadeTo(level float64, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gp != nil {
+		c.gp.FadeTo(level, d)
+	}
+	if c.ext != nil {
+		c.ext.FadeTo(level, d)
+	}
+}
+
+// CanFade reports whether the level set with FadeTo is heard, which only
+// fails while an external player without volume control is playing.
+func (c *CompositeBackend) CanFade() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ext == nil {
+		return true
+	}
+	if c.active == Backend(c.ext) || (c.active == nil && c.gp == nil) {
+		return c.ext.CanFade()
+	}
+	return true
+}
+
+// Devices lists the output devices of the external player.
+func (c *CompositeBackend) Devices() ([]Device, error) {
+	c.mu.Lock()
+	ext := c.ext
+	c.mu.Unlock()
+	if ext == nil {
+		return nil, nil
+	}
+	return ext.Devices()
+}
+
+// Title returns the now-playing title when the active backend reports one.
+func (c *CompositeBackend) Title() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reporter, ok := c.active.(TitleReporter); ok {
+		return reporter.Title()
+	}
+	return ""
+}
+
 func (c *CompositeBackend) IsPlaying() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -87,13 +136,58 @@ func (c *CompositeBackend) LastURL() string {
 	return c.lastURL
 }
 
+// Options configures audio output for every backend.
+type Options struct {
+	Speaker SpeakerOptions
+	Device  string // output device for mpv/ffplay; empty uses the system default
+}
+
+var (
+	optionsMu    sync.Mutex
+	outputDevice string
+)
+
+// Configure applies audio output options. It may be called again at any time:
+// the device is used from the next external playback on, and speaker changes
+// follow ConfigureSpeaker.
+func Configure(opts Options) error {
+	optionsMu.Lock()
+	outputDevice = opts.Device
+	optionsMu.Unlock()
+	return ConfigureSpeaker(opts.Speaker)
+}
+
+// OutputDevice returns the configured output device for external players.
+func OutputDevice() string {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	return outputDevice
+}
+
 // New returns a smart player that tries pure Go audio first,
 // but falls back to system mpv/ffplay for unsupported formats (like AAC).
 func New() (Backend, error) {
-	gp := probeGoAudio()
-	ext, _ := newExternal() // optional fallback
+	return NewWith("auto", "")
+}
+
+// NewWith returns a player limited to one backend: "native" plays with pure
+// Go audio only, "mpv" and "ffplay" run only that player, and "auto" is New.
+// path, when set, is the external player to run instead of looking one up.
+func NewWith(backend, path string) (Backend, error) {
+	var gp *GoPlayer
+	if !isExternalBackend(backend) {
+		gp = probeGoAudio()
+	}
+	var ext *Player
+	var extErr error
+	if backend != "native" {
+		ext, extErr = newExternal(backend, path) // a fallback unless asked for
+	}
 
-	if gp == nil && ext == nil {
+	switch {
+	case isExternalBackend(backend) && ext == nil:
+		return nil, extErr
+	case gp == nil && ext == nil:
 		return nil, errors.New("no player backend available")
 	}
 
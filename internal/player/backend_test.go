@@ -165,6 +165,31 @@ func TestBackendInterface(t *testing.T) {
 	var _ Backend = (*Player)(nil)
 	var _ Backend = (*CompositeBackend)(nil)
 	var _ Backend = (*GoPlayer)(nil)
+	var _ Leveler = (*Player)(nil)
+	var _ Leveler = (*CompositeBackend)(nil)
+	var _ FadeReporter = (*CompositeBackend)(nil)
+}
+
+func TestCompositeBackend_CanFade(t *testing.T) {
+	mpv := &Player{backend: "mpv"}
+	ffplay := &Player{backend: "ffplay"}
+	tests := []struct {
+		name    string
+		backend *CompositeBackend
+		want    bool
+	}{
+		{"nothing playing", &CompositeBackend{ext: ffplay}, false},
+		{"mpv playing", &CompositeBackend{ext: mpv, active: mpv}, true},
+		{"ffplay playing", &CompositeBackend{ext: ffplay, active: ffplay}, false},
+		{"no external player", &CompositeBackend{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backend.CanFade(); got != tt.want {
+				t.Errorf("CanFade() = %v, want %v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestMockBackend_Behavior(t *testing.T) {
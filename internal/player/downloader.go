@@ -137,7 +137,7 @@ func downloadDir() (string, error) {
 	return filepath.Join(configDir, "valvefm", "bin"), nil
 }
 
-func findDownloadedPlayer() (string, string) {
+func findDownloadedPlayer(want string) (string, string) {
 	dir, err := downloadDir()
 	if err != nil {
 		return "", ""
@@ -154,7 +154,7 @@ func findDownloadedPlayer() (string, string) {
 	}
 	for _, candidate := range candidates {
 		path := filepath.Join(dir, candidate.name)
-		if isExecutable(path) {
+		if accepts(want, candidate.backend) && isExecutable(path) {
 			return path, candidate.backend
 		}
 	}
@@ -0,0 +1,159 @@
+package player
+
+import (
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// fadeInDuration is how long a newly tuned station takes to come up.
+	fadeInDuration = 250 * time.Millisecond
+	// fadeOutDuration is how long a station takes to die away when stopped
+	// or replaced, so switching never cuts the audio mid-sample.
+	fadeOutDuration = 400 * time.Millisecond
+)
+
+// Leveler is implemented by backends that can glide their output level on
+// the shared mixer, e.g. to duck a station under tuning static.
+type Leveler interface {
+	FadeTo(level float64, d time.Duration)
+}
+
+// FadeReporter is implemented by backends whose level changes are heard
+// only with some players, e.g. not while ffplay plays.
+type FadeReporter interface {
+	CanFade() bool
+}
+
+// DeviceLister is implemented by backends that can list the output devices
+// Options.Device selects among.
+type DeviceLister interface {
+	Devices() ([]Device, error)
+}
+
+// TitleReporter is implemented by backends that read the now-playing title
+// from in-stream metadata.
+type TitleReporter interface {
+	Title() string
+}
+
+// Fader scales a streamer by a gain that glides toward a target level, so
+// streams sharing the speaker mixer can be crossfaded instead of cut.
+//
+// Once a Fader is playing, its methods must be called with the mixer
+// locked; the package helpers below take care of that.
+type Fader struct {
+	Streamer beep.Streamer
+
+	level  float64
+	target float64
+	step   float64
+	drain  bool
+	done   func()
+}
+
+// NewFader wraps s, starting at the given gain level.
+func NewFader(s beep.Streamer, level float64) *Fader {
+	level = clampLevel(level)
+	return &Fader{Streamer: s, level: level, target: level}
+}
+
+// FadeTo glides the gain to level over the given number of samples.
+// A non-positive sample count applies the level immediately.
+func (f *Fader) FadeTo(level float64, samples int) {
+	f.target = clampLevel(level)
+	f.drain = false
+	if samples <= 0 {
+		f.level = f.target
+		f.step = 0
+		return
+	}
+	f.step = (f.target - f.level) / float64(samples)
+}
+
+// FadeOut glides the gain to silence over the given number of samples and
+// then reports the stream as drained, which removes it from the mixer.
+// done, if non-nil, runs once the fade has finished.
+func (f *Fader) FadeOut(samples int, done func()) {
+	f.FadeTo(0, samples)
+	f.drain = true
+	f.done = done
+}
+
+// Level returns the current gain.
+func (f *Fader) Level() float64 {
+	return f.level
+}
+
+// Target returns the gain the fader is gliding toward.
+func (f *Fader) Target() float64 {
+	return f.target
+}
+
+func (f *Fader) Stream(samples [][2]float64) (int, bool) {
+	if f.drain && f.level <= 0 {
+		f.finish()
+		return 0, false
+	}
+	if f.Streamer == nil {
+		return 0, false
+	}
+
+	n, ok := f.Streamer.Stream(samples)
+	for i := 0; i < n; i++ {
+		if f.level != f.target {
+			f.level += f.step
+			if (f.step > 0 && f.level > f.target) || (f.step < 0 && f.level < f.target) || f.step == 0 {
+				f.level = f.target
+			}
+		}
+		samples[i][0] *= f.level
+		samples[i][1] *= f.level
+	}
+	if f.drain && f.level <= 0 {
+		f.finish()
+		return n, false
+	}
+	return n, ok
+}
+
+func (f *Fader) Err() error {
+	if f.Streamer == nil {
+		return nil
+	}
+	return f.Streamer.Err()
+}
+
+func (f *Fader) finish() {
+	if f.done != nil {
+		done := f.done
+		f.done = nil
+		go done()
+	}
+}
+
+// fadeTo glides a playing fader to level over d, locking the mixer.
+func fadeTo(f *Fader, level float64, d time.Duration) {
+	speakerMu.Lock()
+	f.FadeTo(level, mixerRate().N(d))
+	speakerMu.Unlock()
+}
+
+// fadeOut fades a playing fader to silence over d and drops it from the
+// mixer, running done afterwards.
+func fadeOut(f *Fader, d time.Duration, done func()) {
+	speakerMu.Lock()
+	f.FadeOut(mixerRate().N(d), done)
+	speakerMu.Unlock()
+}
+
+func clampLevel(level float64) float64 {
+	if level < 0 {
+		return 0
+	}
+	if level > 1 {
+		return 1
+	}
+	return level
+}
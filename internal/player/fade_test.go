@@ -0,0 +1,131 @@
+package player
+
+import (
+	"math"
+	"testing"
+)
+
+// constStreamer emits a constant sample forever.
+type constStreamer struct{ value float64 }
+
+func (c constStreamer) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		samples[i][0] = c.value
+		samples[i][1] = c.value
+	}
+	return len(samples), true
+}
+
+func (c constStreamer) Err() error { return nil }
+
+func TestFader_AppliesLevel(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 0.5)
+
+	samples := make([][2]float64, 4)
+	n, ok := f.Stream(samples)
+	if n != 4 || !ok {
+		t.Fatalf("Stream() = %d, %v; want 4, true", n, ok)
+	}
+	for i, s := range samples {
+		if s[0] != 0.5 || s[1] != 0.5 {
+			t.Errorf("samples[%d] = %v, want [0.5 0.5]", i, s)
+		}
+	}
+}
+
+func TestFader_FadeTo_Ramps(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 0)
+	f.FadeTo(1, 10)
+
+	samples := make([][2]float64, 20)
+	f.Stream(samples)
+
+	for i := 1; i < 10; i++ {
+		if samples[i][0] <= samples[i-1][0] {
+			t.Fatalf("sample %d = %v, want rising above %v", i, samples[i][0], samples[i-1][0])
+		}
+	}
+	if samples[19][0] != 1 {
+		t.Errorf("final sample = %v, want 1", samples[19][0])
+	}
+	if f.Level() != 1 {
+		t.Errorf("Level() = %v, want 1", f.Level())
+	}
+}
+
+func TestFader_FadeTo_Immediate(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 1)
+	f.FadeTo(0.25, 0)
+
+	if f.Level() != 0.25 || f.Target() != 0.25 {
+		t.Errorf("Level/Target = %v/%v, want 0.25/0.25", f.Level(), f.Target())
+	}
+}
+
+func TestFader_ClampsLevel(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 3)
+	if f.Level() != 1 {
+		t.Errorf("Level() = %v, want 1", f.Level())
+	}
+	f.FadeTo(-1, 0)
+	if f.Level() != 0 {
+		t.Errorf("Level() = %v, want 0", f.Level())
+	}
+}
+
+func TestFader_FadeOut_Drains(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 1)
+	done := make(chan struct{})
+	f.FadeOut(8, func() { close(done) })
+
+	samples := make([][2]float64, 16)
+	n, ok := f.Stream(samples)
+	if ok {
+		t.Fatal("Stream() should report drained once faded out")
+	}
+	if n != 16 {
+		t.Errorf("Stream() n = %d, want 16", n)
+	}
+	if math.Abs(samples[15][0]) > 1e-9 {
+		t.Errorf("tail sample = %v, want silence", samples[15][0])
+	}
+
+	<-done
+
+	n, ok = f.Stream(samples)
+	if n != 0 || ok {
+		t.Errorf("Stream() after drain = %d, %v; want 0, false", n, ok)
+	}
+}
+
+func TestFader_FadeTo_CancelsFadeOut(t *testing.T) {
+	f := NewFader(constStreamer{value: 1}, 1)
+	f.FadeOut(4, nil)
+	f.FadeTo(1, 4)
+
+	samples := make([][2]float64, 8)
+	if _, ok := f.Stream(samples); !ok {
+		t.Error("Stream() should keep playing after FadeTo overrides FadeOut")
+	}
+}
+
+func TestFader_NilStreamer(t *testing.T) {
+	f := NewFader(nil, 1)
+	n, ok := f.Stream(make([][2]float64, 4))
+	if n != 0 || ok {
+		t.Errorf("Stream() = %d, %v; want 0, false", n, ok)
+	}
+	if f.Err() != nil {
+		t.Errorf("Err() = %v, want nil", f.Err())
+	}
+}
+
+func TestNoisePlayer_NilSafe(t *testing.T) {
+	var n *NoisePlayer
+	n.Start()
+	n.Blend(0.5)
+	n.Stop()
+	if n.IsPlaying() {
+		t.Error("nil NoisePlayer should never report playing")
+	}
+}
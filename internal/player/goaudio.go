@@ -4,38 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gopxl/beep/v2"
 	"github.com/gopxl/beep/v2/mp3"
-	"github.com/gopxl/beep/v2/speaker"
 )
 
-var (
-	speakerOnce sync.Once
-	speakerErr  error
-)
-
-// EnsureSpeaker initializes the audio device at 44100 Hz exactly once.
-func EnsureSpeaker() error {
-	speakerOnce.Do(func() {
-		sr := beep.SampleRate(44100)
-		speakerErr = speaker.Init(sr, sr.N(time.Second/10))
-	})
-	return speakerErr
-}
-
 // GoPlayer plays MP3 HTTP streams using the high-level beep library.
 // It handles resampling automatically, fixing pitch issues with different sample rates.
 type GoPlayer struct {
 	mu          sync.Mutex
 	streamer    beep.StreamSeekCloser
-	ctrl        *beep.Ctrl
+	fader       *Fader
 	resp        *http.Response
 	lastURL     string
+	duck        float64 // attenuation set by FadeTo; zero plays at full level
 	playing     bool
 	initialized bool
+
+	// titleMu guards the title apart from mu: it is set from the decoder,
+	// which runs with the mixer locked. Only titleSrc, the current stream's
+	// reader, may set it; a stream still fading out may not.
+	titleMu  sync.Mutex
+	title    string
+	titleSrc *icyReader
 }
 
 // NewGoPlayer creates a GoPlayer instance.
@@ -76,7 +70,7 @@ func (g *GoPlayer) Play(url string) error {
 		return fmt.Errorf("request: %w", err)
 	}
 	req.Header.Set("User-Agent", "ValveFM/1.0")
-	req.Header.Set("Icy-MetaData", "0")
+	req.Header.Set("Icy-MetaData", "1")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -87,39 +81,54 @@ func (g *GoPlayer) Play(url string) error {
 		return fmt.Errorf("stream HTTP %d", resp.StatusCode)
 	}
 
+	// Strip in-stream metadata, keeping the now-playing title.
+	body := resp.Body
+	if metaInt, err := strconv.Atoi(resp.Header.Get("Icy-Metaint")); err == nil && metaInt > 0 {
+		reader := newICYReader(body, metaInt, nil)
+		reader.onTitle = func(title string) { g.setTitle(reader, title) }
+		g.resetTitle(reader)
+		body = reader
+	}
+
 	// Decode MP3 via beep (wraps go-mp3)
-	streamer, format, err := mp3.Decode(resp.Body)
+	streamer, format, err := mp3.Decode(body)
 	if err != nil {
 		resp.Body.Close()
 		return fmt.Errorf("mp3 decode: %w", err)
 	}
 
-	// Resample to our standard 44100Hz rate
-	resampled := beep.Resample(4, format.SampleRate, beep.SampleRate(44100), streamer)
+	// Resample to the mixer rate
+	resampled := beep.Resample(4, format.SampleRate, mixerRate(), streamer)
 
-	// Wrap in a Ctrl to allow pausing/stopping nicely
-	ctrl := &beep.Ctrl{Streamer: resampled, Paused: false}
+	// Wrap in a Fader so the station comes up smoothly and can be faded out
+	// or ducked under tuning static later.
+	fader := NewFader(resampled, 0)
+	fader.FadeTo(1-g.duck, mixerRate().N(fadeInDuration))
 
 	// Play!
-	speaker.Play(beep.Seq(ctrl, beep.Callback(func() {
-		g.mu.Lock()
-		defer g.mu.Unlock()
-		// Callback when stream ends
-		if g.ctrl == ctrl {
-			g.playing = false
-			g.cleanupLocked()
-		}
+	speakerPlay(beep.Seq(fader, beep.Callback(func() {
+		// The callback runs on the device goroutine with the mixer locked;
+		// hop off it before taking g.mu, which Play holds while locking the mixer.
+		go func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			// Callback when stream ends
+			if g.fader == fader {
+				g.fader = nil
+				g.cleanupLocked()
+			}
+		}()
 	})))
 
 	g.streamer = streamer
-	g.ctrl = ctrl
+	g.fader = fader
 	g.resp = resp
 	g.playing = true
 
 	return nil
 }
 
-// Stop halts playback immediately.
+// Stop fades playback out and releases the stream once it is silent.
 func (g *GoPlayer) Stop() error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -127,12 +136,52 @@ func (g *GoPlayer) Stop() error {
 	return nil
 }
 
+// FadeTo glides the output level of the current (and any future) station.
+func (g *GoPlayer) FadeTo(level float64, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	level = clampLevel(level)
+	g.duck = 1 - level
+	if g.fader != nil {
+		fadeTo(g.fader, level, d)
+	}
+}
+
+// Title returns the now-playing title announced by the stream, if any.
+func (g *GoPlayer) Title() string {
+	g.titleMu.Lock()
+	defer g.titleMu.Unlock()
+	return g.title
+}
+
+func (g *GoPlayer) setTitle(src *icyReader, title string) {
+	g.titleMu.Lock()
+	defer g.titleMu.Unlock()
+	if g.titleSrc == src {
+		g.title = title
+	}
+}
+
+// resetTitle clears the title and hands it to a new stream, or to none.
+func (g *GoPlayer) resetTitle(src *icyReader) {
+	g.titleMu.Lock()
+	defer g.titleMu.Unlock()
+	g.title = ""
+	g.titleSrc = src
+}
+
 func (g *GoPlayer) stopLocked() {
-	// Stop existing playback by pausing the controller (which removes it from mixer eventually)
-	// and closing the streamer/response.
-	if g.ctrl != nil {
-		g.ctrl.Paused = true
-		g.ctrl = nil
+	// Hand the old stream to the mixer to fade out; it is closed once silent
+	// so the next station can start without waiting.
+	if g.fader != nil {
+		streamer := g.streamer
+		fadeOut(g.fader, fadeOutDuration, func() {
+			if streamer != nil {
+				streamer.Close()
+			}
+		})
+		g.fader = nil
+		g.streamer = nil
 	}
 	g.cleanupLocked()
 }
@@ -147,6 +196,7 @@ func (g *GoPlayer) cleanupLocked() {
 	// Explicitly closing resp.Body here could cause issues if streamer already closed it.
 	g.resp = nil
 	g.playing = false
+	g.resetTitle(nil)
 }
 
 func (g *GoPlayer) IsPlaying() bool {
@@ -165,4 +215,8 @@ func probeGoAudio() *GoPlayer {
 	return NewGoPlayer()
 }
 
-var _ Backend = (*GoPlayer)(nil)
+var (
+	_ Backend       = (*GoPlayer)(nil)
+	_ Leveler       = (*GoPlayer)(nil)
+	_ TitleReporter = (*GoPlayer)(nil)
+)
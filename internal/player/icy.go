@@ -0,0 +1,76 @@
+package player
+
+import (
+	"io"
+	"strings"
+)
+
+// icyReader strips the metadata blocks that SHOUTcast/Icecast servers
+// interleave with the audio when asked for "Icy-MetaData: 1", reporting
+// each StreamTitle it finds.
+type icyReader struct {
+	body      io.ReadCloser
+	metaInt   int
+	remaining int // audio bytes left before the next metadata block
+	onTitle   func(string)
+}
+
+func newICYReader(body io.ReadCloser, metaInt int, onTitle func(string)) *icyReader {
+	return &icyReader{body: body, metaInt: metaInt, remaining: metaInt, onTitle: onTitle}
+}
+
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		if err := r.readMeta(); err != nil {
+			return 0, err
+		}
+		r.remaining = r.metaInt
+	}
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.body.Read(p)
+	r.remaining -= n
+	return n, err
+}
+
+func (r *icyReader) Close() error {
+	return r.body.Close()
+}
+
+func (r *icyReader) readMeta() error {
+	var size [1]byte
+	if _, err := io.ReadFull(r.body, size[:]); err != nil {
+		return err
+	}
+	if size[0] == 0 {
+		return nil
+	}
+	meta := make([]byte, int(size[0])*16)
+	if _, err := io.ReadFull(r.body, meta); err != nil {
+		return err
+	}
+	if title, ok := parseStreamTitle(string(meta)); ok && r.onTitle != nil {
+		r.onTitle(title)
+	}
+	return nil
+}
+
+// parseStreamTitle extracts StreamTitle from a block such as
+// "StreamTitle='Artist - Song';" padded with NULs.
+func parseStreamTitle(meta string) (string, bool) {
+	const key = "StreamTitle='"
+	start := strings.Index(meta, key)
+	if start < 0 {
+		return "", false
+	}
+	rest := meta[start+len(key):]
+	end := strings.Index(rest, "';")
+	if end < 0 {
+		end = strings.LastIndex(rest, "'")
+	}
+	if end < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(rest[:end]), true
+}
@@ -0,0 +1,75 @@
+package player
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// icyBlock pads metadata to a multiple of 16 bytes behind its length byte.
+func icyBlock(meta string) []byte {
+	size := (len(meta) + 15) / 16
+	block := make([]byte, 1+size*16)
+	block[0] = byte(size)
+	copy(block[1:], meta)
+	return block
+}
+
+func TestICYReader_StripsMetadata(t *testing.T) {
+	var stream bytes.Buffer
+	stream.WriteString("abcd")
+	stream.Write(icyBlock("StreamTitle='Artist - Song';StreamUrl='';"))
+	stream.WriteString("efgh")
+	stream.WriteByte(0) // empty metadata block
+	stream.WriteString("ij")
+
+	var titles []string
+	reader := newICYReader(io.NopCloser(&stream), 4, func(title string) {
+		titles = append(titles, title)
+	})
+
+	audio, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(audio) != "abcdefghij" {
+		t.Errorf("audio = %q, want %q", audio, "abcdefghij")
+	}
+	if len(titles) != 1 || titles[0] != "Artist - Song" {
+		t.Errorf("titles = %q, want [Artist - Song]", titles)
+	}
+}
+
+func TestICYReader_TruncatedMetadata(t *testing.T) {
+	stream := strings.NewReader("abcd\x02StreamTitle")
+	reader := newICYReader(io.NopCloser(stream), 4, nil)
+
+	audio, err := io.ReadAll(reader)
+	if err == nil {
+		t.Error("a truncated metadata block should surface an error")
+	}
+	if string(audio) != "abcd" {
+		t.Errorf("audio = %q, want %q", audio, "abcd")
+	}
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	tests := []struct {
+		meta   string
+		want   string
+		wantOK bool
+	}{
+		{"StreamTitle='Artist - Song';StreamUrl='';\x00\x00", "Artist - Song", true},
+		{"StreamTitle='It's Alright';", "It's Alright", true},
+		{"StreamTitle='';", "", true},
+		{"StreamUrl='http://example.com';", "", false},
+		{"StreamTitle='Unterminated", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseStreamTitle(tt.meta)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseStreamTitle(%q) = %q, %v; want %q, %v", tt.meta, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -2,38 +2,84 @@ package player
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
+// mpvGlideStep is how often a volume glide updates mpv.
+const mpvGlideStep = 100 * time.Millisecond
+
 type Player struct {
 	mu      sync.Mutex
 	cmd     *exec.Cmd
 	backend string
 	path    string
 	lastURL string
+
+	// cut is 1 minus the output level FadeTo asked for, so the zero value
+	// plays at full level; shown is the cut mpv plays at right now.
+	cut   float64
+	shown float64
+	glide int    // bumped to cancel a running glide
+	ipc   string // mpv's volume socket, if the playing mpv has one
 }
 
-func newExternal() (*Player, error) {
-	if path, backend := findBundledPlayer(); path != "" {
+// newExternal finds an external player: want limits the search to "mpv"
+// or "ffplay", and path, when set, is used instead of searching.
+func newExternal(want, path string) (*Player, error) {
+	if path != "" {
+		if !isExecutable(path) {
+			return nil, fmt.Errorf("%s is not an executable file", path)
+		}
+		backend := want
+		if !isExternalBackend(backend) {
+			backend = backendOf(path)
+		}
 		return &Player{backend: backend, path: path}, nil
 	}
-	if path, backend := findDownloadedPlayer(); path != "" {
+	if path, backend := findBundledPlayer(want); path != "" {
 		return &Player{backend: backend, path: path}, nil
 	}
-	if path, err := exec.LookPath("mpv"); err == nil {
-		return &Player{backend: "mpv", path: path}, nil
+	if path, backend := findDownloadedPlayer(want); path != "" {
+		return &Player{backend: backend, path: path}, nil
 	}
-	if path, err := exec.LookPath("ffplay"); err == nil {
-		return &Player{backend: "ffplay", path: path}, nil
+	for _, backend := range []string{"mpv", "ffplay"} {
+		if !accepts(want, backend) {
+			continue
+		}
+		if path, err := exec.LookPath(backend); err == nil {
+			return &Player{backend: backend, path: path}, nil
+		}
+	}
+	if isExternalBackend(want) {
+		return nil, fmt.Errorf("%s not found (bundle it or add to PATH)", want)
 	}
 	return nil, errors.New("mpv or ffplay not found (bundle one or add to PATH)")
 }
 
+func isExternalBackend(backend string) bool {
+	return backend == "mpv" || backend == "ffplay"
+}
+
+// accepts reports whether a search limited to want takes backend.
+func accepts(want, backend string) bool {
+	return !isExternalBackend(want) || want == backend
+}
+
+// backendOf guesses the player an executable path names.
+func backendOf(path string) string {
+	if strings.Contains(strings.ToLower(filepath.Base(path)), "ffplay") {
+		return "ffplay"
+	}
+	return "mpv"
+}
+
 func (p *Player) Play(url string) error {
 	if url == "" {
 		return errors.New("stream url is required")
@@ -45,14 +91,19 @@ func (p *Player) Play(url string) error {
 	_ = p.stopLocked()
 	p.lastURL = url
 
-	var cmd *exec.Cmd
-	switch p.backend {
-	case "mpv":
-		cmd = exec.Command(p.path, "--no-video", "--quiet", url)
-	case "ffplay":
-		cmd = exec.Command(p.path, "-nodisp", "-autoexit", "-loglevel", "quiet", url)
-	default:
-		return errors.New("no audio backend available")
+	args, env, err := externalArgs(p.backend, url, OutputDevice())
+	if err != nil {
+		return err
+	}
+	if p.backend == "mpv" {
+		p.glide++
+		p.shown = p.cut
+		p.ipc = mpvIPCPath()
+		args = append(mpvLevelArgs(1-p.cut, p.ipc), args...)
+	}
+	cmd := exec.Command(p.path, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
 
 	cmd.Stdout = io.Discard
@@ -74,6 +125,132 @@ func (p *Player) Play(url string) error {
 	return nil
 }
 
+// externalArgs builds the command line for an external player. ffplay has
+// no device flag, so the device is handed to SDL through AUDIODEV instead.
+func externalArgs(backend, url, device string) ([]string, []string, error) {
+	switch backend {
+	case "mpv":
+		args := []string{"--no-video", "--quiet"}
+		if device != "" {
+			args = append(args, "--audio-device="+device)
+		}
+		return append(args, url), nil, nil
+	case "ffplay":
+		args := []string{"-nodisp", "-autoexit", "-loglevel", "quiet", url}
+		if device != "" {
+			return args, []string{"AUDIODEV=" + device}, nil
+		}
+		return args, nil, nil
+	default:
+		return nil, nil, errors.New("no audio backend available")
+	}
+}
+
+// mpvLevelArgs starts mpv at the given output level and, when ipcPath is
+// set, listens there so FadeTo can change the volume while it plays.
+func mpvLevelArgs(level float64, ipcPath string) []string {
+	args := []string{fmt.Sprintf("--volume=%.0f", clampLevel(level)*100)}
+	if ipcPath != "" {
+		args = append(args, "--input-ipc-server="+ipcPath)
+	}
+	return args
+}
+
+// CanFade reports whether FadeTo is heard: mpv takes the level at start
+// and through its IPC server, ffplay only plays at full level.
+func (p *Player) CanFade() bool {
+	return p.backend == "mpv"
+}
+
+// FadeTo glides mpv's volume to level over d. The level is remembered for
+// the stations played later.
+func (p *Player) FadeTo(level float64, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cut = 1 - clampLevel(level)
+	if p.backend != "mpv" || p.cmd == nil || p.shown == p.cut {
+		return
+	}
+	p.glide++
+	go p.glideTo(p.glide, p.ipc, p.shown, p.cut, d)
+}
+
+// glideTo steps mpv's volume from one cut to another until a newer glide
+// or playback replaces it.
+func (p *Player) glideTo(glide int, path string, from, to float64, d time.Duration) {
+	if path == "" {
+		return
+	}
+	conn, err := dialMPV(path)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	steps := max(1, int(d/mpvGlideStep))
+	for i := 1; i <= steps; i++ {
+		if i > 1 {
+			time.Sleep(mpvGlideStep)
+		}
+		cut := from + (to-from)*float64(i)/float64(steps)
+		p.mu.Lock()
+		current := p.glide == glide && p.cmd != nil
+		if current {
+			p.shown = cut
+		}
+		p.mu.Unlock()
+		if !current {
+			return
+		}
+		command := fmt.Sprintf("{\"command\":[\"set_property\",\"volume\",%.1f]}\n", (1-cut)*100)
+		if _, err := io.WriteString(conn, command); err != nil {
+			return
+		}
+	}
+}
+
+// Device is an audio output an external player can be pointed at.
+type Device struct {
+	Name        string // the value for Options.Device
+	Description string
+}
+
+// Devices lists the outputs mpv knows. ffplay cannot list them, so it
+// reports none; its device is named by hand.
+func (p *Player) Devices() ([]Device, error) {
+	if p.backend != "mpv" {
+		return nil, nil
+	}
+	out, err := exec.Command(p.path, "--audio-device=help").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing audio devices: %w", err)
+	}
+	return parseMPVDevices(string(out)), nil
+}
+
+// parseMPVDevices reads the lines of "mpv --audio-device=help", such as
+//
+//	'pulse/alsa_output.usb-dac' (USB DAC Analog Stereo)
+//
+// leaving out "auto", which is the system default.
+func parseMPVDevices(out string) []Device {
+	var devices []Device
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "'") {
+			continue
+		}
+		name, rest, ok := strings.Cut(line[1:], "'")
+		if !ok || name == "" || name == "auto" {
+			continue
+		}
+		description := strings.TrimSpace(rest)
+		description = strings.TrimSuffix(strings.TrimPrefix(description, "("), ")")
+		devices = append(devices, Device{Name: name, Description: description})
+	}
+	return devices
+}
+
 func (p *Player) Stop() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -88,6 +265,10 @@ func (p *Player) stopLocked() error {
 		_ = p.cmd.Process.Kill()
 	}
 	p.cmd = nil
+	if p.ipc != "" {
+		removeMPVIPC(p.ipc)
+		p.ipc = ""
+	}
 	return nil
 }
 
@@ -103,7 +284,7 @@ func (p *Player) LastURL() string {
 	return p.lastURL
 }
 
-func findBundledPlayer() (string, string) {
+func findBundledPlayer(want string) (string, string) {
 	exe, err := os.Executable()
 	if err != nil {
 		return "", ""
@@ -122,7 +303,7 @@ func findBundledPlayer() (string, string) {
 
 	for _, candidate := range candidates {
 		path := filepath.Join(dir, candidate.name)
-		if isExecutable(path) {
+		if accepts(want, candidate.backend) && isExecutable(path) {
 			return path, candidate.backend
 		}
 	}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package player
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"radio-tui/internal/ipc"
+)
+
+// mpvIPCPath is the socket mpv listens on for volume changes, one per
+// valvefm process. It sits next to the control socket, where other users
+// cannot reach it; without that directory mpv plays without one.
+func mpvIPCPath() string {
+	dir, err := ipc.PrivateDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("mpv-%d.sock", os.Getpid()))
+}
+
+func dialMPV(path string) (io.WriteCloser, error) {
+	return net.DialTimeout("unix", path, time.Second)
+}
+
+// removeMPVIPC removes the socket a killed mpv leaves behind.
+func removeMPVIPC(path string) {
+	_ = os.Remove(path)
+}
@@ -0,0 +1,20 @@
+package player
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mpvIPCPath is the named pipe mpv listens on for volume changes, one per
+// valvefm process.
+func mpvIPCPath() string {
+	return fmt.Sprintf(`\\.\pipe\valvefm-mpv-%d`, os.Getpid())
+}
+
+func dialMPV(path string) (io.WriteCloser, error) {
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
+
+// removeMPVIPC does nothing: a named pipe goes away with mpv.
+func removeMPVIPC(string) {}
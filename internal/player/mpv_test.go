@@ -3,6 +3,8 @@ package player
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -171,9 +173,103 @@ func TestNewExternal_NoPlayerAvailable(t *testing.T) {
 	// In most test environments, mpv/ffplay may not be installed
 	// The function should either return a player or an error, never panic
 
-	player, err := newExternal()
+	player, err := newExternal("auto", "")
 	// Either player is found or error is returned
 	if player == nil && err == nil {
 		t.Error("newExternal() should return either a player or an error")
 	}
 }
+
+func TestExternalArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  string
+		device   string
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{"mpv default device", "mpv", "", []string{"--no-video", "--quiet", "http://s"}, nil},
+		{"mpv with device", "mpv", "pulse/usb-dac", []string{"--no-video", "--quiet", "--audio-device=pulse/usb-dac", "http://s"}, nil},
+		{"ffplay default device", "ffplay", "", []string{"-nodisp", "-autoexit", "-loglevel", "quiet", "http://s"}, nil},
+		{"ffplay with device", "ffplay", "hw:1", []string{"-nodisp", "-autoexit", "-loglevel", "quiet", "http://s"}, []string{"AUDIODEV=hw:1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, env, err := externalArgs(tt.backend, "http://s", tt.device)
+			if err != nil {
+				t.Fatalf("externalArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", args, tt.wantArgs)
+			}
+			if !reflect.DeepEqual(env, tt.wantEnv) {
+				t.Errorf("env = %q, want %q", env, tt.wantEnv)
+			}
+		})
+	}
+
+	if _, _, err := externalArgs("vlc", "http://s", ""); err == nil {
+		t.Error("externalArgs() should reject unknown backends")
+	}
+}
+
+func TestMPVLevelArgs(t *testing.T) {
+	if got, want := mpvLevelArgs(0.5, ""), []string{"--volume=50"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mpvLevelArgs() = %q, want %q", got, want)
+	}
+	got := mpvLevelArgs(2, "/tmp/mpv.sock")
+	want := []string{"--volume=100", "--input-ipc-server=/tmp/mpv.sock"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mpvLevelArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestMPVIPCPath_Private(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mpv listens on a named pipe on Windows")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	path := mpvIPCPath()
+	if path == "" || filepath.Dir(path) == filepath.Clean(os.TempDir()) {
+		t.Fatalf("mpvIPCPath() = %q, want a path outside the shared temp dir", path)
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("socket directory permissions = %o, want 700", perm)
+	}
+}
+
+func TestPlayer_FadeToRemembersLevel(t *testing.T) {
+	p := &Player{backend: "mpv"}
+	p.FadeTo(0.25, 0)
+	if got := 1 - p.cut; got != 0.25 {
+		t.Errorf("level = %v, want 0.25", got)
+	}
+	if !p.CanFade() {
+		t.Error("mpv should report that it can fade")
+	}
+	if (&Player{backend: "ffplay"}).CanFade() {
+		t.Error("ffplay should report that it cannot fade")
+	}
+}
+
+func TestParseMPVDevices(t *testing.T) {
+	out := `List of detected audio devices:
+  'auto' (Autoselect device)
+  'pulse' (Default (pulse))
+  'pulse/alsa_output.usb-dac' (USB DAC Analog Stereo)
+`
+	want := []Device{
+		{Name: "pulse", Description: "Default (pulse)"},
+		{Name: "pulse/alsa_output.usb-dac", Description: "USB DAC Analog Stereo"},
+	}
+	if got := parseMPVDevices(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMPVDevices() = %+v, want %+v", got, want)
+	}
+}
@@ -3,16 +3,20 @@ package player
 import (
 	"math/rand"
 	"sync"
-
-	"github.com/gopxl/beep/v2"
-	"github.com/gopxl/beep/v2/speaker"
+	"time"
 )
 
-// NoisePlayer plays infinite pink radio static during loading/buffering periods.
+// noiseFadeDuration smooths static level changes so per-frame dial updates
+// blend into each other instead of stepping.
+const noiseFadeDuration = 60 * time.Millisecond
+
+// NoisePlayer plays infinite pink radio static during loading/buffering periods
+// and while the dial is between stations.
 type NoisePlayer struct {
-	mu      sync.Mutex
-	ctrl    *beep.Ctrl
-	playing bool
+	mu    sync.Mutex
+	fader *Fader
+	held  bool
+	blend float64
 }
 
 // NewNoisePlayer creates a NoisePlayer instance.
@@ -20,40 +24,76 @@ func NewNoisePlayer() *NoisePlayer {
 	return &NoisePlayer{}
 }
 
-// Start begins playing radio static. Safe to call multiple times.
+// Start holds radio static at full level. Safe to call multiple times.
 func (n *NoisePlayer) Start() {
+	if n == nil {
+		return
+	}
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	n.held = true
+	n.applyLocked()
+}
 
-	if n.playing {
+// Stop releases the static held by Start; any tuning blend keeps playing.
+// Safe to call when not playing.
+func (n *NoisePlayer) Stop() {
+	if n == nil {
 		return
 	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.held = false
+	n.applyLocked()
+}
 
-	if err := EnsureSpeaker(); err != nil {
+// Blend sets the tuning static level, from 0 when the dial is locked on a
+// station to 1 when it sits halfway between two.
+func (n *NoisePlayer) Blend(level float64) {
+	if n == nil {
 		return
 	}
-
-	ctrl := &beep.Ctrl{Streamer: &radioStaticStreamer{}, Paused: false}
-	speaker.Play(ctrl)
-	n.ctrl = ctrl
-	n.playing = true
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.blend = clampLevel(level)
+	n.applyLocked()
 }
 
-// Stop halts static playback. Safe to call when not playing.
-func (n *NoisePlayer) Stop() {
+// IsPlaying reports whether static is currently audible or fading.
+func (n *NoisePlayer) IsPlaying() bool {
+	if n == nil {
+		return false
+	}
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	return n.fader != nil
+}
 
-	if !n.playing || n.ctrl == nil {
-		return
+func (n *NoisePlayer) applyLocked() {
+	target := n.blend
+	if n.held {
+		target = 1
 	}
 
-	speaker.Lock()
-	n.ctrl.Paused = true
-	speaker.Unlock()
+	if target <= 0 {
+		if n.fader != nil {
+			fadeOut(n.fader, noiseFadeDuration, nil)
+			n.fader = nil
+		}
+		return
+	}
 
-	n.ctrl = nil
-	n.playing = false
+	if n.fader == nil {
+		if err := EnsureSpeaker(); err != nil {
+			return
+		}
+		fader := NewFader(&radioStaticStreamer{}, 0)
+		fader.FadeTo(target, mixerRate().N(noiseFadeDuration))
+		speakerPlay(fader)
+		n.fader = fader
+		return
+	}
+	fadeTo(n.fader, target, noiseFadeDuration)
 }
 
 // radioStaticStreamer generates infinite pink noise (radio static).
@@ -0,0 +1,196 @@
+package player
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+	"github.com/gopxl/beep/v2"
+)
+
+const (
+	// DefaultSampleRate is the mixer rate used when none is configured.
+	DefaultSampleRate = 44100
+	// DefaultBuffer is the device buffer used when none is configured.
+	DefaultBuffer = 100 * time.Millisecond
+
+	speakerChannels   = 2
+	speakerFrameBytes = speakerChannels * 4 // float32 per channel
+)
+
+// ErrRestartRequired is returned by ConfigureSpeaker when the audio device is
+// already open at a different sample rate. The driver cannot be reopened
+// in-process, so the new rate takes effect on the next launch.
+var ErrRestartRequired = errors.New("sample rate change takes effect after restart")
+
+// SpeakerOptions controls how the pure Go backend opens the audio device.
+type SpeakerOptions struct {
+	SampleRate int           // Hz; zero uses DefaultSampleRate
+	Buffer     time.Duration // device latency; zero uses DefaultBuffer
+}
+
+func (o SpeakerOptions) withDefaults() SpeakerOptions {
+	if o.SampleRate <= 0 {
+		o.SampleRate = DefaultSampleRate
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = DefaultBuffer
+	}
+	return o
+}
+
+var (
+	// speakerMu guards the mixer; it is held while the device pulls samples
+	// and while any playing streamer is modified.
+	speakerMu sync.Mutex
+	mixer     beep.Mixer
+
+	// deviceMu guards the device state below.
+	deviceMu      sync.Mutex
+	deviceCtx     *oto.Context
+	devicePlayer  *oto.Player
+	deviceOptions SpeakerOptions // options the device is open with
+	wantOptions   SpeakerOptions // options requested by ConfigureSpeaker
+
+	// rate is the sample rate streams are resampled to before mixing.
+	rate atomic.Int64
+)
+
+func init() {
+	rate.Store(DefaultSampleRate)
+}
+
+// mixerRate returns the sample rate every stream is resampled to.
+func mixerRate() beep.SampleRate {
+	return beep.SampleRate(rate.Load())
+}
+
+// ConfigureSpeaker sets the sample rate and buffer for the pure Go backend.
+// Before the device opens the options are simply remembered; afterwards a
+// new buffer size reopens the output stream in place, while a new sample
+// rate returns ErrRestartRequired.
+func ConfigureSpeaker(opts SpeakerOptions) error {
+	opts = opts.withDefaults()
+
+	deviceMu.Lock()
+	defer deviceMu.Unlock()
+
+	wantOptions = opts
+	if deviceCtx == nil {
+		rate.Store(int64(opts.SampleRate))
+		return nil
+	}
+	if opts == deviceOptions {
+		return nil
+	}
+
+	var err error
+	if opts.SampleRate != deviceOptions.SampleRate {
+		err = ErrRestartRequired
+		opts.SampleRate = deviceOptions.SampleRate
+	}
+	if opts.Buffer != deviceOptions.Buffer {
+		if devicePlayer != nil {
+			_ = devicePlayer.Close()
+			devicePlayer = nil
+		}
+		if openErr := openPlayerLocked(opts); openErr != nil {
+			return openErr
+		}
+	}
+	return err
+}
+
+// OpenSampleRate returns the rate the audio device is open at, or zero
+// before the built-in player first opens it.
+func OpenSampleRate() int {
+	deviceMu.Lock()
+	defer deviceMu.Unlock()
+	if deviceCtx == nil {
+		return 0
+	}
+	return deviceOptions.SampleRate
+}
+
+// EnsureSpeaker opens the audio device with the configured options if it
+// is not open yet.
+func EnsureSpeaker() error {
+	deviceMu.Lock()
+	defer deviceMu.Unlock()
+
+	if devicePlayer != nil {
+		return nil
+	}
+
+	opts := wantOptions.withDefaults()
+	if deviceCtx == nil {
+		ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   opts.SampleRate,
+			ChannelCount: speakerChannels,
+			Format:       oto.FormatFloat32LE,
+			BufferSize:   opts.Buffer / 2,
+		})
+		if err != nil {
+			return fmt.Errorf("open audio device: %w", err)
+		}
+		<-ready
+		deviceCtx = ctx
+		rate.Store(int64(opts.SampleRate))
+	} else {
+		opts.SampleRate = deviceOptions.SampleRate
+	}
+	return openPlayerLocked(opts)
+}
+
+// openPlayerLocked starts pulling from the mixer with the given buffer,
+// splitting it between the driver and the output stream like beep does.
+func openPlayerLocked(opts SpeakerOptions) error {
+	player := deviceCtx.NewPlayer(&mixerReader{})
+	frames := beep.SampleRate(opts.SampleRate).N(opts.Buffer / 2)
+	player.SetBufferSize(frames * speakerFrameBytes)
+	player.Play()
+	if err := player.Err(); err != nil {
+		_ = player.Close()
+		return fmt.Errorf("start audio output: %w", err)
+	}
+	devicePlayer = player
+	deviceOptions = opts
+	return nil
+}
+
+// speakerPlay adds streamers to the mixer.
+func speakerPlay(s ...beep.Streamer) {
+	speakerMu.Lock()
+	mixer.Add(s...)
+	speakerMu.Unlock()
+}
+
+// mixerReader encodes the mixed output as interleaved float32 frames.
+type mixerReader struct {
+	buf [][2]float64
+}
+
+func (r *mixerReader) Read(p []byte) (int, error) {
+	frames := len(p) / speakerFrameBytes
+	if cap(r.buf) < frames {
+		r.buf = make([][2]float64, frames)
+	}
+	buf := r.buf[:frames]
+
+	speakerMu.Lock()
+	mixer.Stream(buf)
+	speakerMu.Unlock()
+
+	for i := range buf {
+		for c := 0; c < speakerChannels; c++ {
+			v := math.Max(-1, math.Min(1, buf[i][c]))
+			binary.LittleEndian.PutUint32(p[i*speakerFrameBytes+c*4:], math.Float32bits(float32(v)))
+		}
+	}
+	return frames * speakerFrameBytes, nil
+}
@@ -0,0 +1,45 @@
+package player
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpeakerOptions_Defaults(t *testing.T) {
+	opts := SpeakerOptions{}.withDefaults()
+	if opts.SampleRate != DefaultSampleRate {
+		t.Errorf("SampleRate = %d, want %d", opts.SampleRate, DefaultSampleRate)
+	}
+	if opts.Buffer != DefaultBuffer {
+		t.Errorf("Buffer = %v, want %v", opts.Buffer, DefaultBuffer)
+	}
+
+	opts = SpeakerOptions{SampleRate: 48000, Buffer: 300 * time.Millisecond}.withDefaults()
+	if opts.SampleRate != 48000 || opts.Buffer != 300*time.Millisecond {
+		t.Errorf("withDefaults() overrode explicit options: %+v", opts)
+	}
+}
+
+func TestConfigure_BeforeDeviceOpens(t *testing.T) {
+	deviceMu.Lock()
+	opened := deviceCtx != nil
+	deviceMu.Unlock()
+	if opened {
+		t.Skip("audio device already open in this process")
+	}
+	defer func() { _ = Configure(Options{}) }()
+
+	err := Configure(Options{
+		Speaker: SpeakerOptions{SampleRate: 48000, Buffer: 200 * time.Millisecond},
+		Device:  "pulse/usb-dac",
+	})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if got := mixerRate(); got != 48000 {
+		t.Errorf("mixerRate() = %d, want 48000", got)
+	}
+	if got := OutputDevice(); got != "pulse/usb-dac" {
+		t.Errorf("OutputDevice() = %q, want %q", got, "pulse/usb-dac")
+	}
+}
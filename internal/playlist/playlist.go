@@ -0,0 +1,370 @@
+// Package playlist reads and writes station lists in formats other
+// players understand: M3U, PLS, JSON, CSV and plain Radio Browser UUID
+// lists.
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format names a playlist format.
+type Format string
+
+const (
+	M3U   Format = "m3u"
+	PLS   Format = "pls"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	UUIDs Format = "uuids" // one Radio Browser station UUID per line; read only
+)
+
+// Formats lists the formats Write supports.
+var Formats = []Format{M3U, PLS, JSON, CSV}
+
+// Entry is one station in a playlist. Files from other players usually
+// carry only a name and a stream URL; UUIDs come from Radio Browser.
+type Entry struct {
+	UUID    string `json:"uuid,omitempty"`
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	Country string `json:"country,omitempty"`
+	Tags    string `json:"tags,omitempty"`
+}
+
+// ParseFormat accepts a format name such as "m3u" or "M3U8".
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), ".")) {
+	case "m3u", "m3u8":
+		return M3U, nil
+	case "pls":
+		return PLS, nil
+	case "json":
+		return JSON, nil
+	case "csv":
+		return CSV, nil
+	case "uuids", "txt":
+		return UUIDs, nil
+	}
+	return "", fmt.Errorf("unknown playlist format %q (use m3u, pls, json, csv or uuids)", name)
+}
+
+// FormatOf guesses the format of a file from its name, and failing that
+// from its content.
+func FormatOf(path string, data []byte) Format {
+	if format, err := ParseFormat(filepath.Ext(path)); err == nil && path != "" {
+		return format
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[{")), bytes.HasPrefix(trimmed, []byte(`["`)):
+		return JSON
+	case bytes.HasPrefix(bytes.ToLower(trimmed), []byte("[playlist]")):
+		return PLS
+	case bytes.HasPrefix(trimmed, []byte("#EXTM3U")):
+		return M3U
+	}
+	lines := strings.Fields(string(trimmed))
+	if len(lines) > 0 && IsUUID(lines[0]) {
+		return UUIDs
+	}
+	return M3U
+}
+
+// Write writes entries in a format.
+func Write(w io.Writer, format Format, entries []Entry) error {
+	switch format {
+	case M3U:
+		return writeM3U(w, entries)
+	case PLS:
+		return writePLS(w, entries)
+	case JSON:
+		return writeJSON(w, entries)
+	case CSV:
+		return writeCSV(w, entries)
+	}
+	return fmt.Errorf("cannot write %s playlists", format)
+}
+
+// Read parses entries in a format. Entries without a UUID or URL are
+// dropped.
+func Read(r io.Reader, format Format) ([]Entry, error) {
+	var (
+		entries []Entry
+		err     error
+	)
+	switch format {
+	case M3U:
+		entries, err = readM3U(r)
+	case PLS:
+		entries, err = readPLS(r)
+	case JSON:
+		entries, err = readJSON(r)
+	case CSV:
+		entries, err = readCSV(r)
+	case UUIDs:
+		entries, err = readUUIDs(r)
+	default:
+		return nil, fmt.Errorf("cannot read %s playlists", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		entry.UUID = strings.ToLower(strings.TrimSpace(entry.UUID))
+		entry.URL = strings.TrimSpace(entry.URL)
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.UUID != "" || entry.URL != "" {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether s looks like a Radio Browser station UUID.
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// M3U keeps the UUID as an #EXTINF attribute, which other players ignore.
+var extinfUUID = regexp.MustCompile(`radiobrowser-uuid="([^"]*)"`)
+
+func writeM3U(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	for _, entry := range entries {
+		attrs := ""
+		if entry.UUID != "" {
+			attrs = fmt.Sprintf(" radiobrowser-uuid=%q", entry.UUID)
+		}
+		fmt.Fprintf(bw, "#EXTINF:-1%s,%s\n%s\n", attrs, oneLine(entry.Name), entry.URL)
+	}
+	return bw.Flush()
+}
+
+func readM3U(r io.Reader) ([]Entry, error) {
+	var (
+		entries []Entry
+		pending Entry
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			pending = Entry{}
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			if match := extinfUUID.FindStringSubmatch(info); match != nil {
+				pending.UUID = match[1]
+			}
+			// The title follows the first comma outside quotes.
+			if i := titleComma(info); i >= 0 {
+				pending.Name = info[i+1:]
+			}
+		case strings.HasPrefix(line, "#"):
+		case IsUUID(line):
+			entries = append(entries, Entry{UUID: line})
+			pending = Entry{}
+		default:
+			pending.URL = line
+			entries = append(entries, pending)
+			pending = Entry{}
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func titleComma(info string) int {
+	quoted := false
+	for i, r := range info {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+func writePLS(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "[playlist]")
+	for i, entry := range entries {
+		fmt.Fprintf(bw, "File%d=%s\nTitle%d=%s\nLength%d=-1\n", i+1, entry.URL, i+1, oneLine(entry.Name), i+1)
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\nVersion=2\n", len(entries))
+	return bw.Flush()
+}
+
+func readPLS(r io.Reader) ([]Entry, error) {
+	byIndex := map[int]*Entry{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		lower := strings.ToLower(key)
+		var field string
+		for _, name := range []string{"file", "title"} {
+			if strings.HasPrefix(lower, name) {
+				field = name
+			}
+		}
+		index, err := strconv.Atoi(lower[len(field):])
+		if field == "" || err != nil {
+			continue
+		}
+		if byIndex[index] == nil {
+			byIndex[index] = &Entry{}
+		}
+		if field == "file" {
+			byIndex[index].URL = value
+		} else {
+			byIndex[index].Name = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	entries := make([]Entry, 0, len(indexes))
+	for _, index := range indexes {
+		entries = append(entries, *byIndex[index])
+	}
+	return entries, nil
+}
+
+// jsonFile is the JSON export. Reading also takes valvefm's own
+// favorites.json, a bare array of entries and an array of UUIDs.
+type jsonFile struct {
+	Stations []Entry `json:"stations"`
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(jsonFile{Stations: entries})
+}
+
+func readJSON(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var file jsonFile
+	if err := json.Unmarshal(data, &file); err == nil {
+		return file.Stations, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		return entries, nil
+	}
+	var uuids []string
+	if err := json.Unmarshal(data, &uuids); err != nil {
+		return nil, errors.New("expected {\"stations\": [...]}, an array of stations or an array of UUIDs")
+	}
+	for _, uuid := range uuids {
+		entries = append(entries, Entry{UUID: uuid})
+	}
+	return entries, nil
+}
+
+var csvHeader = []string{"uuid", "name", "url", "country", "tags"}
+
+func writeCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := cw.Write([]string{entry.UUID, entry.Name, entry.URL, entry.Country, entry.Tags}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV takes its columns from the header row, so other spreadsheets
+// work as long as they name uuid or url.
+func readCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasUUID := columns["uuid"]
+	_, hasURL := columns["url"]
+	if !hasUUID && !hasURL {
+		return nil, errors.New("csv header needs a uuid or url column")
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	entries := make([]Entry, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		entries = append(entries, Entry{
+			UUID:    cell(row, "uuid"),
+			Name:    cell(row, "name"),
+			URL:     cell(row, "url"),
+			Country: cell(row, "country"),
+			Tags:    cell(row, "tags"),
+		})
+	}
+	return entries, nil
+}
+
+func readUUIDs(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !IsUUID(line) {
+			return nil, fmt.Errorf("%q is not a station UUID", line)
+		}
+		entries = append(entries, Entry{UUID: line})
+	}
+	return entries, scanner.Err()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
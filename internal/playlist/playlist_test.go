@@ -0,0 +1,119 @@
+package playlist
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var sample = []Entry{
+	{UUID: "96062a7b-0601-11e8-ae97-52543be04c81", Name: "Jazz, Smooth FM", URL: "http://example.com/jazz", Country: "France", Tags: "jazz"},
+	{Name: "Custom Stream", URL: "http://example.com/custom.mp3"},
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, sample); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := FormatOf("", buf.Bytes()); got != format && format != CSV {
+				t.Errorf("FormatOf() = %s, want %s", got, format)
+			}
+			entries, err := Read(&buf, format)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			want := sample
+			switch format {
+			case M3U:
+				// M3U keeps the UUID, name and URL.
+				want = []Entry{{UUID: sample[0].UUID, Name: sample[0].Name, URL: sample[0].URL}, {Name: sample[1].Name, URL: sample[1].URL}}
+			case PLS:
+				want = []Entry{{Name: sample[0].Name, URL: sample[0].URL}, {Name: sample[1].Name, URL: sample[1].URL}}
+			}
+			if !reflect.DeepEqual(entries, want) {
+				t.Errorf("Read() = %+v, want %+v", entries, want)
+			}
+		})
+	}
+}
+
+func TestReadM3U_OtherPlayers(t *testing.T) {
+	input := "\ufeff#EXTM3U\n#EXTINF:-1 tvg-logo=\"a,b.png\",Radio One\nhttp://one.example/stream\n\nhttp://bare.example/stream\n# comment\n96062A7B-0601-11E8-AE97-52543BE04C81\n"
+	entries, err := Read(strings.NewReader(input), M3U)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Name: "Radio One", URL: "http://one.example/stream"},
+		{URL: "http://bare.example/stream"},
+		{UUID: "96062a7b-0601-11e8-ae97-52543be04c81"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Read() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadPLS_OrdersByIndex(t *testing.T) {
+	input := "[playlist]\nFile2=http://two\nTitle2=Two\nfile1=http://one\nNumberOfEntries=2\n"
+	entries, err := Read(strings.NewReader(input), PLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{{URL: "http://one"}, {Name: "Two", URL: "http://two"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Read() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadJSON_Shapes(t *testing.T) {
+	for name, input := range map[string]string{
+		"favorites": `{"version": 3, "stations": [{"uuid": "u1", "name": "One"}]}`,
+		"array":     `[{"uuid": "u1", "name": "One"}]`,
+	} {
+		entries, err := Read(strings.NewReader(input), JSON)
+		if err != nil || len(entries) != 1 || entries[0].UUID != "u1" || entries[0].Name != "One" {
+			t.Errorf("%s: Read() = %+v, %v", name, entries, err)
+		}
+	}
+	entries, err := Read(strings.NewReader(`["u1", "u2"]`), JSON)
+	if err != nil || len(entries) != 2 || entries[1].UUID != "u2" {
+		t.Errorf("uuid array: Read() = %+v, %v", entries, err)
+	}
+	if _, err := Read(strings.NewReader(`{"stations": 5}`), JSON); err == nil {
+		t.Error("Read() accepted malformed JSON")
+	}
+}
+
+func TestReadCSV_HeaderColumns(t *testing.T) {
+	entries, err := Read(strings.NewReader("Name,URL\nOne,http://one\n"), CSV)
+	if err != nil || len(entries) != 1 || entries[0].Name != "One" || entries[0].URL != "http://one" {
+		t.Errorf("Read() = %+v, %v", entries, err)
+	}
+	if _, err := Read(strings.NewReader("name,genre\nOne,pop\n"), CSV); err == nil {
+		t.Error("Read() accepted a CSV without uuid or url")
+	}
+}
+
+func TestReadUUIDs(t *testing.T) {
+	input := "# team list\n96062a7b-0601-11e8-ae97-52543be04c81\n\n"
+	entries, err := Read(strings.NewReader(input), FormatOf("stations.txt", []byte(input)))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Read() = %+v, %v", entries, err)
+	}
+	if _, err := Read(strings.NewReader("not-a-uuid\n"), UUIDs); err == nil {
+		t.Error("Read() accepted a line that is not a UUID")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("M3U8"); err != nil || f != M3U {
+		t.Errorf("ParseFormat(M3U8) = %s, %v", f, err)
+	}
+	if _, err := ParseFormat("xspf"); err == nil {
+		t.Error("ParseFormat() accepted xspf")
+	}
+}
@@ -32,6 +32,12 @@ type serverInfo struct {
 
 // NewClient creates a Radio Browser API client.
 func NewClient(userAgent string) (*Client, error) {
+	return NewClientAt(userAgent, "")
+}
+
+// NewClientAt creates a client for the server at mirror, or for a random
+// Radio Browser server when mirror is empty.
+func NewClientAt(userAgent string, mirror string) (*Client, error) {
 	if strings.TrimSpace(userAgent) == "" {
 		return nil, errors.New("user agent is required")
 	}
@@ -41,6 +47,10 @@ func NewClient(userAgent string) (*Client, error) {
 		userAgent: userAgent,
 		http:      &http.Client{Timeout: requestTimeout},
 	}
+	if mirror != "" {
+		client.baseURL = strings.TrimRight(mirror, "/")
+		return client, nil
+	}
 
 	baseURL, err := client.pickRandomServer()
 	if err == nil && baseURL != "" {
@@ -125,6 +135,61 @@ func (c *Client) Countries(ctx context.Context) ([]Country, error) {
 	return countries, nil
 }
 
+// StationByUUID fetches a single station by its UUID.
+func (c *Client) StationByUUID(ctx context.Context, uuid string) (Station, error) {
+	uuid = strings.TrimSpace(uuid)
+	if uuid == "" {
+		return Station{}, errors.New("station uuid is required")
+	}
+
+	endpoint := fmt.Sprintf("/json/stations/byuuid/%s", url.PathEscape(uuid))
+	reqURL := c.baseURL + endpoint
+
+	var stations []Station
+	if err := c.doJSON(ctx, reqURL, &stations); err != nil {
+		return Station{}, err
+	}
+	if len(stations) == 0 {
+		return Station{}, fmt.Errorf("station %s not found", uuid)
+	}
+	return stations[0], nil
+}
+
+// uuidBatch bounds how many UUIDs go into one byuuid request.
+const uuidBatch = 100
+
+// StationsByUUIDs fetches several stations by UUID. Unknown UUIDs are left
+// out of the result.
+func (c *Client) StationsByUUIDs(ctx context.Context, uuids []string) ([]Station, error) {
+	var stations []Station
+	for start := 0; start < len(uuids); start += uuidBatch {
+		batch := uuids[start:min(start+uuidBatch, len(uuids))]
+		query := url.Values{}
+		query.Set("uuids", strings.Join(batch, ","))
+		var found []Station
+		if err := c.doJSON(ctx, c.baseURL+"/json/stations/byuuid?"+query.Encode(), &found); err != nil {
+			return nil, err
+		}
+		stations = append(stations, found...)
+	}
+	return stations, nil
+}
+
+// StationsByURL finds the stations streaming from a URL.
+func (c *Client) StationsByURL(ctx context.Context, streamURL string) ([]Station, error) {
+	streamURL = strings.TrimSpace(streamURL)
+	if streamURL == "" {
+		return nil, errors.New("stream url is required")
+	}
+	query := url.Values{}
+	query.Set("url", streamURL)
+	var stations []Station
+	if err := c.doJSON(ctx, c.baseURL+"/json/stations/byurl?"+query.Encode(), &stations); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
 // ResolveStationURL calls /json/url/{stationuuid} and returns a resolved stream URL.
 func (c *Client) ResolveStationURL(ctx context.Context, uuid string) (string, error) {
 	uuid = strings.TrimSpace(uuid)
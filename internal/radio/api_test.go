@@ -3,6 +3,7 @@ package radio
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -401,6 +402,54 @@ func TestClient_ResolveStationURL_EmptyUUID(t *testing.T) {
 	}
 }
 
+func TestClient_StationByUUID(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Station{{UUID: "test-uuid", Name: "Test FM"}})
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:   server.URL,
+		userAgent: "TestApp/1.0",
+		http:      &http.Client{Timeout: 5 * time.Second},
+	}
+
+	station, err := client.StationByUUID(context.Background(), "test-uuid")
+	if err != nil {
+		t.Fatalf("StationByUUID() error = %v", err)
+	}
+	if gotPath != "/json/stations/byuuid/test-uuid" {
+		t.Errorf("path = %q, want /json/stations/byuuid/test-uuid", gotPath)
+	}
+	if station.Name != "Test FM" {
+		t.Errorf("Name = %q, want %q", station.Name, "Test FM")
+	}
+}
+
+func TestClient_StationByUUID_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:   server.URL,
+		userAgent: "TestApp/1.0",
+		http:      &http.Client{Timeout: 5 * time.Second},
+	}
+
+	if _, err := client.StationByUUID(context.Background(), "missing"); err == nil {
+		t.Error("StationByUUID() should return error when no station matches")
+	}
+	if _, err := client.StationByUUID(context.Background(), " "); err == nil {
+		t.Error("StationByUUID() should return error for empty UUID")
+	}
+}
+
 func TestClient_HTTPError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -518,3 +567,54 @@ func TestResolvedURL(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_StationsByUUIDs(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/stations/byuuid" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		uuids := strings.Split(r.URL.Query().Get("uuids"), ",")
+		requests = append(requests, r.URL.Query().Get("uuids"))
+		stations := []Station{}
+		for _, uuid := range uuids {
+			if uuid != "missing" {
+				stations = append(stations, Station{UUID: uuid, Name: "Station " + uuid})
+			}
+		}
+		json.NewEncoder(w).Encode(stations)
+	}))
+	defer server.Close()
+
+	client := &Client{baseURL: server.URL, userAgent: "TestApp/1.0", http: &http.Client{Timeout: 5 * time.Second}}
+	uuids := []string{"missing"}
+	for i := range uuidBatch {
+		uuids = append(uuids, fmt.Sprint(i))
+	}
+	stations, err := client.StationsByUUIDs(context.Background(), uuids)
+	if err != nil {
+		t.Fatalf("StationsByUUIDs() error = %v", err)
+	}
+	if len(stations) != uuidBatch || len(requests) != 2 {
+		t.Errorf("got %d stations in %d requests, want %d in 2", len(stations), len(requests), uuidBatch)
+	}
+}
+
+func TestClient_StationsByURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/stations/byurl" || r.URL.Query().Get("url") != "http://example.com/stream?x=1" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		json.NewEncoder(w).Encode([]Station{{UUID: "u1", Name: "Found"}})
+	}))
+	defer server.Close()
+
+	client := &Client{baseURL: server.URL, userAgent: "TestApp/1.0", http: &http.Client{Timeout: 5 * time.Second}}
+	stations, err := client.StationsByURL(context.Background(), "http://example.com/stream?x=1")
+	if err != nil || len(stations) != 1 || stations[0].UUID != "u1" {
+		t.Errorf("StationsByURL() = %+v, %v", stations, err)
+	}
+	if _, err := client.StationsByURL(context.Background(), " "); err == nil {
+		t.Error("StationsByURL() should require a URL")
+	}
+}
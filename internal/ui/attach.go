@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/ipc"
+)
+
+type remoteReplyMsg struct {
+	command string
+	err     error
+}
+
+type remoteStatusMsg struct {
+	status ipc.Status
+	err    error
+}
+
+// remoteCmd sends a command to the daemon this TUI is attached to.
+func (m Model) remoteCmd(command string, args any) tea.Cmd {
+	return func() tea.Msg {
+		err := ipc.Call(command, args, nil)
+		return remoteReplyMsg{command: command, err: err}
+	}
+}
+
+func (m Model) remoteStatusCmd() tea.Cmd {
+	return func() tea.Msg {
+		var status ipc.Status
+		err := ipc.Call(ipc.CmdStatus, nil, &status)
+		return remoteStatusMsg{status: status, err: err}
+	}
+}
+
+// reloadDaemonCmd asks the daemon to re-read favorites and alarms after an
+// attached TUI changed them on disk.
+func (m Model) reloadDaemonCmd() tea.Cmd {
+	if m.mode != ModeAttached {
+		return nil
+	}
+	return m.remoteCmd(ipc.CmdReload, nil)
+}
+
+func (m Model) handleRemoteReply(msg remoteReplyMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.errMsg = fmt.Sprintf("Daemon rejected %s: %v", msg.command, msg.err)
+		return m, nil
+	}
+	return m, m.remoteStatusCmd()
+}
+
+// handleRemoteStatus mirrors the daemon's playback state in the TUI.
+func (m Model) handleRemoteStatus(msg remoteStatusMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		if !m.daemonLost {
+			m.daemonLost = true
+			m.errMsg = "Lost connection to the daemon: " + msg.err.Error()
+		}
+		m.playing = false
+		return m, nil
+	}
+	if m.daemonLost {
+		m.daemonLost = false
+		m.errMsg = ""
+	}
+
+	status := msg.status
+	m.playing = status.Playing
+	m.nowPlaying = status.Title
+	if status.UUID != "" {
+		m.playingUUID = status.UUID
+		if m.lastStation.UUID != status.UUID {
+			station := m.lookupStation(status.UUID)
+			station.Name = fallback(station.Name, status.Station)
+			m.lastStation = station
+		}
+	}
+	m.sleepAt = time.Time{}
+	if status.SleepRemaining > 0 {
+		m.sleepAt = time.Now().Add(time.Duration(status.SleepRemaining) * time.Second)
+	}
+	return m, nil
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/player"
+)
+
+// Choices offered by the audio settings overlay. Bluetooth headsets and USB
+// DACs usually want 48 kHz and a larger buffer than the 100 ms default.
+var (
+	sampleRateChoices = []int{44100, 48000}
+	bufferChoices     = []int{50, 100, 200, 400, 800}
+)
+
+const (
+	audioFieldSampleRate = iota
+	audioFieldBuffer
+	audioFieldDevice
+	audioFieldCount
+)
+
+type audioSavedMsg struct {
+	audio config.AudioConfig
+	err   error
+}
+
+type audioDevicesMsg struct {
+	devices []player.Device
+	err     error
+}
+
+// ApplyAudioConfig configures the audio backends from saved settings.
+func ApplyAudioConfig(audio config.AudioConfig) error {
+	return player.Configure(player.Options{
+		Speaker: player.SpeakerOptions{
+			SampleRate: audio.SampleRate,
+			Buffer:     time.Duration(audio.BufferMs) * time.Millisecond,
+		},
+		Device: audio.Device,
+	})
+}
+
+func (m Model) updateAudioSettings(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "a", "A", "esc":
+		m.showAudio = false
+	case "up", "k":
+		if m.audioField > 0 {
+			m.audioField--
+		}
+	case "down", "j":
+		if m.audioField < audioFieldCount-1 {
+			m.audioField++
+		}
+	case "left", "h":
+		m.cycleAudioField(-1)
+	case "right", "l":
+		m.cycleAudioField(1)
+	case "enter":
+		m.showAudio = false
+		return m, m.saveAudioCmd()
+	}
+	return m, nil
+}
+
+func (m *Model) cycleAudioField(delta int) {
+	switch m.audioField {
+	case audioFieldSampleRate:
+		m.audioDraft.SampleRate = cycleChoice(sampleRateChoices, audioSampleRate(m.audioDraft), delta)
+	case audioFieldBuffer:
+		m.audioDraft.BufferMs = cycleChoice(bufferChoices, audioBufferMs(m.audioDraft), delta)
+	case audioFieldDevice:
+		m.audioDraft.Device = cycleDevice(m.deviceChoices(), m.audioDraft.Device, delta)
+	}
+}
+
+// loadAudioDevicesCmd asks the external player for its outputs, which
+// takes a moment as it runs the player.
+func (m Model) loadAudioDevicesCmd() tea.Cmd {
+	lister, ok := m.player.(player.DeviceLister)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		devices, err := lister.Devices()
+		return audioDevicesMsg{devices: devices, err: err}
+	}
+}
+
+func (m Model) handleAudioDevices(msg audioDevicesMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.errMsg = "Failed to list audio devices: " + msg.err.Error()
+		return m, nil
+	}
+	m.audioDevices = msg.devices
+	return m, nil
+}
+
+// deviceChoices are the devices offered: the system default, then those
+// the player lists, then the configured one if it is not among them.
+func (m Model) deviceChoices() []string {
+	choices := []string{""}
+	for _, device := range m.audioDevices {
+		choices = append(choices, device.Name)
+	}
+	if !slices.Contains(choices, m.audio.Device) {
+		choices = append(choices, m.audio.Device)
+	}
+	return choices
+}
+
+// cycleDevice steps from current to the neighbouring device, wrapping
+// around.
+func cycleDevice(choices []string, current string, delta int) string {
+	idx := max(slices.Index(choices, current), 0)
+	return choices[(idx+delta+len(choices))%len(choices)]
+}
+
+// deviceLabel names a device as the player describes it.
+func (m Model) deviceLabel(name string) string {
+	if name == "" {
+		return "system default"
+	}
+	for _, device := range m.audioDevices {
+		if device.Name == name && device.Description != "" {
+			return device.Description
+		}
+	}
+	return name
+}
+
+// sampleRatePending reports whether the drafted sample rate differs from
+// the one the audio device is open at, which only a restart changes.
+func (m Model) sampleRatePending() bool {
+	open := player.OpenSampleRate()
+	return open != 0 && audioSampleRate(m.audioDraft) != open
+}
+
+// saveAudioCmd saves the drafted settings and applies them. An attached TUI
+// plays nothing; the daemon applies what it finds in the saved config.
+func (m Model) saveAudioCmd() tea.Cmd {
+	audio, attached := m.audioDraft, m.mode == ModeAttached
+	return func() tea.Msg {
+		if attached {
+			return audioSavedMsg{audio: audio, err: config.SaveAudio(audio)}
+		}
+		applyErr := ApplyAudioConfig(audio)
+		if err := config.SaveAudio(audio); err != nil {
+			return audioSavedMsg{audio: audio, err: err}
+		}
+		return audioSavedMsg{audio: audio, err: applyErr}
+	}
+}
+
+func (m Model) handleAudioSaved(msg audioSavedMsg) (tea.Model, tea.Cmd) {
+	m.audio = msg.audio
+	switch {
+	case errors.Is(msg.err, player.ErrRestartRequired):
+		m.errMsg = fmt.Sprintf("Audio settings saved; restart Valve FM to play at %d Hz", audioSampleRate(msg.audio))
+	case msg.err != nil:
+		m.errMsg = "Failed to apply audio settings: " + msg.err.Error()
+	case m.mode == ModeAttached:
+		m.errMsg = "Audio settings saved; the daemon picks them up"
+	}
+	return m, nil
+}
+
+// cycleChoice steps from current to the neighbouring choice, wrapping around.
+// Values that are not among the choices start from the first one.
+func cycleChoice(choices []int, current int, delta int) int {
+	idx := -1
+	for i, choice := range choices {
+		if choice == current {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return choices[0]
+	}
+	idx = (idx + delta + len(choices)) % len(choices)
+	return choices[idx]
+}
+
+func audioSampleRate(audio config.AudioConfig) int {
+	if audio.SampleRate > 0 {
+		return audio.SampleRate
+	}
+	return player.DefaultSampleRate
+}
+
+func audioBufferMs(audio config.AudioConfig) int {
+	if audio.BufferMs > 0 {
+		return audio.BufferMs
+	}
+	return int(player.DefaultBuffer / time.Millisecond)
+}
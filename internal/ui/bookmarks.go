@@ -0,0 +1,324 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"radio-tui/internal/bookmarks"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/radio"
+)
+
+// bookmarkRows is how many bookmarks the view shows at once.
+const bookmarkRows = 12
+
+// copyToClipboard puts text on the system clipboard; tests replace it.
+var copyToClipboard = clipboard.WriteAll
+
+// bookmarkExportName is the file X offers to export to, in the home
+// directory.
+const bookmarkExportName = "valvefm-bookmarks.csv"
+
+func newBookmarkSearch() textinput.Model {
+	search := textinput.New()
+	search.Prompt = "/ "
+	search.Placeholder = "Search bookmarks"
+	search.Width = 30
+	return search
+}
+
+func newBookmarkExport() textinput.Model {
+	export := textinput.New()
+	export.Prompt = "Export to: "
+	export.Placeholder = "~/" + bookmarkExportName
+	export.Width = 40
+	return export
+}
+
+type bookmarkMsg struct {
+	bookmark ipc.Bookmark
+	err      error
+}
+
+// bookmarkSong saves the song playing now. An attached TUI asks the
+// daemon, which knows the stream.
+func (m *Model) bookmarkSong() tea.Cmd {
+	if m.mode == ModeAttached {
+		return func() tea.Msg {
+			var bookmark ipc.Bookmark
+			err := ipc.Call(ipc.CmdBookmark, nil, &bookmark)
+			return bookmarkMsg{bookmark: bookmark, err: err}
+		}
+	}
+	reply := m.ipcBookmark(time.Now())
+	if !reply.ok {
+		m.errMsg = reply.err
+		return nil
+	}
+	m.showBookmarked(reply.value.(ipc.Bookmark))
+	return nil
+}
+
+func (m Model) handleBookmark(msg bookmarkMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.errMsg = msg.err.Error()
+		return m, nil
+	}
+	m.showBookmarked(msg.bookmark)
+	return m, nil
+}
+
+func (m *Model) showBookmarked(bookmark ipc.Bookmark) {
+	if bookmark.Added {
+		m.errMsg = "Bookmarked: " + bookmark.Title
+	} else {
+		m.errMsg = "Already bookmarked: " + bookmark.Title
+	}
+}
+
+// ipcBookmark saves the title the playing station announces, with the
+// station and its stream.
+func (m *Model) ipcBookmark(now time.Time) ipcReply {
+	if m.bookmarks == nil {
+		return ipcError(ipc.ErrUnavailable, "bookmarks not available")
+	}
+	if !m.playing {
+		return ipcError(ipc.ErrUnavailable, "nothing is playing")
+	}
+	if m.nowPlaying == "" {
+		return ipcError(ipc.ErrUnavailable, bookmarks.ErrNoTitle.Error())
+	}
+	station := m.lastStation
+	bookmark := bookmarks.Bookmark{
+		Title:   m.nowPlaying,
+		Station: station.Name,
+		UUID:    station.UUID,
+		URL:     fallback(m.playingURL, station.URL),
+		Time:    now,
+	}
+	added, err := m.bookmarks.Add(bookmark)
+	if err != nil {
+		return ipcFailure(fmt.Errorf("saving the bookmark: %w", err))
+	}
+	return ipcReply{ok: true, data: bookmark.Title, value: ipc.Bookmark{
+		Title:   bookmark.Title,
+		Station: bookmark.Station,
+		UUID:    bookmark.UUID,
+		URL:     bookmark.URL,
+		Time:    bookmark.Time.Format(time.RFC3339),
+		Added:   added,
+	}}
+}
+
+// openBookmarks shows the saved songs, read afresh since the daemon or
+// another session may have added some.
+func (m Model) openBookmarks() (tea.Model, tea.Cmd) {
+	if m.bookmarks == nil {
+		m.errMsg = "Bookmarks not available"
+		return m, nil
+	}
+	if err := m.bookmarks.Reload(); err != nil {
+		m.errMsg = "Failed to read bookmarks: " + err.Error()
+		return m, nil
+	}
+	m.showBookmarks = true
+	m.bookmarkIdx = 0
+	m.bookmarkSearch.SetValue("")
+	m.bookmarkSearch.Blur()
+	m.bookmarkExport.Blur()
+	return m, nil
+}
+
+// shownBookmarks are the bookmarks matching the search, latest first.
+func (m Model) shownBookmarks() []bookmarks.Bookmark {
+	var shown []bookmarks.Bookmark
+	for _, bookmark := range m.bookmarks.List() {
+		if bookmark.Matches(m.bookmarkSearch.Value()) {
+			shown = append(shown, bookmark)
+		}
+	}
+	return shown
+}
+
+func (m Model) updateBookmarks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.bookmarkExport.Focused() {
+		return m.updateBookmarkExport(msg)
+	}
+	if m.bookmarkSearch.Focused() {
+		switch msg.String() {
+		case "esc":
+			m.bookmarkSearch.SetValue("")
+			m.bookmarkSearch.Blur()
+		case "enter", "up", "down":
+			m.bookmarkSearch.Blur()
+		default:
+			var cmd tea.Cmd
+			m.bookmarkSearch, cmd = m.bookmarkSearch.Update(msg)
+			m.bookmarkIdx = 0
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	shown := m.shownBookmarks()
+	var bookmark bookmarks.Bookmark
+	onBookmark := m.bookmarkIdx < len(shown)
+	if onBookmark {
+		bookmark = shown[m.bookmarkIdx]
+	}
+
+	switch msg.String() {
+	case "B", "esc":
+		m.showBookmarks = false
+	case "up", "k":
+		if m.bookmarkIdx > 0 {
+			m.bookmarkIdx--
+		}
+	case "down", "j":
+		if m.bookmarkIdx < len(shown)-1 {
+			m.bookmarkIdx++
+		}
+	case "/":
+		m.bookmarkSearch.Focus()
+		m.bookmarkSearch.CursorEnd()
+		return m, textinput.Blink
+	case "c", "C", "y":
+		if onBookmark {
+			if err := copyToClipboard(bookmark.Title); err != nil {
+				m.errMsg = "Failed to copy: " + err.Error()
+			} else {
+				m.errMsg = "Copied: " + bookmark.Title
+			}
+		}
+	case "d", "D", "delete":
+		if onBookmark {
+			if err := m.bookmarks.Remove(bookmark); err != nil {
+				m.errMsg = "Failed to delete bookmark: " + err.Error()
+			}
+			m.bookmarkIdx = max(0, min(m.bookmarkIdx, len(shown)-2))
+		}
+	case "x", "X":
+		if len(shown) == 0 {
+			m.errMsg = "No bookmarks to export"
+			break
+		}
+		m.bookmarkExport.SetValue("~/" + bookmarkExportName)
+		m.bookmarkExport.Focus()
+		m.bookmarkExport.CursorEnd()
+		m.exportReplace = ""
+		return m, textinput.Blink
+	case "enter":
+		if onBookmark && bookmark.UUID != "" {
+			m.showBookmarks = false
+			m.errMsg = ""
+			m.noise.Start()
+			return m, m.playStationCmd(radio.Station{UUID: bookmark.UUID, Name: bookmark.Station, URL: bookmark.URL})
+		}
+	}
+	return m, nil
+}
+
+// updateBookmarkExport asks for the file to export the bookmarks shown
+// to. An existing file is replaced only when Enter is pressed again.
+func (m Model) updateBookmarkExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.bookmarkExport.Blur()
+		m.exportReplace = ""
+	case "enter":
+		path, err := expandHome(strings.TrimSpace(m.bookmarkExport.Value()))
+		if err != nil {
+			m.errMsg = "Failed to export bookmarks: " + err.Error()
+			break
+		}
+		if path == "" {
+			m.errMsg = "Enter a file to export to"
+			break
+		}
+		shown := m.shownBookmarks()
+		err = bookmarks.Export(path, shown, path == m.exportReplace)
+		if errors.Is(err, bookmarks.ErrExists) {
+			m.exportReplace = path
+			m.errMsg = path + " exists; press Enter again to replace it"
+			break
+		}
+		m.exportReplace = ""
+		m.bookmarkExport.Blur()
+		if err != nil {
+			m.errMsg = "Failed to export bookmarks: " + err.Error()
+			break
+		}
+		m.errMsg = fmt.Sprintf("Exported %d bookmarks to %s", len(shown), path)
+	default:
+		var cmd tea.Cmd
+		m.bookmarkExport, cmd = m.bookmarkExport.Update(msg)
+		m.exportReplace = ""
+		return m, cmd
+	}
+	return m, nil
+}
+
+// expandHome resolves a leading ~ to the home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+func (m Model) renderBookmarks() string {
+	lines := []string{
+		m.styles.ListHeader.Render("Bookmarks"),
+		"",
+	}
+	if m.bookmarkSearch.Focused() || m.bookmarkSearch.Value() != "" {
+		lines = append(lines, m.bookmarkSearch.View(), "")
+	}
+	if m.bookmarkExport.Focused() {
+		lines = append(lines, m.bookmarkExport.View(), "")
+	}
+
+	shown := m.shownBookmarks()
+	switch {
+	case len(shown) == 0 && m.bookmarkSearch.Value() != "":
+		lines = append(lines, m.styles.Muted.Render("No bookmarks match"))
+	case len(shown) == 0:
+		lines = append(lines, m.styles.Muted.Render("No bookmarks yet; press b while a song plays"))
+	}
+	// Scroll so that the selected bookmark stays in view.
+	first := max(0, m.bookmarkIdx-bookmarkRows+1)
+	for i := first; i < len(shown) && i < first+bookmarkRows; i++ {
+		bookmark := shown[i]
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.bookmarkIdx {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		when := bookmark.Time.Local().Format("Jan 02 15:04")
+		title := padRight(truncateText(bookmark.Title, 36), 37)
+		lines = append(lines, style.Render(marker+title+when+"  "+truncateText(bookmark.Station, 20)))
+	}
+	if len(shown) > bookmarkRows {
+		lines = append(lines, m.styles.Muted.Render(fmt.Sprintf("%d of %d", m.bookmarkIdx+1, len(shown))))
+	}
+	lines = append(lines,
+		"",
+		m.styles.Muted.Render("Enter play station  C copy title  / search"),
+		m.styles.Muted.Render("X export  D delete  Esc close"),
+	)
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
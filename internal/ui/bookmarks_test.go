@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/bookmarks"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/radio"
+)
+
+func pressKey(t *testing.T, m Model, key string) Model {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	updated, _ := m.Update(msg)
+	return updated.(Model)
+}
+
+func TestIPCBookmark(t *testing.T) {
+	m := createTestModel()
+	m.bookmarks = bookmarks.OpenFile(filepath.Join(t.TempDir(), "bookmarks.json"))
+	now := time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)
+
+	if reply := m.ipcBookmark(now); reply.ok || reply.code != ipc.ErrUnavailable {
+		t.Errorf("BOOKMARK while stopped = %+v", reply)
+	}
+	m.playing = true
+	m.lastStation = radio.Station{UUID: "3", Name: "Jazz Station", URL: "http://jazz.example/listen.pls"}
+	m.playingURL = "http://jazz.example/stream"
+	if reply := m.ipcBookmark(now); reply.ok {
+		t.Errorf("BOOKMARK without a title = %+v", reply)
+	}
+
+	m.nowPlaying = "Artist - Song"
+	reply := m.ipcBookmark(now)
+	bookmark, _ := reply.value.(ipc.Bookmark)
+	if !reply.ok || !bookmark.Added || bookmark.Station != "Jazz Station" || bookmark.URL != "http://jazz.example/stream" || bookmark.Time != "2026-10-19T21:30:00Z" {
+		t.Errorf("BOOKMARK = %+v", reply)
+	}
+	if reply := m.ipcBookmark(now.Add(time.Minute)); reply.value.(ipc.Bookmark).Added {
+		t.Error("the same song was bookmarked twice")
+	}
+	if list := m.bookmarks.List(); len(list) != 1 || list[0].Title != "Artist - Song" {
+		t.Errorf("bookmarks = %+v", list)
+	}
+}
+
+func TestBookmarksView(t *testing.T) {
+	var copied string
+	defer func(copy func(string) error) { copyToClipboard = copy }(copyToClipboard)
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+
+	m := createTestModel()
+	m.bookmarkSearch = newBookmarkSearch()
+	m.bookmarks = bookmarks.OpenFile(filepath.Join(t.TempDir(), "bookmarks.json"))
+	at := time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)
+	for i, b := range []bookmarks.Bookmark{
+		{Title: "Miles - So What", Station: "Jazz Station", UUID: "3"},
+		{Title: "Band - Loud", Station: "Rock FM", UUID: "1"},
+	} {
+		b.Time = at.Add(time.Duration(i) * time.Minute)
+		if _, err := m.bookmarks.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := pressKey(t, *m, "B")
+	if !got.showBookmarks || len(got.shownBookmarks()) != 2 || got.shownBookmarks()[0].UUID != "1" {
+		t.Fatalf("B shows %+v, want both bookmarks, latest first", got.shownBookmarks())
+	}
+	for _, key := range []string{"/", "j", "a", "z", "z", "enter"} {
+		got = pressKey(t, got, key)
+	}
+	if shown := got.shownBookmarks(); len(shown) != 1 || shown[0].UUID != "3" {
+		t.Errorf("searching jazz shows %+v", shown)
+	}
+
+	got = pressKey(t, got, "c")
+	if copied != "Miles - So What" {
+		t.Errorf("copied %q", copied)
+	}
+	got = pressKey(t, got, "d")
+	if list := got.bookmarks.List(); len(list) != 1 || list[0].UUID != "1" {
+		t.Errorf("bookmarks after delete = %+v", list)
+	}
+	if got = pressKey(t, got, "esc"); got.showBookmarks {
+		t.Error("esc did not close the bookmarks")
+	}
+}
+
+func TestBookmarksExport(t *testing.T) {
+	m := createTestModel()
+	m.bookmarkSearch = newBookmarkSearch()
+	m.bookmarkExport = newBookmarkExport()
+	m.bookmarks = bookmarks.OpenFile(filepath.Join(t.TempDir(), "bookmarks.json"))
+	if _, err := m.bookmarks.Add(bookmarks.Bookmark{Title: "Miles - So What", Station: "Jazz Station", UUID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "songs.csv")
+	if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := pressKey(t, pressKey(t, *m, "B"), "X")
+	if !got.bookmarkExport.Focused() {
+		t.Fatal("X did not ask for a file to export to")
+	}
+	got.bookmarkExport.SetValue(path)
+	got = pressKey(t, got, "enter")
+	if data, _ := os.ReadFile(path); string(data) != "keep me" || !strings.Contains(got.errMsg, "exists") {
+		t.Fatalf("the export replaced an existing file without asking (%q)", got.errMsg)
+	}
+	got = pressKey(t, got, "enter")
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "Miles - So What") || got.bookmarkExport.Focused() {
+		t.Errorf("a second Enter did not replace the file: %q (%q)", data, got.errMsg)
+	}
+}
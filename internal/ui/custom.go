@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/radio"
+)
+
+// Fields of the custom station form, in tab order.
+const (
+	customFieldName = iota
+	customFieldURL
+	customFieldTags
+	customFieldFrequency
+	customFieldCount
+)
+
+func newCustomFields() []textinput.Model {
+	fields := make([]textinput.Model, customFieldCount)
+	for i, spec := range []struct {
+		prompt, placeholder string
+		limit               int
+	}{
+		customFieldName:      {"Name: ", "Office radio", 60},
+		customFieldURL:       {"URL: ", "https://stream.example.com/live.mp3", 500},
+		customFieldTags:      {"Tags: ", "news,internal", 120},
+		customFieldFrequency: {"MHz: ", "optional, e.g. 101.5", 6},
+	} {
+		field := textinput.New()
+		field.Prompt = spec.prompt
+		field.Placeholder = spec.placeholder
+		field.CharLimit = spec.limit
+		field.Width = 36
+		fields[i] = field
+	}
+	return fields
+}
+
+// openCustomDialog shows the custom station form, empty for a new station
+// or filled in to edit an existing one.
+func (m Model) openCustomDialog(edit *config.Favorite) (tea.Model, tea.Cmd) {
+	if m.favorites == nil {
+		m.errMsg = "Favorites not available"
+		return m, nil
+	}
+	m.showCustom = true
+	m.customUUID = ""
+	values := make([]string, customFieldCount)
+	if edit != nil {
+		m.customUUID = edit.UUID
+		values[customFieldName] = edit.Name
+		values[customFieldURL] = edit.URL
+		values[customFieldTags] = edit.Tags
+		if edit.Frequency > 0 {
+			values[customFieldFrequency] = strconv.FormatFloat(edit.Frequency, 'f', -1, 64)
+		}
+	}
+	for i := range m.customFields {
+		m.customFields[i].SetValue(values[i])
+	}
+	m.focusCustomField(customFieldName)
+	return m, textinput.Blink
+}
+
+// editCustomStation opens the form for the selected station, which must be
+// a custom one.
+func (m Model) editCustomStation() (tea.Model, tea.Cmd) {
+	station, ok := m.currentStation()
+	if !ok || !config.IsCustom(station.UUID) || m.favorites == nil {
+		m.errMsg = "Only custom stations can be edited; press C to add one"
+		return m, nil
+	}
+	fav, ok := m.favorites.Get(station.UUID)
+	if !ok {
+		m.errMsg = "Custom station not found"
+		return m, nil
+	}
+	return m.openCustomDialog(&fav)
+}
+
+func (m *Model) focusCustomField(field int) {
+	m.customField = (field + customFieldCount) % customFieldCount
+	for i := range m.customFields {
+		if i == m.customField {
+			m.customFields[i].Focus()
+			m.customFields[i].CursorEnd()
+		} else {
+			m.customFields[i].Blur()
+		}
+	}
+}
+
+func (m Model) updateCustomDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.closeCustomDialog()
+		return m, nil
+	case "tab", "down":
+		m.focusCustomField(m.customField + 1)
+		return m, nil
+	case "shift+tab", "up":
+		m.focusCustomField(m.customField - 1)
+		return m, nil
+	case "enter":
+		return m.saveCustomStation()
+	}
+
+	var cmd tea.Cmd
+	m.customFields[m.customField], cmd = m.customFields[m.customField].Update(msg)
+	return m, cmd
+}
+
+func (m *Model) closeCustomDialog() {
+	m.showCustom = false
+	for i := range m.customFields {
+		m.customFields[i].Blur()
+	}
+}
+
+// saveCustomStation adds or updates the station in the form. A new station
+// is shown among all favorites, the only list it appears in.
+func (m Model) saveCustomStation() (tea.Model, tea.Cmd) {
+	fav := config.Favorite{
+		UUID: m.customUUID,
+		Name: m.customFields[customFieldName].Value(),
+		URL:  m.customFields[customFieldURL].Value(),
+		Tags: m.customFields[customFieldTags].Value(),
+	}
+	if text := strings.TrimSpace(m.customFields[customFieldFrequency].Value()); text != "" {
+		freq, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(text), "mhz"), 64)
+		if err != nil {
+			m.errMsg = fmt.Sprintf("Frequency %q is not a number", text)
+			return m, nil
+		}
+		fav.Frequency = freq
+	}
+
+	var err error
+	if m.customUUID == "" {
+		_, err = m.favorites.AddCustom(fav)
+	} else {
+		err = m.favorites.UpdateCustom(fav)
+	}
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.closeCustomDialog()
+	m.errMsg = ""
+	if m.customUUID == "" && (m.stationSource != sourceFavorites || m.listGroup() != "") {
+		return m, tea.Batch(m.showFavoritesGroup(""), m.reloadDaemonCmd())
+	}
+	return m, tea.Batch(m.favoritesChanged(), m.reloadDaemonCmd())
+}
+
+// playCustomCmd plays a custom station straight from its stream URL,
+// without asking the directory.
+func (m Model) playCustomCmd(station radio.Station) tea.Cmd {
+	if station.URL == "" && m.favorites != nil {
+		if fav, ok := m.favorites.Get(station.UUID); ok {
+			station = favoritesToStations([]config.Favorite{fav})[0]
+		}
+	}
+	return func() tea.Msg {
+		if station.URL == "" {
+			return playMsg{err: fmt.Errorf("custom station %s has no stream URL", fallback(station.Name, station.UUID))}
+		}
+		return playMsg{station: station, url: station.URL}
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/radio"
+)
+
+func typeText(t *testing.T, m Model, text string) Model {
+	t.Helper()
+	updated, _ := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+	return updated.(Model)
+}
+
+func TestModel_CustomStationForm(t *testing.T) {
+	m := groupedModel(t)
+	m.customFields = newCustomFields()
+
+	updated, _ := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	got := updated.(Model)
+	if !got.showCustom {
+		t.Fatal("C did not open the custom station form")
+	}
+	got = typeText(t, got, "Office Radio")
+	for _, field := range []string{"http://intranet.example/live", "news", "99.9"} {
+		updated, _ = got.update(tea.KeyMsg{Type: tea.KeyTab})
+		got = typeText(t, updated.(Model), field)
+	}
+	updated, cmd := got.update(tea.KeyMsg{Type: tea.KeyEnter})
+	got = updated.(Model)
+	if got.showCustom || got.errMsg != "" {
+		t.Fatalf("form still open (error %q)", got.errMsg)
+	}
+
+	list := got.favorites.List()
+	custom := list[len(list)-1]
+	if !config.IsCustom(custom.UUID) || custom.Name != "Office Radio" || custom.URL != "http://intranet.example/live" || custom.Frequency != 99.9 {
+		t.Fatalf("saved %+v", custom)
+	}
+	if cmd == nil || got.stationSource != sourceFavorites {
+		t.Fatal("the favorites were not shown after adding a custom station")
+	}
+
+	// E edits the selected custom station in place.
+	got.stations = favoritesToStations(got.favorites.List())
+	got.selected = len(got.stations) - 1
+	updated, _ = got.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	got = updated.(Model)
+	if !got.showCustom || got.customFields[customFieldURL].Value() != custom.URL {
+		t.Fatalf("E did not open the station for editing")
+	}
+	got = typeText(t, got, " 2")
+	updated, _ = got.update(tea.KeyMsg{Type: tea.KeyEnter})
+	got = updated.(Model)
+	if fav, _ := got.favorites.Get(custom.UUID); fav.Name != "Office Radio 2" || got.favorites.Count() != len(list) {
+		t.Errorf("edited station = %+v", fav)
+	}
+
+	got.selected = 0
+	updated, _ = got.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if got = updated.(Model); got.showCustom {
+		t.Error("E opened a directory station for editing")
+	}
+}
+
+func TestModel_CustomStationFormRejectsBadInput(t *testing.T) {
+	m := groupedModel(t)
+	m.customFields = newCustomFields()
+
+	updated, _ := m.openCustomDialog(nil)
+	got := typeText(t, updated.(Model), "No URL")
+	updated, _ = got.update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got = updated.(Model); !got.showCustom || got.errMsg == "" {
+		t.Errorf("saved a station without a URL (error %q)", got.errMsg)
+	}
+	if got.favorites.Count() != 3 {
+		t.Errorf("favorites = %d, want 3", got.favorites.Count())
+	}
+}
+
+func TestModel_PlayCustomStationSkipsDirectory(t *testing.T) {
+	m := groupedModel(t)
+	fav, err := m.favorites.AddCustom(config.Favorite{Name: "Office Radio", URL: "http://intranet.example/live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// m.api is nil: any directory lookup would fail.
+	msg, ok := m.playStationCmd(radio.Station{UUID: fav.UUID})().(playMsg)
+	if !ok || msg.err != nil || msg.url != fav.URL || msg.station.Name != "Office Radio" {
+		t.Fatalf("play = %+v", msg)
+	}
+
+	if msg := m.playStationCmd(radio.Station{UUID: "custom-gone"})().(playMsg); msg.err == nil {
+		t.Error("played an unknown custom station")
+	}
+}
+
+func TestModel_CustomStationFrequencyOnDial(t *testing.T) {
+	m := createTestModel()
+	m.stationSource = sourceFavorites
+	m.stations = []radio.Station{
+		{UUID: "1", Name: "Preset"},
+		{UUID: "custom-a", Name: "Office Radio", Frequency: 80.5},
+	}
+	m.updateDialRange()
+
+	for i, station := range m.stations {
+		label, _ := m.listFrequency(i, len(m.stations), station)
+		if dial := m.dialValueForIndex(i); dial != label {
+			t.Errorf("station %d: dial at %v, list shows %v", i, dial, label)
+		}
+	}
+	if m.dialMin > 80.5 {
+		t.Errorf("dial starts at %v, above the custom station's 80.5 MHz", m.dialMin)
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"log"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/ctl"
+	"radio-tui/internal/ipc"
+)
+
+// Mode selects where a Model plays audio.
+type Mode int
+
+const (
+	// ModeStandalone plays audio in process and serves IPC for the tray.
+	ModeStandalone Mode = iota
+	// ModeDaemon plays audio and serves IPC without a terminal, so playback
+	// outlives any TUI.
+	ModeDaemon
+	// ModeAttached drives the playback session of a running daemon over IPC.
+	ModeAttached
+)
+
+// WithMode returns a copy of the model running in the given mode.
+func (m Model) WithMode(mode Mode) Model {
+	m.mode = mode
+	switch mode {
+	case ModeDaemon:
+		// Nobody tunes the dial without a terminal, so there is no static.
+		m.noise = nil
+	case ModeAttached:
+		// The daemon plays, notifies and runs the hooks.
+		m.player = nil
+		m.hooks = nil
+		m.noise = nil
+		m.missingPlayer = false
+		m.downloadingPlayer = false
+	}
+	return m
+}
+
+// WithStartupPlay returns a copy of the model that plays the given station
+// as soon as it has loaded, as for "valvefm play <uuid>" when no session
+// was running.
+func (m Model) WithStartupPlay(args ipc.PlayArgs) Model {
+	m.startupPlay = &args
+	return m
+}
+
+// startupTarget names the startup station for ipcPlay: a favorite or
+// Radio Browser UUID, or else a name.
+func (m Model) startupTarget(station string) *ipc.PlayArgs {
+	target := ctl.PlayTarget([]string{station})
+	if m.favorites != nil {
+		if _, ok := m.favorites.Get(station); ok {
+			target = ipc.PlayArgs{UUID: station}
+		}
+	}
+	return &target
+}
+
+// playStartup plays the station asked for on the command line. It runs
+// once, after the first station list, and with it the favorites, is in
+// place so that names can be looked up.
+func (m *Model) playStartup() tea.Cmd {
+	if m.startupPlay == nil || m.mode == ModeAttached {
+		return nil
+	}
+	args := *m.startupPlay
+	m.startupPlay = nil
+	cmd, reply := m.ipcPlay(args)
+	if !reply.ok {
+		m.errMsg = reply.err
+	}
+	return cmd
+}
+
+// RunDaemon runs the model headless until it is told to QUIT over IPC or
+// the process is signalled. Status messages are logged to stderr.
+func RunDaemon(m Model) error {
+	daemon := daemonModel{
+		Model:  m.WithMode(ModeDaemon),
+		logger: log.New(os.Stderr, "valvefm: ", log.LstdFlags),
+	}
+	program := tea.NewProgram(daemon, tea.WithoutRenderer(), tea.WithInput(nil))
+	final, err := program.Run()
+	if d, ok := final.(daemonModel); ok {
+		d.shutdown()
+	}
+	return err
+}
+
+// daemonModel wraps a Model without a view, logging what the TUI would
+// show in its status line.
+type daemonModel struct {
+	Model
+	logger *log.Logger
+}
+
+func (d daemonModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	prev := d.errMsg
+	next, cmd := d.Model.Update(msg)
+	if m, ok := next.(Model); ok {
+		d.Model = m
+	}
+	if d.errMsg != "" && d.errMsg != prev {
+		d.logger.Println(d.errMsg)
+	}
+	return d, cmd
+}
+
+func (d daemonModel) View() string {
+	return ""
+}
@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/player"
+	"radio-tui/internal/radio"
+)
+
+func TestModel_WithMode_Attached(t *testing.T) {
+	m := createTestModel()
+	m.noise = player.NewNoisePlayer()
+	m.missingPlayer = true
+
+	attached := m.WithMode(ModeAttached)
+	if attached.mode != ModeAttached {
+		t.Errorf("mode = %v, want ModeAttached", attached.mode)
+	}
+	if attached.player != nil || attached.noise != nil {
+		t.Error("an attached TUI should not own any audio")
+	}
+	if attached.missingPlayer {
+		t.Error("an attached TUI should not report a missing player")
+	}
+}
+
+func TestModel_IPCPlay_SelectsStation(t *testing.T) {
+	m := createTestModel()
+
+	cmd, reply := m.ipcPlay(ipc.PlayArgs{UUID: "3"})
+	if !reply.ok {
+		t.Fatalf("PLAY failed: %s", reply.err)
+	}
+	if cmd == nil {
+		t.Error("PLAY should queue playback")
+	}
+	if m.selected != 2 {
+		t.Errorf("selected = %d, want 2", m.selected)
+	}
+}
+
+func TestModel_StartupPlay_AfterStationsLoad(t *testing.T) {
+	m := createTestModel().WithStartupPlay(ipc.PlayArgs{Name: "jazz"})
+	stations := m.stations
+	m.stations = nil
+
+	next, cmd := m.Update(stationsMsg{stations: stations, country: "US"})
+	m = next.(Model)
+	if cmd == nil || m.startupPlay != nil {
+		t.Fatal("the startup station should be queued once stations load")
+	}
+	if m.selected != 2 {
+		t.Errorf("selected = %d, want the jazz station", m.selected)
+	}
+
+	// Later station lists leave playback alone.
+	if _, cmd := m.Update(stationsMsg{stations: stations, country: "US"}); cmd != nil {
+		t.Error("the startup station should only be played once")
+	}
+}
+
+func TestModel_IPCPlay_ResumesLastStation(t *testing.T) {
+	m := createTestModel()
+	m.lastStation = radio.Station{UUID: "x", Name: "Elsewhere FM"}
+
+	_, reply := m.ipcPlay(ipc.PlayArgs{})
+	if !reply.ok {
+		t.Fatalf("PLAY failed: %s", reply.err)
+	}
+	if m.selected != 0 {
+		t.Errorf("resuming should keep the selection, got %d", m.selected)
+	}
+}
+
+func TestModel_IPCStatus_ReportsLastStation(t *testing.T) {
+	m := createTestModel()
+	m.playing = true
+	m.lastStation = radio.Station{UUID: "4", Name: "News Talk"}
+
+	status := m.ipcStatus()
+	if !status.Playing || status.UUID != "4" || status.Station != "News Talk" {
+		t.Errorf("status = %+v, want playing News Talk (4)", status)
+	}
+}
+
+func TestModel_LookupStation(t *testing.T) {
+	m := createTestModel()
+
+	if got := m.lookupStation("2"); got.Name != "Pop Radio" {
+		t.Errorf("lookupStation(2).Name = %q, want Pop Radio", got.Name)
+	}
+	if got := m.lookupStation("missing"); got.UUID != "missing" || got.Name != "" {
+		t.Errorf("lookupStation(missing) = %+v, want bare UUID", got)
+	}
+}
+
+func TestModel_HandleRemoteStatus(t *testing.T) {
+	m := createTestModel().WithMode(ModeAttached)
+
+	updated, _ := m.handleRemoteStatus(remoteStatusMsg{status: ipc.Status{
+		Playing:        true,
+		Station:        "Jazz Station",
+		UUID:           "3",
+		SleepRemaining: 600,
+	}})
+	got := updated.(Model)
+	if !got.playing || got.playingUUID != "3" {
+		t.Errorf("playing = %v (%q), want true (3)", got.playing, got.playingUUID)
+	}
+	if got.lastStation.Name != "Jazz Station" {
+		t.Errorf("lastStation = %+v, want Jazz Station", got.lastStation)
+	}
+	if remaining := got.sleepRemaining(time.Now()); remaining < 9*time.Minute {
+		t.Errorf("sleepRemaining() = %v, want ~10m", remaining)
+	}
+}
+
+func TestModel_HandleRemoteStatus_LostDaemon(t *testing.T) {
+	m := createTestModel().WithMode(ModeAttached)
+	m.playing = true
+
+	updated, _ := m.handleRemoteStatus(remoteStatusMsg{err: errors.New("connection refused")})
+	got := updated.(Model)
+	if got.playing {
+		t.Error("playback state should reset when the daemon is gone")
+	}
+	if !got.daemonLost || !strings.Contains(got.errMsg, "daemon") {
+		t.Errorf("errMsg = %q, want a lost daemon notice", got.errMsg)
+	}
+
+	got.errMsg = "something else"
+	updated, _ = got.handleRemoteStatus(remoteStatusMsg{err: errors.New("connection refused")})
+	if updated.(Model).errMsg != "something else" {
+		t.Error("a lost daemon should only be reported once")
+	}
+
+	updated, _ = got.handleRemoteStatus(remoteStatusMsg{status: ipc.Status{}})
+	if got := updated.(Model); got.daemonLost || got.errMsg != "" {
+		t.Error("reconnecting should clear the notice")
+	}
+}
+
+func TestModel_StopPlayback_Attached(t *testing.T) {
+	m := createTestModel().WithMode(ModeAttached)
+	m.playing = true
+
+	if cmd := m.stopPlayback(); cmd == nil {
+		t.Error("an attached TUI should ask the daemon to stop")
+	}
+	if m.playing {
+		t.Error("stopPlayback() should clear the playing state")
+	}
+}
+
+func TestDaemonModel_LogsStatusMessages(t *testing.T) {
+	var buf bytes.Buffer
+	d := daemonModel{Model: createTestModel().WithMode(ModeDaemon), logger: log.New(&buf, "", 0)}
+
+	next, _ := d.Update(playMsg{err: errors.New("stream unavailable")})
+	if !strings.Contains(buf.String(), "stream unavailable") {
+		t.Errorf("log = %q, want the play error", buf.String())
+	}
+	if next.(daemonModel).View() != "" {
+		t.Error("the daemon should not render a view")
+	}
+}
+
+// levelPlayer is a backend with a built-in volume.
+type levelPlayer struct{ level float64 }
+
+func (p *levelPlayer) Play(string) error                     { return nil }
+func (p *levelPlayer) Stop() error                           { return nil }
+func (p *levelPlayer) IsPlaying() bool                       { return false }
+func (p *levelPlayer) LastURL() string                       { return "" }
+func (p *levelPlayer) FadeTo(level float64, _ time.Duration) { p.level = level }
+
+// fullLevelPlayer is a backend that ignores level changes, like ffplay.
+type fullLevelPlayer struct{ levelPlayer }
+
+func (p *fullLevelPlayer) CanFade() bool { return false }
+
+// stopCounter counts how often playback is stopped.
+type stopCounter struct {
+	levelPlayer
+	stops int
+}
+
+func (p *stopCounter) Stop() error {
+	p.stops++
+	return nil
+}
+
+func TestModel_ShutdownRunsOnce(t *testing.T) {
+	m := createTestModel()
+	backend := &stopCounter{}
+	m.player = backend
+
+	// QUIT shuts down in the handler, then RunDaemon on the final model.
+	m.shutdown()
+	final := *m
+	final.shutdown()
+	if backend.stops != 1 {
+		t.Errorf("player stopped %d times, want once", backend.stops)
+	}
+}
+
+func TestModel_IPCPlay_ByName(t *testing.T) {
+	m := createTestModel()
+
+	if _, reply := m.ipcPlay(ipc.PlayArgs{Name: "jazz"}); !reply.ok {
+		t.Fatalf("PLAY jazz failed: %s", reply.err)
+	}
+	if m.selected != 2 {
+		t.Errorf("selected = %d, want the Jazz Station row", m.selected)
+	}
+
+	_, reply := m.ipcPlay(ipc.PlayArgs{Name: "polka"})
+	if reply.ok || reply.code != ipc.ErrNotFound {
+		t.Errorf("PLAY polka reply = %+v, want not_found", reply)
+	}
+}
+
+func TestModel_StationByName_PrefersExactMatch(t *testing.T) {
+	m := createTestModel()
+	m.stations = append(m.stations, radio.Station{UUID: "6", Name: "Rock"})
+
+	station, ok := m.stationByName("ROCK")
+	if !ok || station.UUID != "6" {
+		t.Errorf("stationByName(ROCK) = %+v, want the exact match", station)
+	}
+	station, _ = m.stationByName("news")
+	if station.UUID != "4" {
+		t.Errorf("stationByName(news) = %+v, want the prefix match", station)
+	}
+}
+
+func TestModel_IPCVolume(t *testing.T) {
+	m := createTestModel()
+	if reply := m.ipcVolume(ipc.VolumeArgs{Delta: -10}); reply.code != ipc.ErrUnavailable {
+		t.Errorf("VOLUME without a built-in player = %+v, want unavailable", reply)
+	}
+	m.player = &fullLevelPlayer{}
+	if reply := m.ipcVolume(ipc.VolumeArgs{Delta: -10}); reply.code != ipc.ErrUnavailable || m.volume() != 100 {
+		t.Errorf("VOLUME while ffplay plays = %+v, want unavailable", reply)
+	}
+
+	backend := &levelPlayer{}
+	m.player = backend
+	level := 40
+	m.ipcVolume(ipc.VolumeArgs{Level: &level})
+	reply := m.ipcVolume(ipc.VolumeArgs{Delta: -50})
+	if got := reply.value.(ipc.VolumeStatus).Level; got != 0 {
+		t.Errorf("volume = %d, want it clamped at 0", got)
+	}
+	reply = m.ipcVolume(ipc.VolumeArgs{Delta: 30})
+	if got := reply.value.(ipc.VolumeStatus).Level; got != 30 || m.ipcStatus().Volume != 30 {
+		t.Errorf("volume = %d, want 30", got)
+	}
+	if backend.level != 0.3 {
+		t.Errorf("player level = %v, want 0.3", backend.level)
+	}
+}
+
+func TestModel_IPCMute(t *testing.T) {
+	m := createTestModel()
+	m.player = &fullLevelPlayer{}
+	if reply := m.ipcMute(nil); reply.code != ipc.ErrUnavailable || m.muted {
+		t.Errorf("MUTE while ffplay plays = %+v, want unavailable", reply)
+	}
+
+	backend := &levelPlayer{}
+	m.player = backend
+	level := 60
+	m.ipcVolume(ipc.VolumeArgs{Level: &level})
+
+	reply := m.ipcMute(nil)
+	if status := reply.value.(ipc.VolumeStatus); !status.Muted || status.Level != 60 {
+		t.Errorf("MUTE = %+v, want muted keeping level 60", status)
+	}
+	if backend.level != 0 || !m.ipcStatus().Muted {
+		t.Errorf("player level = %v, want silence", backend.level)
+	}
+
+	m.ipcMute(&ipc.MuteArgs{Muted: false})
+	if m.muted || backend.level != 0.6 {
+		t.Errorf("unmuted level = %v, want 0.6", backend.level)
+	}
+}
+
+func TestModel_RememberRecent(t *testing.T) {
+	m := createTestModel()
+	for _, uuid := range []string{"1", "2", "1", "3"} {
+		m.rememberRecent(m.lookupStation(uuid))
+	}
+	stations := m.ipcRecent().value.([]ipc.Station)
+	var uuids []string
+	for _, station := range stations {
+		uuids = append(uuids, station.UUID)
+	}
+	if strings.Join(uuids, ",") != "3,1,2" {
+		t.Errorf("recent = %v, want 3,1,2 with repeats moved to the front", uuids)
+	}
+
+	for i := range recentLimit + 5 {
+		m.rememberRecent(radio.Station{UUID: fmt.Sprint("x", i)})
+	}
+	if len(m.recent) != recentLimit {
+		t.Errorf("recent holds %d stations, want %d", len(m.recent), recentLimit)
+	}
+}
+
+func TestModel_IPCFavorite_AddAndRemove(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	favorites, err := config.LoadFavorites()
+	if err != nil {
+		t.Fatalf("LoadFavorites() error = %v", err)
+	}
+	m := createTestModel()
+	m.favorites = favorites
+	m.playingUUID = "3"
+
+	reply := make(chan ipcReply, 1)
+	msg := m.ipcFavoriteCmd(ipc.FavoriteArgs{}, reply)()
+	if r := <-reply; !r.ok {
+		t.Fatalf("FAV add failed: %s", r.err)
+	}
+	if _, ok := msg.(favoritesChangedMsg); !ok {
+		t.Errorf("FAV add returned %T, want favoritesChangedMsg", msg)
+	}
+	if list := favorites.List(); len(list) != 1 || list[0].Name != "Jazz Station" {
+		t.Errorf("favorites = %+v, want the playing Jazz Station", list)
+	}
+
+	// Adding again changes nothing.
+	if msg := m.ipcFavoriteCmd(ipc.FavoriteArgs{UUID: "3"}, reply)(); msg != nil || !(<-reply).ok {
+		t.Errorf("FAV add of a favorite returned %v, want a plain ok", msg)
+	}
+
+	m.ipcFavoriteCmd(ipc.FavoriteArgs{UUID: "3", Remove: true}, reply)()
+	if r := <-reply; !r.ok || favorites.IsFavorite("3") {
+		t.Errorf("FAV remove reply = %+v, favorite kept = %v", r, favorites.IsFavorite("3"))
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"time"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/player"
+)
+
+// refreshNowPlaying picks up the title announced by the playing stream.
+func (m *Model) refreshNowPlaying() {
+	title := ""
+	if reporter, ok := m.player.(player.TitleReporter); ok && m.playing {
+		title = reporter.Title()
+	}
+	m.nowPlaying = title
+}
+
+// publishChanges pushes what changed since prev to IPC subscribers and
+// MPRIS clients.
+func (m *Model) publishChanges(prev Model) {
+	if m.mpris != nil {
+		m.mpris.Update(m.mprisState())
+	}
+	m.notifyChanges(prev)
+	if m.hooks != nil {
+		for _, ev := range m.hookEvents(prev) {
+			m.hooks.Fire(ev)
+		}
+	}
+	if m.ipc == nil {
+		return
+	}
+	if m.playingUUID != prev.playingUUID || m.lastStation.UUID != prev.lastStation.UUID {
+		m.ipc.publish(ipc.EventStation, m.ipcStatus())
+	}
+	if m.playing != prev.playing {
+		m.ipc.publish(ipc.EventPlayState, m.ipcStatus())
+	}
+	if m.nowPlaying != prev.nowPlaying {
+		m.ipc.publish(ipc.EventTitle, ipc.TitleEvent{UUID: m.playingUUID, Title: m.nowPlaying})
+	}
+	if m.favoritesRev != prev.favoritesRev {
+		count := 0
+		if m.favorites != nil {
+			count = m.favorites.Count()
+		}
+		m.ipc.publish(ipc.EventFavorites, ipc.FavoritesEvent{Count: count})
+	}
+	if !m.sleepAt.Equal(prev.sleepAt) || m.nextAlarmLabel() != prev.nextAlarmLabel() {
+		m.ipc.publish(ipc.EventTimers, m.ipcStatus())
+	}
+	if m.volume() != prev.volume() || m.muted != prev.muted {
+		m.ipc.publish(ipc.EventVolume, m.volumeStatus())
+	}
+	if m.errMsg != "" && m.errMsg != prev.errMsg {
+		m.ipc.publish(ipc.EventError, ipc.ErrorEvent{Message: m.errMsg})
+	}
+}
+
+// nextAlarmLabel formats when the next alarm rings, or "" without one.
+func (m Model) nextAlarmLabel() string {
+	if _, at, ok := config.NextAlarm(m.alarms, time.Now()); ok {
+		return at.Format("15:04")
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/radio"
+)
+
+// titlePlayer is a backend whose stream announces a fixed title.
+type titlePlayer struct{ title string }
+
+func (p *titlePlayer) Play(string) error { return nil }
+func (p *titlePlayer) Stop() error       { return nil }
+func (p *titlePlayer) IsPlaying() bool   { return true }
+func (p *titlePlayer) LastURL() string   { return "" }
+func (p *titlePlayer) Title() string     { return p.title }
+
+// recordingServer returns a server with one subscriber to every event,
+// whose queue collects what gets published.
+func recordingServer() (*ipcServer, *ipcSubscriber) {
+	sub := &ipcSubscriber{queue: make(chan []byte, ipcSubscriberQueue)}
+	server := &ipcServer{subs: map[*ipcSubscriber]struct{}{sub: {}}}
+	return server, sub
+}
+
+func publishedEvents(t *testing.T, sub *ipcSubscriber) []ipc.Event {
+	t.Helper()
+	var events []ipc.Event
+	for {
+		select {
+		case line := <-sub.queue:
+			var ev ipc.Event
+			if err := json.Unmarshal(line, &ev); err != nil {
+				t.Fatalf("published line %q is not an event: %v", line, err)
+			}
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+func TestModel_PublishChanges(t *testing.T) {
+	server, sub := recordingServer()
+	prev := createTestModel()
+	prev.ipc = server
+
+	m := *prev
+	m.playing = true
+	m.playingUUID = "3"
+	m.lastStation = radio.Station{UUID: "3", Name: "Jazz Station"}
+	m.nowPlaying = "Artist - Song"
+	m.favoritesRev++
+	m.errMsg = "Playing Jazz Station"
+	m.muted = true
+	m.publishChanges(*prev)
+
+	got := map[string]ipc.Event{}
+	for _, ev := range publishedEvents(t, sub) {
+		got[ev.Event] = ev
+	}
+	for _, name := range []string{ipc.EventStation, ipc.EventPlayState, ipc.EventTitle, ipc.EventFavorites, ipc.EventVolume, ipc.EventError} {
+		if _, ok := got[name]; !ok {
+			t.Errorf("no %s event published", name)
+		}
+	}
+	if _, ok := got[ipc.EventTimers]; ok {
+		t.Error("timers event published without a timer change")
+	}
+
+	var status ipc.Status
+	if err := json.Unmarshal(got[ipc.EventStation].Data, &status); err != nil || status.Station != "Jazz Station" || !status.Playing {
+		t.Errorf("station event = %s, want Jazz Station playing", got[ipc.EventStation].Data)
+	}
+
+	// Nothing changed, nothing published.
+	m.publishChanges(m)
+	if events := publishedEvents(t, sub); len(events) != 0 {
+		t.Errorf("published %d events without a change", len(events))
+	}
+}
+
+func TestModel_RefreshNowPlaying(t *testing.T) {
+	m := createTestModel()
+	m.player = &titlePlayer{title: "Artist - Song"}
+
+	m.refreshNowPlaying()
+	if m.nowPlaying != "" {
+		t.Errorf("nowPlaying = %q while stopped, want empty", m.nowPlaying)
+	}
+
+	m.playing = true
+	m.refreshNowPlaying()
+	if m.nowPlaying != "Artist - Song" {
+		t.Errorf("nowPlaying = %q, want the stream title", m.nowPlaying)
+	}
+}
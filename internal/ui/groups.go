@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// openGroupDialog shows the favorite groups; Space adds the selected
+// station to the highlighted group or takes it out.
+func (m Model) openGroupDialog() (tea.Model, tea.Cmd) {
+	if m.favorites == nil {
+		m.errMsg = "Favorites not available"
+		return m, nil
+	}
+	m.showGroups = true
+	m.groupEditing = false
+	m.groupStation, _ = m.currentStation()
+	m.groupIdx = 0
+	for i, group := range m.favorites.Groups() {
+		if strings.EqualFold(group.Name, m.favGroup) {
+			m.groupIdx = i + 1
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateGroupDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.groupEditing {
+		return m.updateGroupEditor(msg)
+	}
+
+	// Row 0 is all favorites and the last row is "New group".
+	groups := m.favorites.Groups()
+	var group config.Group
+	onGroup := m.groupIdx > 0 && m.groupIdx <= len(groups)
+	if onGroup {
+		group = groups[m.groupIdx-1]
+	}
+
+	switch msg.String() {
+	case "g", "G", "esc":
+		m.showGroups = false
+	case "up", "k":
+		if m.groupIdx > 0 {
+			m.groupIdx--
+		}
+	case "down", "j":
+		if m.groupIdx <= len(groups) {
+			m.groupIdx++
+		}
+	case "shift+up", "K":
+		if onGroup {
+			m.groupIdx = max(m.groupIdx-1, 1)
+			return m, m.editGroups(m.favorites.MoveGroup(group.Name, -1))
+		}
+	case "shift+down", "J":
+		if onGroup {
+			m.groupIdx = min(m.groupIdx+1, len(groups))
+			return m, m.editGroups(m.favorites.MoveGroup(group.Name, 1))
+		}
+	case " ":
+		if !onGroup || m.groupStation.UUID == "" {
+			return m, nil
+		}
+		if !m.favorites.IsFavorite(m.groupStation.UUID) {
+			m.errMsg = "Press F to make the station a favorite first"
+			return m, nil
+		}
+		in := m.favorites.InGroup(m.groupStation.UUID, group.Name)
+		return m, m.editGroups(m.favorites.SetInGroup(m.groupStation.UUID, group.Name, !in))
+	case "r", "R":
+		if onGroup {
+			return m.startGroupEditor(group.Name)
+		}
+	case "n", "N":
+		return m.startGroupEditor("")
+	case "d", "D", "delete":
+		if onGroup {
+			if strings.EqualFold(m.favGroup, group.Name) {
+				m.favGroup = ""
+			}
+			m.groupIdx = min(m.groupIdx, len(groups)-1)
+			return m, m.editGroups(m.favorites.DeleteGroup(group.Name))
+		}
+	case "enter":
+		if m.groupIdx > len(groups) {
+			return m.startGroupEditor("")
+		}
+		m.showGroups = false
+		return m, m.showFavoritesGroup(group.Name)
+	}
+	return m, nil
+}
+
+// startGroupEditor names a new group, or renames the given one.
+func (m Model) startGroupEditor(renaming string) (tea.Model, tea.Cmd) {
+	m.groupEditing = true
+	m.groupRenaming = renaming
+	m.groupName.SetValue(renaming)
+	m.groupName.Focus()
+	m.groupName.CursorEnd()
+	return m, textinput.Blink
+}
+
+func (m Model) updateGroupEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.groupEditing = false
+		m.groupName.Blur()
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(m.groupName.Value())
+		var err error
+		if m.groupRenaming == "" {
+			err = m.favorites.CreateGroup(name)
+		} else {
+			err = m.favorites.RenameGroup(m.groupRenaming, name)
+		}
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		if m.groupRenaming != "" && strings.EqualFold(m.favGroup, m.groupRenaming) {
+			m.favGroup = name
+		}
+		for i, group := range m.favorites.Groups() {
+			if group.Name == name {
+				m.groupIdx = i + 1
+			}
+		}
+		m.groupEditing = false
+		m.groupName.Blur()
+		m.errMsg = ""
+		return m, m.editGroups(nil)
+	}
+
+	var cmd tea.Cmd
+	m.groupName, cmd = m.groupName.Update(msg)
+	return m, cmd
+}
+
+// editGroups reports a failed group edit, or passes a saved one on to the
+// list and the daemon.
+func (m *Model) editGroups(err error) tea.Cmd {
+	if err != nil {
+		m.errMsg = err.Error()
+		return nil
+	}
+	return tea.Batch(m.favoritesChanged(), m.reloadDaemonCmd())
+}
+
+// cycleGroup shows the next (delta 1) or previous favorites group, with
+// all favorites between the last group and the first.
+func (m *Model) cycleGroup(delta int) tea.Cmd {
+	if m.favorites == nil || len(m.favorites.Groups()) == 0 {
+		m.errMsg = "No favorite groups yet; press G to create one"
+		return nil
+	}
+	names := []string{""}
+	for _, group := range m.favorites.Groups() {
+		names = append(names, group.Name)
+	}
+	at := 0
+	for i, name := range names {
+		if strings.EqualFold(name, m.favGroup) {
+			at = i
+		}
+	}
+	if m.stationSource == sourceFavorites {
+		at = (at + delta + len(names)) % len(names)
+	}
+	return m.showFavoritesGroup(names[at])
+}
+
+// showFavoritesGroup switches the list to a favorites group, or to all
+// favorites for an empty name. An attached TUI moves the daemon along, so
+// that NEXT and PREV stay in the group.
+func (m *Model) showFavoritesGroup(name string) tea.Cmd {
+	m.stationSource = sourceFavorites
+	m.favGroup = name
+	m.activeSearch = ""
+	m.search.SetValue("")
+	m.page = 0
+	m.hasMore = false
+	m.selected = 0
+	m.loading = true
+	m.errMsg = ""
+	m.noise.Start()
+	if m.mode == ModeAttached {
+		return tea.Batch(m.loadStationsCmd(), m.remoteCmd(ipc.CmdGroup, ipc.GroupArgs{Name: name}))
+	}
+	return m.loadStationsCmd()
+}
+
+// listGroup is the favorites group the list shows, if any.
+func (m *Model) listGroup() string {
+	if m.stationSource != sourceFavorites {
+		return ""
+	}
+	return m.favGroup
+}
+
+// ipcGroups lists the favorite groups.
+func (m *Model) ipcGroups() ipcReply {
+	groups := []ipc.Group{}
+	if m.favorites != nil {
+		for _, group := range m.favorites.Groups() {
+			groups = append(groups, ipc.Group{
+				Name:     group.Name,
+				Stations: len(group.Stations),
+				Current:  strings.EqualFold(group.Name, m.listGroup()),
+			})
+		}
+	}
+	return ipcReply{ok: true, value: groups}
+}
+
+// ipcGroup switches to a favorites group, or reports the current one
+// without arguments.
+func (m *Model) ipcGroup(args *ipc.GroupArgs) (tea.Cmd, ipcReply) {
+	if args == nil {
+		name := m.listGroup()
+		return nil, ipcReply{ok: true, data: name, value: ipc.GroupArgs{Name: name}}
+	}
+	if m.favorites == nil {
+		return nil, ipcError(ipc.ErrUnavailable, "favorites not available")
+	}
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return m.showFavoritesGroup(""), ipcReply{ok: true}
+	}
+	for _, group := range m.favorites.Groups() {
+		if strings.EqualFold(group.Name, name) {
+			return m.showFavoritesGroup(group.Name), ipcReply{ok: true}
+		}
+	}
+	return nil, ipcError(ipc.ErrNotFound, fmt.Sprintf("no group named %q", name))
+}
+
+// forgetMissingGroup falls back to all favorites when the shown group was
+// deleted elsewhere.
+func (m *Model) forgetMissingGroup() {
+	if m.favGroup != "" && (m.favorites == nil || !m.favorites.HasGroup(m.favGroup)) {
+		m.favGroup = ""
+	}
+}
@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// groupedModel has favorites 1, 3 and 4, with 3 and 4 in "Talk".
+func groupedModel(t *testing.T) *Model {
+	t.Helper()
+	return groupedModelOf(t, createTestModel())
+}
+
+// groupedModelOf makes the favorites of groupedModel from m's stations.
+func groupedModelOf(t *testing.T, m *Model) *Model {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	favorites, err := config.LoadFavorites()
+	if err != nil {
+		t.Fatalf("LoadFavorites() error = %v", err)
+	}
+	m.favorites = favorites
+	for _, i := range []int{0, 2, 3} {
+		if _, err := favorites.Toggle(m.stations[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := favorites.CreateGroup("Talk"); err != nil {
+		t.Fatal(err)
+	}
+	for _, uuid := range []string{"3", "4"} {
+		if err := favorites.SetInGroup(uuid, "Talk", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// loadList runs the station load and applies its result.
+func loadList(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	msg, ok := cmd().(stationsMsg)
+	if !ok {
+		t.Fatal("expected a station list load")
+	}
+	updated, _ := m.update(msg)
+	return updated.(Model)
+}
+
+func TestModel_IPCGroup_NextStaysInGroup(t *testing.T) {
+	m := groupedModel(t)
+
+	cmd, reply := m.ipcGroup(&ipc.GroupArgs{Name: "talk"})
+	if !reply.ok {
+		t.Fatalf("GROUP failed: %s", reply.err)
+	}
+	got := loadList(t, *m, cmd)
+	if got.favGroup != "Talk" || len(got.stations) != 2 {
+		t.Fatalf("group %q lists %+v, want the 2 Talk stations", got.favGroup, got.stations)
+	}
+
+	for range 3 {
+		got.ipcSelectAndPlay(1)
+		if station, _ := got.currentStation(); station.UUID != "4" {
+			t.Fatalf("NEXT tuned to %+v, want News Talk at the end of the group", station)
+		}
+	}
+
+	if _, reply := got.ipcGroup(nil); reply.data != "Talk" {
+		t.Errorf("GROUP reports %q, want Talk", reply.data)
+	}
+	if _, reply := got.ipcGroup(&ipc.GroupArgs{Name: "Nope"}); reply.ok {
+		t.Error("GROUP accepted an unknown group")
+	}
+
+	groups := got.ipcGroups().value.([]ipc.Group)
+	if len(groups) != 1 || groups[0].Stations != 2 || !groups[0].Current {
+		t.Errorf("GROUPS = %+v", groups)
+	}
+}
+
+func TestModel_CycleGroup(t *testing.T) {
+	m := groupedModel(t)
+	m.stationSource = sourceFavorites
+
+	cmd := m.cycleGroup(1)
+	got := loadList(t, *m, cmd)
+	if got.favGroup != "Talk" {
+		t.Fatalf("Tab showed %q, want Talk", got.favGroup)
+	}
+	cmd = got.cycleGroup(1)
+	got = loadList(t, got, cmd)
+	if got.favGroup != "" || len(got.stations) != 3 {
+		t.Errorf("Tab after the last group showed %q with %d stations, want all 3 favorites", got.favGroup, len(got.stations))
+	}
+}
+
+func TestModel_GroupDialog_AddsStationAndDeletesGroup(t *testing.T) {
+	m := groupedModel(t)
+	m.selected = 0 // Rock FM, a favorite outside Talk
+
+	updated, _ := m.openGroupDialog()
+	got := updated.(Model)
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeySpace, Runes: []rune{' '}}} {
+		updated, _ = got.updateGroupDialog(key)
+		got = updated.(Model)
+	}
+	if !got.favorites.InGroup("1", "Talk") {
+		t.Fatal("Space did not add the station to the group")
+	}
+
+	got.favGroup = "Talk"
+	updated, _ = got.updateGroupDialog(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	got = updated.(Model)
+	if got.favorites.HasGroup("Talk") || got.favGroup != "" {
+		t.Errorf("D left group %v, shown group %q", got.favorites.Groups(), got.favGroup)
+	}
+	if !got.favorites.IsFavorite("1") {
+		t.Error("deleting the group removed its favorites")
+	}
+}
+
+func TestModel_PlayPreset(t *testing.T) {
+	m := groupedModel(t) // favorites 1, 3, 4 in that order
+
+	if _, reply := m.playPreset(2); !reply.ok || m.selected != 2 {
+		t.Errorf("preset 2 = %+v, selected %d, want Jazz Station", reply, m.selected)
+	}
+	if _, reply := m.playPreset(4); reply.ok {
+		t.Error("preset 4 played with 3 favorites")
+	}
+	if _, reply := m.playPreset(10); reply.ok {
+		t.Error("preset 10 played")
+	}
+}
+
+func TestModel_MoveFavorite_KeepsSelection(t *testing.T) {
+	m := groupedModel(t)
+	m.stationSource = sourceFavorites
+	got := loadList(t, *m, m.loadStationsCmd())
+	got.selected = 2 // News Talk
+
+	got.moveFavorite(-1)
+	if got.selected != 1 || got.stations[1].UUID != "4" {
+		t.Fatalf("selected %d of %+v, want News Talk second", got.selected, got.stations)
+	}
+	if fav, _ := got.favorites.Preset(2); fav.UUID != "4" {
+		t.Errorf("preset 2 = %+v, want News Talk", fav)
+	}
+
+	got.activeSearch = "news"
+	got.moveFavorite(1)
+	if got.errMsg == "" {
+		t.Error("reordering a search result was allowed")
+	}
+}
+
+func TestFavoriteFrequency_PresetsStayPut(t *testing.T) {
+	for total := 1; total <= 30; total++ {
+		for i := 0; i < min(total, 9); i++ {
+			if got, want := favoriteFrequency(i, total), 88+2*float64(i); got != want {
+				t.Fatalf("preset %d of %d at %.1f MHz, want %.1f", i+1, total, got, want)
+			}
+		}
+		last := 0.0
+		for i := range total {
+			freq := favoriteFrequency(i, total)
+			if freq <= last || freq > 108 {
+				t.Fatalf("favorite %d of %d at %.2f MHz after %.2f", i, total, freq, last)
+			}
+			last = freq
+		}
+	}
+}
+
+func TestModel_FavoriteFrequency_StableAcrossViews(t *testing.T) {
+	// Stations without a frequency of their own sit on their dial slot.
+	m := createTestModel()
+	for i := range m.stations {
+		m.stations[i].Frequency = 0
+	}
+	*m = *groupedModelOf(t, m) // favorites 1, 3, 4; 3 and 4 in "Talk"
+	m.stationSource = sourceFavorites
+
+	dialOf := func(got Model, uuid string) float64 {
+		t.Helper()
+		for i, station := range got.stations {
+			if station.UUID == uuid {
+				label, _ := got.listFrequency(i, len(got.stations), station)
+				if dial := got.dialValueForIndex(i); dial != label {
+					t.Fatalf("station %s: dial at %v, list shows %v", uuid, dial, label)
+				}
+				return label
+			}
+		}
+		t.Fatalf("station %s not listed in %+v", uuid, got.stations)
+		return 0
+	}
+
+	first := loadList(t, *m, m.loadStationsCmd())
+	want := dialOf(first, "4")
+	if want != 92 {
+		t.Fatalf("preset 3 at %v MHz, want 92", want)
+	}
+
+	m.config.UI.PageSize = 2
+	m.page = 1
+	if got := dialOf(loadList(t, *m, m.loadStationsCmd()), "4"); got != want {
+		t.Errorf("on page 2 preset 3 at %v MHz, want %v", got, want)
+	}
+
+	m.page = 0
+	m.favGroup = "Talk"
+	if got := dialOf(loadList(t, *m, m.loadStationsCmd()), "4"); got != want {
+		t.Errorf("in the Talk group preset 3 at %v MHz, want %v", got, want)
+	}
+}
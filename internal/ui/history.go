@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"radio-tui/internal/history"
+	"radio-tui/internal/radio"
+)
+
+// Tabs of the listening stats view.
+const (
+	statsTop = iota
+	statsWeeks
+	statsCountries
+	statsTabCount
+)
+
+var statsTabNames = [statsTabCount]string{"Top stations", "By week", "By country"}
+
+// statsRows is how many rows a stats tab shows.
+const statsRows = 12
+
+type historySavedMsg struct{ err error }
+
+type statsMsg struct {
+	entries []history.Entry
+	err     error
+}
+
+// recordListening keeps the listening history: a station is logged once
+// it stops or another takes over, with the titles it announced meanwhile.
+// The process playing the audio keeps the log. The returned command saves
+// a finished station.
+func (m *Model) recordListening(now time.Time) tea.Cmd {
+	if m.history == nil || m.mode == ModeAttached {
+		return nil
+	}
+	var cmd tea.Cmd
+	if m.listening.UUID != "" && (!m.playing || m.playingUUID != m.listening.UUID) {
+		cmd = m.logListeningCmd(now)
+	}
+	if m.playing && m.listening.UUID == "" {
+		station := m.lastStation
+		m.listening = history.Entry{UUID: station.UUID, Name: station.Name, Country: station.Country, URL: station.URL, Start: now}
+	}
+	titles := m.listening.Titles
+	if m.listening.UUID != "" && m.nowPlaying != "" && (len(titles) == 0 || titles[len(titles)-1] != m.nowPlaying) {
+		// Clip so that earlier copies of the model keep their titles.
+		m.listening.Titles = append(slices.Clip(titles), m.nowPlaying)
+	}
+	return cmd
+}
+
+// logListeningCmd logs the station playing until now in the background.
+func (m *Model) logListeningCmd(now time.Time) tea.Cmd {
+	log, entry := m.history, m.listening
+	m.listening = history.Entry{}
+	return func() tea.Msg {
+		return historySavedMsg{err: log.Add(entry, now)}
+	}
+}
+
+// finishListening logs the station playing until now before quitting.
+func (m *Model) finishListening(now time.Time) {
+	if m.history == nil || m.listening.UUID == "" {
+		return
+	}
+	if err := m.history.Add(m.listening, now); err != nil {
+		m.errMsg = "Failed to save listening history: " + err.Error()
+	}
+	m.listening = history.Entry{}
+}
+
+// recentStations lists the stations played this session, then those in
+// the history, latest first and each once.
+func recentStations(session []radio.Station, log *history.Log) ([]radio.Station, error) {
+	stations := slices.Clone(session)
+	if log != nil {
+		entries, err := log.Entries()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range history.Recent(entries) {
+			stations = append(stations, radio.Station{UUID: entry.UUID, Name: entry.Name, Country: entry.Country, URL: entry.URL})
+		}
+	}
+	seen := make(map[string]bool)
+	return slices.DeleteFunc(stations, func(s radio.Station) bool {
+		dup := seen[s.UUID]
+		seen[s.UUID] = true
+		return dup
+	}), nil
+}
+
+// toggleRecent switches between the recently played stations and the
+// country list.
+func (m *Model) toggleRecent() tea.Cmd {
+	if m.stationSource == sourceRecent {
+		m.stationSource = sourceCountry
+	} else {
+		m.stationSource = sourceRecent
+	}
+	m.activeSearch = ""
+	m.search.SetValue("")
+	m.page = 0
+	m.hasMore = false
+	m.selected = 0
+	m.loading = true
+	m.errMsg = ""
+	m.noise.Start()
+	return m.loadStationsCmd()
+}
+
+// openStats shows the listening stats, read afresh from the history.
+func (m *Model) openStats() tea.Cmd {
+	log := m.history
+	if log == nil {
+		m.errMsg = "Listening history is not available"
+		return nil
+	}
+	m.showStats = true
+	m.statsTab = statsTop
+	m.stats = [statsTabCount][]history.Total{}
+	return func() tea.Msg {
+		entries, err := log.Entries()
+		return statsMsg{entries: entries, err: err}
+	}
+}
+
+func (m Model) handleStats(msg statsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.showStats = false
+		m.errMsg = "Failed to read listening history: " + msg.err.Error()
+		return m, nil
+	}
+	m.stats = [statsTabCount][]history.Total{
+		statsTop:       history.TopStations(msg.entries),
+		statsWeeks:     history.ByWeek(msg.entries),
+		statsCountries: history.ByCountry(msg.entries),
+	}
+	return m, nil
+}
+
+func (m Model) updateStats(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "s", "S", "esc":
+		m.showStats = false
+	case "tab", "right", "l":
+		m.statsTab = (m.statsTab + 1) % statsTabCount
+	case "shift+tab", "left", "h":
+		m.statsTab = (m.statsTab + statsTabCount - 1) % statsTabCount
+	}
+	return m, nil
+}
+
+func (m Model) renderStats() string {
+	tabs := make([]string, 0, statsTabCount)
+	for i, name := range statsTabNames {
+		if i == m.statsTab {
+			tabs = append(tabs, m.styles.ListActive.Render("["+name+"]"))
+		} else {
+			tabs = append(tabs, m.styles.Muted.Render(" "+name+" "))
+		}
+	}
+	lines := []string{
+		m.styles.ListHeader.Render("Listening Stats"),
+		lipgloss.JoinHorizontal(lipgloss.Top, tabs...),
+		"",
+	}
+
+	totals := m.stats[m.statsTab]
+	if len(totals) == 0 {
+		lines = append(lines, m.styles.Muted.Render("Nothing listened to yet"))
+	}
+	for i, total := range totals {
+		if i == statsRows {
+			lines = append(lines, m.styles.Muted.Render(fmt.Sprintf("and %d more", len(totals)-statsRows)))
+			break
+		}
+		label := padRight(truncateText(total.Label, 28), 29)
+		lines = append(lines, m.styles.ListItem.Render(fmt.Sprintf("%s%8s  %3d plays", label, formatListenTime(total.Time), total.Plays)))
+	}
+	lines = append(lines, "", m.styles.Muted.Render("Tab switch view  S/Esc close"))
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// formatListenTime shows a listening time as "3h 05m" or "12m".
+func formatListenTime(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
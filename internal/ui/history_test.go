@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/history"
+	"radio-tui/internal/radio"
+)
+
+func TestRecordListening(t *testing.T) {
+	log := history.OpenFile(filepath.Join(t.TempDir(), "history.jsonl"))
+	start := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+
+	m := createTestModel()
+	m.history = log
+	m.playing = true
+	m.playingUUID = "1"
+	m.lastStation = radio.Station{UUID: "1", Name: "Rock FM", Country: "Germany", URL: "http://rock.example/stream"}
+	m.recordListening(start)
+	m.nowPlaying = "Song A"
+	m.recordListening(start.Add(time.Minute))
+	m.recordListening(start.Add(2 * time.Minute))
+	m.nowPlaying = "Song B"
+	m.recordListening(start.Add(3 * time.Minute))
+	if len(m.listening.Titles) != 2 {
+		t.Fatalf("titles = %q, want each song once", m.listening.Titles)
+	}
+
+	m.playing = false
+	cmd := m.recordListening(start.Add(5 * time.Minute))
+	if m.listening.UUID != "" {
+		t.Error("the station is still being recorded after stopping")
+	}
+	if entries, _ := log.Entries(); len(entries) != 0 {
+		t.Fatal("the history was written inside Update")
+	}
+	if cmd == nil {
+		t.Fatal("stopping returned no command to save the history")
+	}
+	if msg := cmd().(historySavedMsg); msg.err != nil {
+		t.Fatalf("saving the history: %v", msg.err)
+	}
+	entries, err := log.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Entries() = %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.UUID != "1" || e.Duration != 300 || e.URL != "http://rock.example/stream" || e.Titles[1] != "Song B" {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestRecentStations(t *testing.T) {
+	log := history.OpenFile(filepath.Join(t.TempDir(), "history.jsonl"))
+	start := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	for _, uuid := range []string{"1", "2"} {
+		if err := log.Add(history.Entry{UUID: uuid, Name: "Station " + uuid, Start: start}, start.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stations, err := recentStations([]radio.Station{{UUID: "1", Name: "Rock FM"}, {UUID: "3"}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uuids []string
+	for _, s := range stations {
+		uuids = append(uuids, s.UUID)
+	}
+	if len(uuids) != 3 || uuids[0] != "1" || uuids[1] != "3" || uuids[2] != "2" {
+		t.Errorf("recentStations() = %v, want 1 3 2", uuids)
+	}
+}
+
+func TestStatsView(t *testing.T) {
+	m := createTestModel()
+	m.history = history.OpenFile(filepath.Join(t.TempDir(), "history.jsonl"))
+	cmd := m.openStats()
+	if !m.showStats || cmd == nil {
+		t.Fatal("the stats view did not open")
+	}
+	start := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	entries := []history.Entry{{UUID: "1", Name: "Rock FM", Country: "Germany", Start: start, Stop: start.Add(time.Hour), Duration: 3600}}
+	updated, _ := m.Update(statsMsg{entries: entries})
+	got := updated.(Model)
+	if len(got.stats[statsTop]) != 1 || got.stats[statsCountries][0].Label != "Germany" {
+		t.Errorf("stats = %+v", got.stats)
+	}
+
+	updated, _ = got.Update(tea.KeyMsg{Type: tea.KeyTab})
+	got = updated.(Model)
+	if got.statsTab != statsWeeks {
+		t.Errorf("statsTab = %d after tab, want %d", got.statsTab, statsWeeks)
+	}
+	updated, _ = got.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showStats {
+		t.Error("esc did not close the stats view")
+	}
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/hooks"
+)
+
+type hookFailedMsg struct{ err error }
+
+// listenHooksCmd waits for the next hook failure, to show it.
+func (m Model) listenHooksCmd() tea.Cmd {
+	if m.hooks == nil {
+		return nil
+	}
+	failures := m.hooks.Failures()
+	return func() tea.Msg {
+		return hookFailedMsg{err: <-failures}
+	}
+}
+
+// playbackFailed shows an error that kept a station from playing, which
+// error hooks are told about; other notices only go to the status line.
+func (m *Model) playbackFailed(msg string) {
+	m.errMsg = msg
+	m.playError = msg
+	m.playFailures++
+}
+
+// hookEvents lists the hook events between prev and m: a station starting
+// (including a switch to another one), playback stopping, a new song, and
+// a playback or stream error.
+func (m Model) hookEvents(prev Model) []hooks.Event {
+	station := m.lastStation
+	event := func(name string) hooks.Event {
+		return hooks.Event{Event: name, UUID: station.UUID, Station: station.Name, Country: station.Country, Title: m.nowPlaying}
+	}
+
+	var events []hooks.Event
+	switch {
+	case m.playing && (!prev.playing || m.playingUUID != prev.playingUUID):
+		events = append(events, event(hooks.EventStart))
+	case !m.playing && prev.playing:
+		events = append(events, event(hooks.EventStop))
+	}
+	if m.playing && m.nowPlaying != "" && m.nowPlaying != prev.nowPlaying {
+		events = append(events, event(hooks.EventTrack))
+	}
+	if m.playFailures != prev.playFailures {
+		ev := event(hooks.EventError)
+		ev.Error = m.playError
+		events = append(events, ev)
+	}
+	return events
+}
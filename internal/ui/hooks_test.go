@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"radio-tui/internal/hooks"
+	"radio-tui/internal/radio"
+)
+
+func hookNames(events []hooks.Event) []string {
+	var names []string
+	for _, ev := range events {
+		names = append(names, ev.Event)
+	}
+	return names
+}
+
+func TestModel_HookEvents(t *testing.T) {
+	prev := *createTestModel()
+	m := prev
+	m.playing = true
+	m.playingUUID = "3"
+	m.lastStation = radio.Station{UUID: "3", Name: "Jazz Station", Country: "France"}
+	events := m.hookEvents(prev)
+	if len(events) != 1 || events[0].Event != hooks.EventStart || events[0].Station != "Jazz Station" || events[0].Country != "France" {
+		t.Fatalf("start events = %+v", events)
+	}
+
+	prev, m.nowPlaying = m, "Artist - Song"
+	events = m.hookEvents(prev)
+	if len(events) != 1 || events[0].Event != hooks.EventTrack || events[0].Title != "Artist - Song" {
+		t.Fatalf("track events = %+v", events)
+	}
+
+	// Switching stations starts again, and the new station's title is a track.
+	prev = m
+	m.playingUUID = "4"
+	m.lastStation = radio.Station{UUID: "4", Name: "News Talk"}
+	m.nowPlaying = "Headlines"
+	if got := hookNames(m.hookEvents(prev)); len(got) != 2 || got[0] != hooks.EventStart || got[1] != hooks.EventTrack {
+		t.Errorf("switch events = %v", got)
+	}
+
+	prev, m.playing = m, false
+	if got := hookNames(m.hookEvents(prev)); len(got) != 1 || got[0] != hooks.EventStop {
+		t.Errorf("stop events = %v", got)
+	}
+
+	prev = m
+	m.playbackFailed("stream failed")
+	events = m.hookEvents(prev)
+	if len(events) != 1 || events[0].Event != hooks.EventError || events[0].Error != "stream failed" {
+		t.Errorf("error events = %+v", events)
+	}
+
+	// Notices in the status line are not errors.
+	prev, m.errMsg = m, "Already on the first page"
+	if got := m.hookEvents(prev); len(got) != 0 {
+		t.Errorf("a notice fired %v", hookNames(got))
+	}
+
+	if got := m.hookEvents(m); len(got) != 0 {
+		t.Errorf("unchanged model fired %v", hookNames(got))
+	}
+}
+
+func TestModel_HookFailureDoesNotFireErrorHook(t *testing.T) {
+	prev := *createTestModel()
+	updated, _ := prev.update(hookFailedMsg{err: errors.New("hook track: exit status 1")})
+	m := updated.(Model)
+	if m.errMsg == "" {
+		t.Fatal("hook failure not shown")
+	}
+	if got := m.hookEvents(prev); len(got) != 0 {
+		t.Errorf("hook failure fired %v", hookNames(got))
+	}
+}
+
+func TestModel_PlaybackErrorFiresErrorHook(t *testing.T) {
+	prev := *createTestModel()
+	updated, _ := prev.update(playMsg{station: radio.Station{UUID: "3"}, err: errors.New("resolving the stream: 404")})
+	m := updated.(Model)
+	events := m.hookEvents(prev)
+	if len(events) != 1 || events[0].Event != hooks.EventError || events[0].Error != "resolving the stream: 404" {
+		t.Fatalf("events = %+v, want the playback error", events)
+	}
+
+	// The same error again fires again.
+	prev = m
+	updated, _ = m.update(playMsg{station: radio.Station{UUID: "3"}, err: errors.New("resolving the stream: 404")})
+	if got := hookNames(updated.(Model).hookEvents(prev)); len(got) != 1 {
+		t.Errorf("repeated error fired %v", got)
+	}
+}
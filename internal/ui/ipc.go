@@ -2,31 +2,76 @@ package ui
 
 import (
 	"bufio"
-	"errors"
-	"fmt"
+	"encoding/json"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"radio-tui/internal/ipc"
 )
 
+const (
+	// ipcFirstLineTimeout bounds waiting for a client's first request.
+	ipcFirstLineTimeout = 2 * time.Second
+	// ipcIdleTimeout closes JSON-lines connections that stay silent.
+	ipcIdleTimeout = 5 * time.Minute
+	// ipcReplyTimeout bounds how long a request may take to answer.
+	ipcReplyTimeout = 12 * time.Second
+	// ipcMaxLine caps the length of a request line.
+	ipcMaxLine = 64 * 1024
+	// ipcWriteTimeout drops clients that stop reading their replies.
+	ipcWriteTimeout = 5 * time.Second
+	// ipcSubscriberQueue is how many events a subscriber may fall behind
+	// before the oldest ones are dropped.
+	ipcSubscriberQueue = 64
+)
+
 type ipcServer struct {
 	endpoint ipc.Endpoint
 	listener net.Listener
 	messages chan ipcMsg
 	done     chan struct{}
+	close    sync.Once
+
+	subsMu sync.Mutex
+	subs   map[*ipcSubscriber]struct{}
+}
+
+// ipcConn serializes writes to a client: replies and pushed events may be
+// written concurrently.
+type ipcConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// ipcSubscriber queues events for one subscribed client. Publishing
+// never blocks the model; when the queue is full the oldest event is
+// dropped and the subscriber told how many it missed.
+type ipcSubscriber struct {
+	write   func(line []byte) error
+	hangup  func()          // disconnects the client
+	events  map[string]bool // nil means all events
+	queue   chan []byte
+	dropped atomic.Int64
+	done    chan struct{}
 }
 
 type ipcMsg struct {
-	cmd   string
+	req   ipc.Request
 	reply chan ipcReply
 }
 
+// ipcReply is a handler's answer. Legacy clients get data (or value as
+// JSON) on success and "ERR err" on failure; JSON-lines clients get value
+// (or data as a string) and the error code.
 type ipcReply struct {
-	ok   bool
-	data string
-	err  string
+	ok    bool
+	data  string
+	value any
+	code  ipc.ErrorCode
+	err   string
 }
 
 type ipcReadyMsg struct {
@@ -36,6 +81,15 @@ type ipcReadyMsg struct {
 
 type ipcClosedMsg struct{}
 
+func ipcError(code ipc.ErrorCode, message string) ipcReply {
+	return ipcReply{ok: false, code: code, err: message}
+}
+
+func ipcFailure(err error) ipcReply {
+	protoErr := ipc.AsError(err)
+	return ipcError(protoErr.Code, protoErr.Message)
+}
+
 func newIPCServer() (*ipcServer, error) {
 	listener, endpoint, err := ipc.Listen()
 	if err != nil {
@@ -47,22 +101,29 @@ func newIPCServer() (*ipcServer, error) {
 		listener: listener,
 		messages: make(chan ipcMsg, 8),
 		done:     make(chan struct{}),
+		subs:     map[*ipcSubscriber]struct{}{},
 	}
 	go server.acceptLoop()
 	return server, nil
 }
 
+// Close stops the server. The daemon, the HTTP API and signals may all
+// shut down at once, so it is safe to call concurrently and more than once.
 func (s *ipcServer) Close() {
-	select {
-	case <-s.done:
-		return
-	default:
+	s.close.Do(func() {
 		close(s.done)
-	}
-	if s.listener != nil {
-		_ = s.listener.Close()
-	}
-	_ = ipc.Cleanup(s.endpoint)
+		if s.listener != nil {
+			_ = s.listener.Close()
+		}
+		_ = ipc.Cleanup(s.endpoint)
+
+		// Hang up on subscribers so they notice the server is gone.
+		s.subsMu.Lock()
+		for sub := range s.subs {
+			sub.hangup()
+		}
+		s.subsMu.Unlock()
+	})
 }
 
 func (s *ipcServer) acceptLoop() {
@@ -80,63 +141,292 @@ func (s *ipcServer) acceptLoop() {
 	}
 }
 
+// handleConn serves one client. A legacy client sends a single verb line
+// and gets one reply; a JSON-lines client may send any number of requests
+// over the same connection. After SUBSCRIBE, events are pushed between
+// replies and the connection never idles out. Connections from other users
+// are turned away, and every request must carry the session token, except
+// plain verb lines from a peer whose credentials were checked.
 func (s *ipcServer) handleConn(conn net.Conn) {
 	defer conn.Close()
-
-	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
-	reader := bufio.NewScanner(conn)
-	if !reader.Scan() {
-		s.writeReply(conn, ipcReply{ok: false, err: "empty command"})
+	out := &ipcConn{conn: conn}
+	verified, err := ipc.CheckPeer(conn)
+	if err != nil {
+		_ = out.writeResponse(nil, ipcError(ipc.ErrUnauthorized, "connection refused"))
 		return
 	}
-	cmd := strings.TrimSpace(reader.Text())
-	if cmd == "" {
-		s.writeReply(conn, ipcReply{ok: false, err: "empty command"})
-		return
+	var sub *ipcSubscriber
+	defer func() {
+		if sub != nil {
+			s.unsubscribe(sub)
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), ipcMaxLine)
+	_ = conn.SetReadDeadline(time.Now().Add(ipcFirstLineTimeout))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		req, legacy, err := ipc.ParseRequest(line)
+		var reply ipcReply
+		switch {
+		case err != nil:
+			reply = ipcFailure(err)
+		case !s.endpoint.Authorized(req.Token) && !(legacy && req.Token == "" && verified):
+			// Plain verb lines predate the token; they keep working
+			// without one from a peer the kernel says is this user.
+			reply = ipcError(ipc.ErrUnauthorized, "missing or wrong token")
+		case req.Cmd == ipc.CmdSubscribe:
+			if sub == nil {
+				sub, reply = s.subscribe(out, req)
+			} else {
+				reply = ipcError(ipc.ErrBadRequest, "already subscribed")
+			}
+		default:
+			reply = s.dispatch(req)
+		}
+
+		if legacy {
+			if err := out.writeLegacyReply(reply); err != nil || sub == nil {
+				return
+			}
+		} else if err := out.writeResponse(req.ID, reply); err != nil {
+			return
+		}
+
+		if sub != nil {
+			_ = conn.SetReadDeadline(time.Time{})
+		} else {
+			_ = conn.SetReadDeadline(time.Now().Add(ipcIdleTimeout))
+		}
+	}
+}
+
+// call runs a command for an in-process client, such as MPRIS, through
+// the same handlers as the socket.
+func (s *ipcServer) call(cmd string, args any) error {
+	req, err := ipc.NewRequest(0, cmd, args)
+	if err != nil {
+		return err
+	}
+	if reply := s.dispatch(req); !reply.ok {
+		return reply.response(nil).Error
 	}
+	return nil
+}
 
+// dispatch hands a request to the model and waits for its reply. When the
+// model is busy the request waits its turn instead of being dropped.
+func (s *ipcServer) dispatch(req ipc.Request) ipcReply {
 	replyChan := make(chan ipcReply, 1)
-	msg := ipcMsg{cmd: cmd, reply: replyChan}
+	msg := ipcMsg{req: req, reply: replyChan}
+	deadline := time.NewTimer(ipcReplyTimeout)
+	defer deadline.Stop()
 
 	select {
 	case <-s.done:
-		s.writeReply(conn, ipcReply{ok: false, err: "server shutting down"})
-		return
+		return ipcError(ipc.ErrShuttingDown, "server shutting down")
 	case s.messages <- msg:
-	default:
-		s.writeReply(conn, ipcReply{ok: false, err: "busy"})
-		return
+	case <-deadline.C:
+		return ipcError(ipc.ErrBusy, "busy")
 	}
 
 	select {
 	case reply := <-replyChan:
-		s.writeReply(conn, reply)
-	case <-time.After(2 * time.Second):
-		s.writeReply(conn, ipcReply{ok: false, err: "timeout"})
+		return reply
+	case <-deadline.C:
+		return ipcError(ipc.ErrTimeout, "timeout")
+	}
+}
+
+// subscribe registers the connection for the requested events.
+func (s *ipcServer) subscribe(out *ipcConn, req ipc.Request) (*ipcSubscriber, ipcReply) {
+	var args ipc.SubscribeArgs
+	if err := req.DecodeArgs(&args); err != nil {
+		return nil, ipcFailure(err)
+	}
+	sub, err := s.addSubscriber(args.Events, out.writeLine, func() { _ = out.conn.Close() })
+	if err != nil {
+		return nil, ipcFailure(err)
+	}
+	return sub, ipcReply{ok: true, data: "OK", value: ipc.SubscribeArgs{Events: args.Events}}
+}
+
+// addSubscriber starts pushing the named events, or all of them, through
+// write. hangup is called when writes fail or the server closes.
+func (s *ipcServer) addSubscriber(events []string, write func([]byte) error, hangup func()) (*ipcSubscriber, error) {
+	sub := &ipcSubscriber{
+		write:  write,
+		hangup: hangup,
+		queue:  make(chan []byte, ipcSubscriberQueue),
+		done:   make(chan struct{}),
+	}
+	if len(events) > 0 {
+		sub.events = map[string]bool{}
+		for _, event := range events {
+			if !ipc.IsEvent(event) {
+				return nil, ipc.Errorf(ipc.ErrBadRequest, "unknown event %s", event)
+			}
+			sub.events[event] = true
+		}
+	}
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	select {
+	case <-s.done:
+		return nil, ipc.Errorf(ipc.ErrShuttingDown, "server shutting down")
+	default:
+	}
+	s.subs[sub] = struct{}{}
+	go sub.run()
+	return sub, nil
+}
+
+// Dispatch runs a request for an in-process client, such as the web API.
+func (s *ipcServer) Dispatch(req ipc.Request) ipc.Response {
+	return s.dispatch(req).response(req.ID)
+}
+
+// Subscribe streams events to an in-process client, such as the web API.
+func (s *ipcServer) Subscribe(events []string, write func(line []byte) error, hangup func()) (func(), error) {
+	sub, err := s.addSubscriber(events, write, hangup)
+	if err != nil {
+		return nil, err
+	}
+	return func() { s.unsubscribe(sub) }, nil
+}
+
+func (s *ipcServer) unsubscribe(sub *ipcSubscriber) {
+	s.subsMu.Lock()
+	delete(s.subs, sub)
+	s.subsMu.Unlock()
+	close(sub.done)
+}
+
+// publish pushes an event to every interested subscriber without blocking.
+func (s *ipcServer) publish(name string, data any) {
+	ev, err := ipc.NewEvent(name, data)
+	if err != nil {
+		return
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for sub := range s.subs {
+		if sub.wants(name) {
+			sub.push(line)
+		}
+	}
+}
+
+func (sub *ipcSubscriber) wants(name string) bool {
+	return sub.events == nil || sub.events[name]
+}
+
+// push queues an event, dropping the oldest queued one when full.
+func (sub *ipcSubscriber) push(line []byte) {
+	for {
+		select {
+		case sub.queue <- line:
+			return
+		default:
+		}
+		select {
+		case <-sub.queue:
+			sub.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// run writes queued events until the subscriber goes away. A subscriber
+// that cannot take a write within ipcWriteTimeout is disconnected.
+func (sub *ipcSubscriber) run() {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case line := <-sub.queue:
+			if missed := sub.dropped.Swap(0); missed > 0 {
+				if ev, err := ipc.NewEvent(ipc.EventDropped, ipc.DroppedEvent{Count: missed}); err == nil {
+					if data, err := json.Marshal(ev); err == nil && sub.write(data) != nil {
+						sub.hangup()
+						return
+					}
+				}
+			}
+			if err := sub.write(line); err != nil {
+				sub.hangup()
+				return
+			}
+		}
 	}
 }
 
-func (s *ipcServer) writeReply(conn net.Conn, reply ipcReply) {
+func (c *ipcConn) writeLine(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(ipcWriteTimeout))
+	_, err := c.conn.Write(append(data, '\n'))
+	return err
+}
+
+func (c *ipcConn) writeLegacyReply(reply ipcReply) error {
 	if reply.ok {
 		if strings.TrimSpace(reply.data) != "" {
-			_, _ = fmt.Fprintln(conn, reply.data)
-			return
+			return c.writeLine([]byte(reply.data))
 		}
-		_, _ = fmt.Fprintln(conn, "OK")
-		return
+		if reply.value != nil {
+			if data, err := json.Marshal(reply.value); err == nil {
+				return c.writeLine(data)
+			}
+		}
+		return c.writeLine([]byte("OK"))
 	}
 
 	message := strings.TrimSpace(reply.err)
 	if message == "" {
 		message = "error"
 	}
-	_, _ = fmt.Fprintln(conn, "ERR "+message)
+	return c.writeLine([]byte("ERR " + message))
+}
+
+func (c *ipcConn) writeResponse(id json.RawMessage, reply ipcReply) error {
+	data, err := json.Marshal(reply.response(id))
+	if err != nil {
+		return err
+	}
+	return c.writeLine(data)
 }
 
-func parseIPCCommand(cmd string) (string, error) {
-	cmd = strings.TrimSpace(strings.ToUpper(cmd))
-	if cmd == "" {
-		return "", errors.New("empty command")
+// response converts the reply into a JSON-lines Response.
+func (r ipcReply) response(id json.RawMessage) ipc.Response {
+	resp := ipc.Response{V: ipc.ProtocolVersion, ID: id, OK: r.ok}
+	if !r.ok {
+		code := r.code
+		if code == "" {
+			code = ipc.ErrInternal
+		}
+		resp.Error = &ipc.Error{Code: code, Message: strings.TrimSpace(r.err)}
+		return resp
+	}
+
+	var value any
+	switch {
+	case r.value != nil:
+		value = r.value
+	case strings.TrimSpace(r.data) != "":
+		value = r.data
+	default:
+		return resp
+	}
+	if data, err := json.Marshal(value); err == nil {
+		resp.Data = data
 	}
-	return cmd, nil
+	return resp
 }
@@ -1,74 +1,20 @@
 package ui
 
-import "testing"
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 
-func TestParseIPCCommand(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-		wantErr  bool
-	}{
-		// Valid commands
-		{"simple command", "play", "PLAY", false},
-		{"uppercase command", "STOP", "STOP", false},
-		{"mixed case", "PlAy", "PLAY", false},
-		{"with leading space", "  play", "PLAY", false},
-		{"with trailing space", "stop  ", "STOP", false},
-		{"with both spaces", "  toggle  ", "TOGGLE", false},
-
-		// Invalid commands
-		{"empty string", "", "", true},
-		{"whitespace only", "   ", "", true},
-		{"tabs only", "\t\t", "", true},
-		{"newlines only", "\n\n", "", true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result, err := parseIPCCommand(tt.input)
-
-			if tt.wantErr {
-				if err == nil {
-					t.Error("parseIPCCommand() should return error")
-				}
-				return
-			}
-
-			if err != nil {
-				t.Fatalf("parseIPCCommand() error = %v", err)
-			}
-			if result != tt.expected {
-				t.Errorf("parseIPCCommand(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestParseIPCCommand_CommonCommands(t *testing.T) {
-	// Test common IPC commands that the app might receive
-	commands := []string{
-		"PLAY",
-		"STOP",
-		"TOGGLE",
-		"NEXT",
-		"PREV",
-		"STATUS",
-		"QUIT",
-	}
-
-	for _, cmd := range commands {
-		t.Run(cmd, func(t *testing.T) {
-			result, err := parseIPCCommand(cmd)
-			if err != nil {
-				t.Fatalf("parseIPCCommand(%q) error = %v", cmd, err)
-			}
-			if result != cmd {
-				t.Errorf("parseIPCCommand(%q) = %q, want %q", cmd, result, cmd)
-			}
-		})
-	}
-}
+	"radio-tui/internal/ipc"
+)
 
 func TestIPCReply_Struct(t *testing.T) {
 	// Test ipcReply struct construction
@@ -115,12 +61,12 @@ func TestIPCMsg_Struct(t *testing.T) {
 	// Test ipcMsg struct construction
 	replyChan := make(chan ipcReply, 1)
 	msg := ipcMsg{
-		cmd:   "PLAY",
+		req:   ipc.Request{Cmd: "PLAY"},
 		reply: replyChan,
 	}
 
-	if msg.cmd != "PLAY" {
-		t.Errorf("msg.cmd = %q, want %q", msg.cmd, "PLAY")
+	if msg.req.Cmd != "PLAY" {
+		t.Errorf("msg.req.Cmd = %q, want %q", msg.req.Cmd, "PLAY")
 	}
 
 	// Test channel works
@@ -136,3 +82,352 @@ func TestIPCMsg_Struct(t *testing.T) {
 		t.Errorf("reply.data = %q, want %q", reply.data, "Playing")
 	}
 }
+
+func TestIPCReply_Response(t *testing.T) {
+	resp := ipcReply{ok: true, value: ipc.Hello{Version: 1}}.response(json.RawMessage(`7`))
+	if !resp.OK || string(resp.ID) != "7" || string(resp.Data) != `{"version":1}` {
+		t.Errorf("response = %+v, want ok with id 7 and hello data", resp)
+	}
+
+	resp = ipcReply{ok: true, data: "QUEUED"}.response(nil)
+	if string(resp.Data) != `"QUEUED"` {
+		t.Errorf("Data = %s, want the text reply as a string", resp.Data)
+	}
+
+	resp = ipcError(ipc.ErrNotFound, "no station selected").response(nil)
+	if resp.OK || resp.Error == nil || resp.Error.Code != ipc.ErrNotFound {
+		t.Errorf("response = %+v, want not_found error", resp)
+	}
+
+	resp = ipcReply{ok: false, err: "boom"}.response(nil)
+	if resp.Error == nil || resp.Error.Code != ipc.ErrInternal {
+		t.Errorf("response = %+v, want internal error code by default", resp)
+	}
+}
+
+// startTestIPCServer serves a temporary socket, answering every request with
+// its command name.
+func startTestIPCServer(t *testing.T) ipc.Endpoint {
+	_, ep := startTestIPCServerWith(t)
+	return ep
+}
+
+func startTestIPCServerWith(t *testing.T) (*ipcServer, ipc.Endpoint) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets only")
+	}
+	ep := ipc.Endpoint{Network: "unix", Address: filepath.Join(t.TempDir(), "ctl.sock"), Token: "test-token"}
+	listener, err := net.Listen(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := &ipcServer{
+		endpoint: ep,
+		listener: listener,
+		messages: make(chan ipcMsg, 8),
+		done:     make(chan struct{}),
+		subs:     map[*ipcSubscriber]struct{}{},
+	}
+	go server.acceptLoop()
+	go func() {
+		for {
+			select {
+			case msg := <-server.messages:
+				if msg.req.Cmd == "FAIL" {
+					msg.reply <- ipcError(ipc.ErrNotFound, "nothing here")
+					continue
+				}
+				msg.reply <- ipcReply{ok: true, data: msg.req.Cmd}
+			case <-server.done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(server.Close)
+	return server, ep
+}
+
+func TestIPCServer_CloseConcurrently(t *testing.T) {
+	server, _ := startTestIPCServerWith(t)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server.Close()
+		}()
+	}
+	wg.Wait()
+	select {
+	case <-server.done:
+	default:
+		t.Error("Close left the server running")
+	}
+}
+
+func TestIPCServer_JSONLinesConnection(t *testing.T) {
+	ep := startTestIPCServer(t)
+	conn, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	// Several requests share one connection and keep their IDs.
+	for i, cmd := range []string{"status", "next", "FAIL"} {
+		fmt.Fprintf(conn, "{\"v\":1,\"id\":%d,\"token\":%q,\"cmd\":%q}\n", i+1, ep.Token, cmd)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read reply %d: %v", i+1, err)
+		}
+		var resp ipc.Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("reply %q is not JSON: %v", line, err)
+		}
+		if string(resp.ID) != fmt.Sprint(i+1) {
+			t.Errorf("reply id = %s, want %d", resp.ID, i+1)
+		}
+		if cmd == "FAIL" {
+			if resp.OK || resp.Error == nil || resp.Error.Code != ipc.ErrNotFound {
+				t.Errorf("FAIL reply = %s, want not_found", line)
+			}
+			continue
+		}
+		if want := fmt.Sprintf("%q", strings.ToUpper(cmd)); !resp.OK || string(resp.Data) != want {
+			t.Errorf("reply = %s, want ok with data %s", line, want)
+		}
+	}
+
+	fmt.Fprintln(conn, `{"v":99,"id":"x","token":"test-token","cmd":"STATUS"}`)
+	line, _ := reader.ReadString('\n')
+	if !strings.Contains(line, string(ipc.ErrUnsupportedVersion)) || !strings.Contains(line, `"id":"x"`) {
+		t.Errorf("future version reply = %q, want unsupported_version for id x", line)
+	}
+}
+
+func TestIPCServer_LegacyCommand(t *testing.T) {
+	ep := startTestIPCServer(t)
+	conn, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, "AUTH test-token ping")
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read reply: %v", err)
+	}
+	if strings.TrimSpace(line) != "PING" {
+		t.Errorf("legacy reply = %q, want PING", line)
+	}
+}
+
+func TestIPCServer_RequiresToken(t *testing.T) {
+	ep := startTestIPCServer(t)
+	conn, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for _, line := range []string{
+		`{"v":1,"id":1,"cmd":"QUIT"}`,
+		`{"v":1,"id":2,"token":"guess","cmd":"QUIT"}`,
+	} {
+		fmt.Fprintln(conn, line)
+		reply, _ := reader.ReadString('\n')
+		if !strings.Contains(reply, string(ipc.ErrUnauthorized)) {
+			t.Errorf("reply to %s = %q, want unauthorized", line, reply)
+		}
+	}
+
+	legacy, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer legacy.Close()
+	fmt.Fprintln(legacy, "AUTH guess QUIT")
+	if reply, _ := bufio.NewReader(legacy).ReadString('\n'); !strings.HasPrefix(reply, "ERR") {
+		t.Errorf("legacy reply with a wrong token = %q, want ERR", reply)
+	}
+}
+
+// sendLegacy sends one plain verb line without a token, as scripts did
+// before tokens, and returns the reply.
+func sendLegacy(t *testing.T, ep ipc.Endpoint, line string) string {
+	t.Helper()
+	conn, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintln(conn, line)
+	reply, _ := bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSpace(reply)
+}
+
+func skipUncheckedPeers(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credentials are not checked on " + runtime.GOOS)
+	}
+}
+
+func TestParseIPCCommand(t *testing.T) {
+	skipUncheckedPeers(t)
+	ep := startTestIPCServer(t)
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		// Valid commands
+		{"simple command", "play", "PLAY", false},
+		{"uppercase command", "STOP", "STOP", false},
+		{"mixed case", "PlAy", "PLAY", false},
+		{"with leading space", "  play", "PLAY", false},
+		{"with trailing space", "stop  ", "STOP", false},
+		{"with both spaces", "  next  ", "NEXT", false},
+
+		// Invalid commands
+		{"whitespace only", "   ", "", true},
+		{"tabs only", "\t\t", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := sendLegacy(t, ep, tt.input)
+			if tt.wantErr {
+				if !strings.HasPrefix(reply, "ERR") {
+					t.Errorf("reply to %q = %q, want ERR", tt.input, reply)
+				}
+				return
+			}
+			if reply != tt.expected {
+				t.Errorf("reply to %q = %q, want %q", tt.input, reply, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseIPCCommand_CommonCommands(t *testing.T) {
+	skipUncheckedPeers(t)
+	ep := startTestIPCServer(t)
+	// Plain verbs that scripts and key bindings have always sent.
+	commands := []string{"PLAY", "STOP", "PLAY_PAUSE", "NEXT", "PREV", "STATUS", "QUIT"}
+
+	for _, cmd := range commands {
+		t.Run(cmd, func(t *testing.T) {
+			if reply := sendLegacy(t, ep, cmd); reply != cmd {
+				t.Errorf("reply to %q = %q, want %q", cmd, reply, cmd)
+			}
+		})
+	}
+}
+
+func TestIPCServer_ClientCall(t *testing.T) {
+	ep := startTestIPCServer(t)
+	client, err := ipc.DialEndpoint(ep)
+	if err != nil {
+		t.Fatalf("DialEndpoint() error = %v", err)
+	}
+	defer client.Close()
+
+	var data string
+	if err := client.Call(ipc.CmdStatus, nil, &data); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if data != ipc.CmdStatus {
+		t.Errorf("Call() data = %q, want STATUS", data)
+	}
+
+	err = client.Call("FAIL", nil, nil)
+	var protoErr *ipc.Error
+	if !errors.As(err, &protoErr) || protoErr.Code != ipc.ErrNotFound {
+		t.Errorf("Call(FAIL) error = %v, want not_found", err)
+	}
+}
+
+func TestIPCServer_SubscribePushesEvents(t *testing.T) {
+	server, ep := startTestIPCServerWith(t)
+	sub, err := ipc.SubscribeEndpoint(ep, ipc.EventTitle)
+	if err != nil {
+		t.Fatalf("SubscribeEndpoint() error = %v", err)
+	}
+	defer sub.Close()
+
+	server.publish(ipc.EventPlayState, ipc.Status{Playing: true})
+	server.publish(ipc.EventTitle, ipc.TitleEvent{UUID: "abc", Title: "Artist - Song"})
+
+	select {
+	case event := <-sub.Events():
+		if event.Event != ipc.EventTitle {
+			t.Fatalf("event = %q, want only the subscribed title event", event.Event)
+		}
+		var title ipc.TitleEvent
+		if err := json.Unmarshal(event.Data, &title); err != nil || title.Title != "Artist - Song" {
+			t.Errorf("title event = %s, want Artist - Song", event.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event pushed")
+	}
+}
+
+func TestIPCServer_SubscribeRejectsUnknownEvent(t *testing.T) {
+	ep := startTestIPCServer(t)
+	_, err := ipc.SubscribeEndpoint(ep, "weather")
+	var protoErr *ipc.Error
+	if !errors.As(err, &protoErr) || protoErr.Code != ipc.ErrBadRequest {
+		t.Errorf("SubscribeEndpoint(weather) error = %v, want bad_request", err)
+	}
+}
+
+func TestIPCServer_LegacySubscribeKeepsConnection(t *testing.T) {
+	server, ep := startTestIPCServerWith(t)
+	conn, err := net.Dial(ep.Network, ep.Address)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprintln(conn, "AUTH test-token SUBSCRIBE station")
+	if line, _ := reader.ReadString('\n'); strings.TrimSpace(line) != "OK" {
+		t.Fatalf("SUBSCRIBE reply = %q, want OK", line)
+	}
+	server.publish(ipc.EventStation, ipc.Status{Station: "Test FM"})
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.Contains(line, `"event":"station"`) || !strings.Contains(line, "Test FM") {
+		t.Errorf("pushed line = %q (err %v), want a station event", line, err)
+	}
+}
+
+func TestIPCSubscriber_DropsOldestWhenFull(t *testing.T) {
+	sub := &ipcSubscriber{queue: make(chan []byte, 2)}
+	for _, line := range []string{"a", "b", "c", "d"} {
+		sub.push([]byte(line))
+	}
+	if got := sub.dropped.Load(); got != 2 {
+		t.Errorf("dropped = %d, want 2", got)
+	}
+	if first := string(<-sub.queue); first != "c" {
+		t.Errorf("oldest queued = %q, want c", first)
+	}
+}
+
+func TestIPCServer_InProcessCall(t *testing.T) {
+	server, _ := startTestIPCServerWith(t)
+	if err := server.call(ipc.CmdNext, nil); err != nil {
+		t.Errorf("call(NEXT) error = %v", err)
+	}
+	err := server.call("FAIL", nil)
+	var protoErr *ipc.Error
+	if !errors.As(err, &protoErr) || protoErr.Code != ipc.ErrNotFound {
+		t.Errorf("call(FAIL) error = %v, want not_found", err)
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"radio-tui/internal/config"
+)
+
+// keyMap translates pressed keys into the default keys the handler knows.
+// A default key given up by a rebound action maps to "".
+type keyMap map[string]string
+
+func newKeyMap(bindings map[string][]string) keyMap {
+	keys := keyMap{}
+	for action := range bindings {
+		for _, key := range config.DefaultKeys[action] {
+			keys[key] = ""
+		}
+	}
+	for action, bound := range bindings {
+		defaults, ok := config.DefaultKeys[action]
+		if !ok {
+			continue
+		}
+		for _, key := range bound {
+			keys[key] = defaults[0]
+		}
+	}
+	return keys
+}
+
+// resolve returns the default key that key stands for.
+func (k keyMap) resolve(key string) string {
+	if to, ok := k[key]; ok {
+		return to
+	}
+	return key
+}
+
+// reboundHelp lists the rebound actions for the help screen.
+func reboundHelp(bindings map[string][]string) []string {
+	var lines []string
+	for action, bound := range bindings {
+		if _, ok := config.DefaultKeys[action]; !ok || len(bound) == 0 {
+			continue
+		}
+		names := make([]string, len(bound))
+		for i, key := range bound {
+			names[i] = strings.ReplaceAll(key, " ", "space")
+		}
+		lines = append(lines, padRight(strings.Join(names, " "), 13)+keyLabel(action))
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func keyLabel(action string) string {
+	return strings.ReplaceAll(action, "_", " ")
+}
+
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
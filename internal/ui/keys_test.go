@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyMap_Rebinds(t *testing.T) {
+	keys := newKeyMap(map[string][]string{"favorite": {"x", "ctrl+f"}, "quit": {"ctrl+q"}})
+	for key, want := range map[string]string{"x": "f", "ctrl+f": "f", "f": "", "F": "", "q": "", "ctrl+q": "q", "t": "t"} {
+		if got := keys.resolve(key); got != want {
+			t.Errorf("resolve(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestUpdate_UsesReboundKeys(t *testing.T) {
+	m := createTestModel()
+	m.config.Keys = map[string][]string{"theme": {"x"}}
+	m.keys = newKeyMap(m.config.Keys)
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	if next.(Model).showTheme {
+		t.Error("t still opens the theme picker")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if !next.(Model).showTheme {
+		t.Error("x does not open the theme picker")
+	}
+}
@@ -5,13 +5,21 @@ import (
 	"fmt"
 	"math"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"radio-tui/internal/bookmarks"
 	"radio-tui/internal/config"
+	"radio-tui/internal/history"
+	"radio-tui/internal/hooks"
+	"radio-tui/internal/httpapi"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/mpris"
+	"radio-tui/internal/notify"
 	"radio-tui/internal/player"
 	"radio-tui/internal/radio"
 )
@@ -25,13 +33,23 @@ const (
 	inputLocation
 	inputSearch
 	inputCountrySelect
+)
 
-	stationPageSize = 200
+const (
+	// tuningCaptureMHz and tuningCaptureIndex are how far the dial pointer
+	// may drift from a station before only static remains, on frequency and
+	// index dials respectively.
+	tuningCaptureMHz   = 0.5
+	tuningCaptureIndex = 0.5
+
+	// tuningFadeDuration glides the station level between dial frames.
+	tuningFadeDuration = 30 * time.Millisecond
 )
 
 const (
 	sourceCountry stationSource = iota
 	sourceFavorites
+	sourceRecent // stations from the listening history, latest first
 )
 
 type Model struct {
@@ -41,6 +59,29 @@ type Model struct {
 	favorites *config.Favorites
 	styles    Styles
 	ipc       *ipcServer
+	mpris     *mpris.Server
+	web       *httpapi.Server
+	notifier  *notify.Service
+	hooks     *hooks.Runner
+	mode      Mode
+
+	daemonLost   bool
+	favoritesRev int // bumped whenever favorites change, for subscribers
+	watcher      *config.Watcher
+	keepSelected string // station to select again once the list reloads
+
+	config    config.AppConfig // the settings last loaded, with defaults filled in
+	keys      keyMap
+	stateFile *config.StateFile
+
+	history   *history.Log
+	listening history.Entry // the station playing since listening.Start; empty when stopped
+
+	showStats bool
+	statsTab  int
+	stats     [statsTabCount][]history.Total
+
+	startupPlay *ipc.PlayArgs // played once the first station list arrives
 
 	stations []radio.Station
 	selected int
@@ -48,12 +89,16 @@ type Model struct {
 	loading bool
 	errMsg  string
 
+	playError    string // the last playback or stream error, for error hooks
+	playFailures int    // counts playback errors, so that a repeated one fires again
+
 	country string
 	page    int
 	hasMore bool
 
 	stationSource stationSource
-	activeSearch string
+	activeSearch  string
+	favGroup      string // the favorites group shown; empty shows all favorites
 
 	inputMode     inputMode
 	location      textinput.Model
@@ -65,12 +110,62 @@ type Model struct {
 	themeIdx  int
 	theme     Theme
 
+	httpConfig   config.HTTPConfig
+	notifyConfig config.NotificationsConfig
+	hookConfig   []config.Hook
+
+	showAudio    bool
+	audio        config.AudioConfig
+	audioDraft   config.AudioConfig
+	audioField   int
+	audioDevices []player.Device // the outputs the external player lists
+
+	showSleep bool
+	sleepIdx  int
+	sleepAt   time.Time
+
+	showGroups    bool
+	groupIdx      int
+	groupEditing  bool
+	groupRenaming string // the group being renamed; empty while naming a new one
+	groupName     textinput.Model
+	groupStation  radio.Station // the station Space puts in or takes out of a group
+
+	bookmarks      *bookmarks.Store
+	showBookmarks  bool
+	bookmarkIdx    int
+	bookmarkSearch textinput.Model
+	bookmarkExport textinput.Model // the file X exports to, focused while asking
+	exportReplace  string          // the existing file a second Enter replaces
+
+	showCustom   bool
+	customUUID   string // the custom station being edited; empty while adding one
+	customFields []textinput.Model
+	customField  int
+
+	showAlarms      bool
+	alarms          []config.Alarm
+	alarmIdx        int
+	alarmEditing    bool
+	alarmTime       textinput.Model
+	alarmStationIdx int
+	alarmRampAt     time.Time
+	lastClock       time.Time
+
+	volumeCut int  // percent taken off the volume; zero plays at full level
+	muted     bool // silences output without losing the volume
+
 	width  int
 	height int
 
+	shutDown bool // shutdown has run
+
 	playing           bool
 	playingUUID       string
+	playingURL        string // the stream playingUUID plays
 	lastStation       radio.Station
+	recent            []radio.Station // most recently played first
+	nowPlaying        string
 	missingPlayer     bool
 	downloadingPlayer bool
 
@@ -92,6 +187,7 @@ type stationsMsg struct {
 	page     int
 	country  string
 	search   string
+	group    string
 	hasMore  bool
 	err      error
 }
@@ -116,7 +212,10 @@ type playerDownloadMsg struct {
 
 type themeSavedMsg struct{ err error }
 
-func NewModel(api *radio.Client, p player.Backend, favorites *config.Favorites, playerErr error, favErr error, themeName string) Model {
+// favoritesChangedMsg reports favorites edited outside the key handler.
+type favoritesChangedMsg struct{}
+
+func NewModel(api *radio.Client, p player.Backend, favorites *config.Favorites, playerErr error, favErr error, cfg config.AppConfig) Model {
 	location := textinput.New()
 	location.Prompt = "Country: "
 	location.Placeholder = "US"
@@ -133,7 +232,21 @@ func NewModel(api *radio.Client, p player.Backend, favorites *config.Favorites,
 	countrySearch.Placeholder = "Type country or code"
 	countrySearch.Width = 26
 
-	theme := ThemeBySlug(themeName)
+	alarmTime := textinput.New()
+	alarmTime.Prompt = "Time: "
+	alarmTime.Placeholder = "07:00"
+	alarmTime.CharLimit = 5
+	alarmTime.Width = 6
+
+	groupName := textinput.New()
+	groupName.Prompt = "Name: "
+	groupName.Placeholder = "Work focus"
+	groupName.CharLimit = 40
+	groupName.Width = 24
+
+	given := cfg
+	cfg = cfg.WithDefaults()
+	theme := ThemeBySlug(cfg.Theme)
 	themeIdx := 0
 	for i, t := range Themes {
 		if t.Slug == theme.Slug {
@@ -150,16 +263,54 @@ func NewModel(api *radio.Client, p player.Backend, favorites *config.Favorites,
 		styles:        BuildStyles(theme),
 		theme:         theme,
 		themeIdx:      themeIdx,
-		country:       "US",
+		audio:         cfg.Audio,
+		httpConfig:    cfg.HTTP,
+		notifyConfig:  cfg.Notifications,
+		hookConfig:    cfg.Hooks,
+		alarms:        cfg.Alarms,
+		alarmTime:     alarmTime,
+		groupName:     groupName,
+		customFields:  newCustomFields(),
+		config:        cfg,
+		keys:          newKeyMap(cfg.Keys),
+		country:       cfg.Startup.Country,
 		stationSource: sourceCountry,
+		volumeCut:     100 - *cfg.Startup.Volume,
 		location:      location,
 		search:        search,
 		countrySearch: countrySearch,
 		loading:       true,
 	}
-	if favorites != nil && favorites.Count() > 0 {
+	switch {
+	case cfg.Startup.Source == "recent":
+		m.stationSource = sourceRecent
+	case cfg.Startup.Source == "favorites" && favorites != nil && favorites.Count() > 0:
 		m.stationSource = sourceFavorites
 	}
+	if !*cfg.UI.TuningStatic {
+		m.noise = nil
+	}
+	if cfg.Startup.Station != "" && *cfg.Startup.Autoplay {
+		m.startupPlay = m.startupTarget(cfg.Startup.Station)
+	}
+	if len(cfg.Hooks) > 0 {
+		m.hooks = hooks.New(cfg.Hooks)
+	}
+	if watcher, err := config.NewWatcher(); err == nil {
+		m.watcher = watcher
+	}
+	if log, err := history.Open(); err == nil {
+		m.history = log
+	}
+	m.bookmarkSearch = newBookmarkSearch()
+	m.bookmarkExport = newBookmarkExport()
+	if store, err := bookmarks.Open(); err == nil {
+		m.bookmarks = store
+	}
+	if stateFile, err := config.LoadState(); err == nil {
+		m.stateFile = stateFile
+		m.restoreState(stateFile.State(), given)
+	}
 
 	if playerErr != nil {
 		m.missingPlayer = true
@@ -182,11 +333,25 @@ func NewModel(api *radio.Client, p player.Backend, favorites *config.Favorites,
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.mode == ModeAttached {
+		return tea.Batch(m.loadStationsCmd(), m.remoteStatusCmd(), m.clockTickCmd(), m.fileCheckCmd())
+	}
 	m.noise.Start()
-	return tea.Batch(m.loadStationsCmd(), m.startIPCCmd(), m.maybeDownloadPlayerCmd())
+	return tea.Batch(m.loadStationsCmd(), m.startIPCCmd(), m.startNotifierCmd(), m.listenHooksCmd(), m.maybeDownloadPlayerCmd(), m.clockTickCmd(), m.fileCheckCmd())
 }
 
+// Update applies msg and pushes the resulting changes to IPC subscribers.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if updated, ok := next.(Model); ok {
+		logCmd := updated.recordListening(time.Now())
+		updated.publishChanges(m)
+		return updated, tea.Batch(cmd, logCmd)
+	}
+	return next, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -196,19 +361,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		key := msg.String()
 
-		if key == "ctrl+c" || key == "q" {
-			if m.player != nil {
-				_ = m.player.Stop()
-			}
-			m.noise.Stop()
-			if m.ipc != nil {
-				m.ipc.Close()
-			}
+		if key == "ctrl+c" || m.keys.resolve(key) == "q" {
+			m.shutdown()
 			return m, tea.Quit
 		}
 
 		if m.showHelp {
-			if key == "?" || key == "esc" || key == "enter" {
+			if m.keys.resolve(key) == "?" || key == "esc" || key == "enter" {
 				m.showHelp = false
 			}
 			return m, nil
@@ -237,6 +396,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.showAudio {
+			return m.updateAudioSettings(key)
+		}
+
+		if m.showSleep {
+			return m.updateSleepDialog(key)
+		}
+
+		if m.showAlarms {
+			return m.updateAlarmDialog(msg)
+		}
+
+		if m.showGroups {
+			return m.updateGroupDialog(msg)
+		}
+
+		if m.showCustom {
+			return m.updateCustomDialog(msg)
+		}
+
+		if m.showStats {
+			return m.updateStats(key)
+		}
+
+		if m.showBookmarks {
+			return m.updateBookmarks(msg)
+		}
+
 		switch m.inputMode {
 		case inputLocation:
 			return m.updateLocationInput(msg)
@@ -246,7 +433,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateCountrySelect(msg)
 		}
 
-		switch key {
+		switch key := m.keys.resolve(key); key {
 		case "?":
 			m.showHelp = true
 		case "left":
@@ -265,6 +452,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.moveSelection(1) {
 				return m, m.dialTickCmd()
 			}
+		case "shift+up", "K":
+			return m, m.moveFavorite(-1)
+		case "shift+down", "J":
+			return m, m.moveFavorite(1)
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			cmd, reply := m.playPreset(int(key[0] - '0'))
+			if !reply.ok {
+				m.errMsg = reply.err
+			}
+			return m, cmd
 		case "]", "pgdown":
 			if m.loading {
 				return m, nil
@@ -301,11 +498,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case " ":
 			if m.playing {
-				if m.player != nil {
-					_ = m.player.Stop()
-				}
-				m.playing = false
-				return m, nil
+				return m, m.stopPlayback()
 			}
 			if m.lastStation.UUID != "" {
 				m.noise.Start()
@@ -352,9 +545,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.errMsg = ""
 			m.noise.Start()
 			return m, m.loadStationsCmd()
+		case "r", "R":
+			return m, m.toggleRecent()
+		case "s", "S":
+			return m, m.openStats()
+		case "b":
+			return m, m.bookmarkSong()
+		case "B":
+			return m.openBookmarks()
 		case "/":
-			// If in favorites view, switch to full station list before searching
-			if m.stationSource == sourceFavorites {
+			// If in favorites or recent view, switch to full station list before searching
+			if m.stationSource != sourceCountry {
 				m.stationSource = sourceCountry
 				m.activeSearch = ""
 			}
@@ -365,6 +566,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, textinput.Blink
 		case "t", "T":
 			m.showTheme = true
+		case "a", "A":
+			m.showAudio = true
+			m.audioDraft = m.audio
+			m.audioField = audioFieldSampleRate
+			return m, m.loadAudioDevicesCmd()
+		case "z", "Z":
+			m.showSleep = true
+			m.sleepIdx = 0
+		case "g", "G":
+			return m.openGroupDialog()
+		case "c", "C":
+			return m.openCustomDialog(nil)
+		case "e", "E":
+			return m.editCustomStation()
+		case "tab":
+			return m, m.cycleGroup(1)
+		case "shift+tab":
+			return m, m.cycleGroup(-1)
+		case "w", "W":
+			m.showAlarms = true
+			m.alarmIdx = 0
+			m.alarmEditing = false
 		case "f", "F":
 			if m.favorites != nil {
 				if station, ok := m.currentStation(); ok {
@@ -373,19 +596,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.errMsg = err.Error()
 						return m, nil
 					}
-					if m.stationSource == sourceFavorites {
-						m.page = 0
-						m.hasMore = false
-						m.selected = 0
-						m.loading = true
-						m.noise.Start()
-						return m, m.loadStationsCmd()
-					}
+					return m, tea.Batch(m.favoritesChanged(), m.reloadDaemonCmd())
 				}
 			}
 		}
 	case stationsMsg:
-		if msg.source != m.stationSource || msg.page != m.page || msg.country != m.country || msg.search != m.activeSearch {
+		if msg.source != m.stationSource || msg.page != m.page || msg.country != m.country || msg.search != m.activeSearch || msg.group != m.listGroup() {
 			return m, nil
 		}
 		m.loading = false
@@ -395,23 +611,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.stations = nil
 			m.hasMore = false
 			m.selected = 0
-			return m, nil
+			m.keepSelected = ""
+			return m, m.playStartup()
 		}
 		m.errMsg = ""
 		m.stations = msg.stations
 		m.hasMore = msg.hasMore
-		m.selected = 0
-		m.ensureSelection()
+		m.selectKept()
 		m.updateDialRange()
 		m.snapDial()
-		return m, nil
+		return m, m.playStartup()
 	case ipcReadyMsg:
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			return m, nil
 		}
 		m.ipc = msg.server
-		return m, m.listenIPCCmd()
+		return m, tea.Batch(m.listenIPCCmd(), m.startMPRISCmd(), m.startWebCmd())
+	case webReadyMsg:
+		if msg.err != nil {
+			m.errMsg = "Web API: " + msg.err.Error()
+			return m, nil
+		}
+		m.web = msg.server
+		return m, nil
+	case hookFailedMsg:
+		m.errMsg = msg.err.Error()
+		return m, m.listenHooksCmd()
+	case notifierReadyMsg:
+		if msg.err != nil {
+			m.errMsg = "Notifications unavailable: " + msg.err.Error()
+			return m, nil
+		}
+		m.notifier = msg.service
+		return m, nil
+	case mprisReadyMsg:
+		m.mpris = msg.server
+		m.mpris.Update(m.mprisState())
+		return m, nil
 	case ipcMsg:
 		return m.handleIPC(msg)
 	case ipcClosedMsg:
@@ -423,7 +660,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.errMsg = "Failed to download ffplay: " + msg.err.Error() + " (install mpv or ffplay and ensure it is in PATH)"
 			return m, nil
 		}
-		p, err := player.New()
+		p, err := player.NewWith(m.config.Player.Backend, m.config.Player.MPVPath)
 		if err != nil {
 			m.errMsg = "Audio player not available: " + err.Error()
 			return m, nil
@@ -450,28 +687,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case playMsg:
 		if msg.err != nil {
 			m.noise.Stop()
-			m.errMsg = msg.err.Error()
+			m.playbackFailed(msg.err.Error())
 			return m, nil
 		}
 		if m.player == nil {
 			m.noise.Stop()
 			if m.downloadingPlayer {
-				m.errMsg = "Audio player not available yet. Downloading ffplay..."
+				m.playbackFailed("Audio player not available yet. Downloading ffplay...")
 			} else {
-				m.errMsg = "Audio player not available. Install mpv or ffplay and ensure it is in PATH."
+				m.playbackFailed("Audio player not available. Install mpv or ffplay and ensure it is in PATH.")
 			}
 			return m, nil
 		}
 		if err := m.player.Play(msg.url); err != nil {
 			m.noise.Stop()
-			m.errMsg = err.Error()
+			m.playbackFailed(err.Error())
 			return m, nil
 		}
 		m.noise.Stop()
-		m.errMsg = ""
 		m.playing = true
 		m.playingUUID = msg.station.UUID
+		m.playingURL = msg.url
 		m.lastStation = msg.station
+		m.rememberRecent(msg.station)
+		m.nowPlaying = ""
+		m.errMsg = m.fadeNotice()
 		return m, nil
 	case dialTickMsg:
 		return m.updateDialAnimation()
@@ -480,6 +720,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.errMsg = "Failed to save theme: " + msg.err.Error()
 		}
 		return m, nil
+	case audioDevicesMsg:
+		return m.handleAudioDevices(msg)
+	case audioSavedMsg:
+		return m.handleAudioSaved(msg)
+	case clockTickMsg:
+		return m.handleClockTick(msg.at)
+	case historySavedMsg:
+		if msg.err != nil {
+			m.errMsg = "Failed to save listening history: " + msg.err.Error()
+		}
+		return m, nil
+	case stateSavedMsg:
+		if msg.err != nil {
+			m.errMsg = "Failed to save session state: " + msg.err.Error()
+		}
+		return m, nil
+	case statsMsg:
+		return m.handleStats(msg)
+	case bookmarkMsg:
+		return m.handleBookmark(msg)
+	case favoritesChangedMsg:
+		return m, m.favoritesChanged()
+	case fileCheckMsg:
+		return m, m.fileCheckCmd()
+	case filesReloadedMsg:
+		return m.handleFilesReloaded(msg)
+	case alarmsSavedMsg:
+		if msg.err != nil {
+			m.errMsg = "Failed to save alarms: " + msg.err.Error()
+		}
+		return m, nil
+	case remoteReplyMsg:
+		return m.handleRemoteReply(msg)
+	case remoteStatusMsg:
+		return m.handleRemoteStatus(msg)
 	}
 
 	return m, nil
@@ -493,6 +768,14 @@ func (m Model) saveThemeCmd() tea.Cmd {
 	}
 }
 
+// pageSize is the number of stations listed per page.
+func (m Model) pageSize() int {
+	if m.config.UI.PageSize > 0 {
+		return m.config.UI.PageSize
+	}
+	return config.DefaultPageSize
+}
+
 func (m Model) loadStationsCmd() tea.Cmd {
 	source := m.stationSource
 	country := m.country
@@ -500,10 +783,24 @@ func (m Model) loadStationsCmd() tea.Cmd {
 	page := m.page
 	api := m.api
 	favorites := m.favorites
+	group := m.listGroup()
+	pageSize := m.pageSize()
+	recent, log := m.recent, m.history
 	return func() tea.Msg {
-		if source == sourceFavorites {
+		if source == sourceFavorites || source == sourceRecent {
 			all := []radio.Station{}
-			if favorites != nil {
+			if source == sourceRecent {
+				var err error
+				if all, err = recentStations(recent, log); err != nil {
+					return stationsMsg{err: err, source: source, page: page, country: country, search: search}
+				}
+			} else if favorites != nil && group != "" {
+				favs, err := favorites.ListGroup(group)
+				if err != nil {
+					return stationsMsg{err: err, source: source, page: page, country: country, search: search, group: group}
+				}
+				all = favoritesToStations(favs)
+			} else if favorites != nil {
 				all = favoritesToStations(favorites.List())
 			}
 
@@ -521,7 +818,7 @@ func (m Model) loadStationsCmd() tea.Cmd {
 				all = filtered
 			}
 
-			offset := page * stationPageSize
+			offset := page * pageSize
 			if offset < 0 {
 				offset = 0
 			}
@@ -532,11 +829,12 @@ func (m Model) loadStationsCmd() tea.Cmd {
 					page:     page,
 					country:  country,
 					search:   search,
+					group:    group,
 					hasMore:  false,
 				}
 			}
 
-			end := offset + stationPageSize
+			end := offset + pageSize
 			hasMore := false
 			if end < len(all) {
 				hasMore = true
@@ -550,6 +848,7 @@ func (m Model) loadStationsCmd() tea.Cmd {
 				page:     page,
 				country:  country,
 				search:   search,
+				group:    group,
 				hasMore:  hasMore,
 			}
 		}
@@ -557,8 +856,8 @@ func (m Model) loadStationsCmd() tea.Cmd {
 		if api == nil {
 			return stationsMsg{err: fmt.Errorf("radio api not available"), source: source}
 		}
-		offset := page * stationPageSize
-		limit := stationPageSize + 1
+		offset := page * pageSize
+		limit := pageSize + 1
 
 		var (
 			stations []radio.Station
@@ -579,9 +878,9 @@ func (m Model) loadStationsCmd() tea.Cmd {
 			}
 		}
 
-		hasMore := len(stations) > stationPageSize
+		hasMore := len(stations) > pageSize
 		if hasMore {
-			stations = stations[:stationPageSize]
+			stations = stations[:pageSize]
 		}
 		return stationsMsg{
 			stations: stations,
@@ -595,6 +894,12 @@ func (m Model) loadStationsCmd() tea.Cmd {
 }
 
 func (m Model) playStationCmd(station radio.Station) tea.Cmd {
+	if m.mode == ModeAttached {
+		return m.remoteCmd(ipc.CmdPlay, ipc.PlayArgs{UUID: station.UUID})
+	}
+	if config.IsCustom(station.UUID) {
+		return m.playCustomCmd(station)
+	}
 	api := m.api
 	return func() tea.Msg {
 		if api == nil {
@@ -602,6 +907,12 @@ func (m Model) playStationCmd(station radio.Station) tea.Cmd {
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), 12*time.Second)
 		defer cancel()
+		if station.Name == "" {
+			// Stations requested by UUID alone over IPC.
+			if details, err := api.StationByUUID(ctx, station.UUID); err == nil {
+				station = details
+			}
+		}
 		streamURL, err := api.ResolveStationURL(ctx, station.UUID)
 		return playMsg{station: station, url: streamURL, err: err}
 	}
@@ -640,6 +951,24 @@ func (m Model) startIPCCmd() tea.Cmd {
 	}
 }
 
+type webReadyMsg struct {
+	server *httpapi.Server
+	err    error
+}
+
+// startWebCmd starts the web API when it is enabled, sharing the IPC
+// server's dispatcher.
+func (m Model) startWebCmd() tea.Cmd {
+	server, cfg := m.ipc, m.httpConfig
+	if server == nil || !cfg.Enabled || m.mode == ModeAttached {
+		return nil
+	}
+	return func() tea.Msg {
+		web, err := httpapi.Start(cfg, server)
+		return webReadyMsg{server: web, err: err}
+	}
+}
+
 func (m Model) listenIPCCmd() tea.Cmd {
 	if m.ipc == nil {
 		return nil
@@ -667,6 +996,7 @@ func (m Model) updateDialAnimation() (tea.Model, tea.Cmd) {
 	diff := m.dialTarget - m.dialPos
 	if math.Abs(diff) < 0.05 {
 		m.dialPos = m.dialTarget
+		m.applyTuningBlend()
 		return m, nil
 	}
 	step := math.Copysign(0.4, diff)
@@ -674,9 +1004,39 @@ func (m Model) updateDialAnimation() (tea.Model, tea.Cmd) {
 		step = diff
 	}
 	m.dialPos += step
+	m.applyTuningBlend()
 	return m, m.dialTickCmd()
 }
 
+// applyTuningBlend crossfades static and the playing station according to
+// how far the dial pointer is from a station, like an analog tuner.
+func (m *Model) applyTuningBlend() {
+	m.noise.Blend(m.tuningBlend())
+	m.applyOutputLevel(time.Now(), tuningFadeDuration)
+}
+
+// tuningBlend returns how detuned the dial pointer is, from 0 when it sits
+// on a station to 1 once it is at least half a channel away from all of them.
+func (m Model) tuningBlend() float64 {
+	list := m.visibleStations()
+	if len(list) == 0 {
+		return 0
+	}
+
+	nearest := math.MaxFloat64
+	for i := range list {
+		if dist := math.Abs(m.dialPos - m.dialValueForIndex(i)); dist < nearest {
+			nearest = dist
+		}
+	}
+
+	capture := tuningCaptureIndex
+	if m.dialUseFreq {
+		capture = tuningCaptureMHz
+	}
+	return math.Min(nearest/capture, 1)
+}
+
 func (m Model) updateLocationInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.location, cmd = m.location.Update(msg)
@@ -779,39 +1139,124 @@ func (m Model) updateCountrySelect(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleIPC(msg ipcMsg) (tea.Model, tea.Cmd) {
-	cmd, err := parseIPCCommand(msg.cmd)
-	if err != nil {
-		sendIPCReply(msg.reply, ipcReply{ok: false, err: err.Error()})
-		return m, m.listenIPCCmd()
-	}
-
+	req := msg.req
 	var reply ipcReply
 	var cmdTea tea.Cmd
 
-	switch cmd {
-	case "PLAY_PAUSE":
+	switch req.Cmd {
+	case ipc.CmdPlayPause:
 		cmdTea, reply = m.ipcPlayPause()
-	case "NEXT":
+	case ipc.CmdNext:
 		cmdTea, reply = m.ipcSelectAndPlay(1)
-	case "PREV":
+	case ipc.CmdPrev:
 		cmdTea, reply = m.ipcSelectAndPlay(-1)
-	case "QUIT":
+	case ipc.CmdPlay:
+		var args ipc.PlayArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
+		}
+		cmdTea, reply = m.ipcPlay(args)
+	case ipc.CmdStop:
+		cmdTea = m.stopPlayback()
 		reply = ipcReply{ok: true}
-		sendIPCReply(msg.reply, reply)
-		if m.player != nil {
-			_ = m.player.Stop()
+	case ipc.CmdSearch:
+		var args ipc.SearchArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
 		}
-		m.noise.Stop()
-		if m.ipc != nil {
-			m.ipc.Close()
+		// The search answers on its own once the directory replies.
+		return m, tea.Batch(m.ipcSearchCmd(args, msg.reply), m.listenIPCCmd())
+	case ipc.CmdFavorite:
+		var args ipc.FavoriteArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
 		}
+		// Adding may need the station's details from the directory first.
+		return m, tea.Batch(m.ipcFavoriteCmd(args, msg.reply), m.listenIPCCmd())
+	case ipc.CmdFavorites:
+		reply = m.ipcFavorites()
+	case ipc.CmdVolume:
+		if !req.HasArgs() {
+			reply = ipcReply{ok: true, data: strconv.Itoa(m.volume()), value: m.volumeStatus()}
+			break
+		}
+		var args ipc.VolumeArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
+		}
+		reply = m.ipcVolume(args)
+	case ipc.CmdMute:
+		var args *ipc.MuteArgs
+		if req.HasArgs() {
+			args = &ipc.MuteArgs{}
+			if err := req.DecodeArgs(args); err != nil {
+				reply = ipcFailure(err)
+				break
+			}
+		}
+		reply = m.ipcMute(args)
+	case ipc.CmdRecent:
+		reply = m.ipcRecent()
+	case ipc.CmdBookmark:
+		reply = m.ipcBookmark(time.Now())
+	case ipc.CmdPreset:
+		var args ipc.PresetArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
+		}
+		cmdTea, reply = m.playPreset(args.Number)
+	case ipc.CmdGroups:
+		reply = m.ipcGroups()
+	case ipc.CmdGroup:
+		var args *ipc.GroupArgs
+		if req.HasArgs() {
+			args = &ipc.GroupArgs{}
+			if err := req.DecodeArgs(args); err != nil {
+				reply = ipcFailure(err)
+				break
+			}
+		}
+		cmdTea, reply = m.ipcGroup(args)
+	case ipc.CmdReload:
+		reply = m.ipcReload()
+	case ipc.CmdQuit:
+		reply = ipcReply{ok: true}
+		sendIPCReply(msg.reply, reply)
+		m.shutdown()
 		return m, tea.Quit
-	case "STATUS":
-		reply = ipcReply{ok: true, data: m.ipcStatus()}
-	case "PING":
-		reply = ipcReply{ok: true, data: "OK"}
+	case ipc.CmdSleep:
+		if !req.HasArgs() {
+			reply = m.ipcSleepStatus()
+			break
+		}
+		var args ipc.SleepArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
+		}
+		reply = m.ipcSleep(args)
+	case ipc.CmdAlarm:
+		if !req.HasArgs() {
+			reply = m.ipcNextAlarm()
+			break
+		}
+		var args ipc.AlarmArgs
+		if err := req.DecodeArgs(&args); err != nil {
+			reply = ipcFailure(err)
+			break
+		}
+		cmdTea, reply = m.ipcAlarm(args)
+	case ipc.CmdStatus:
+		reply = ipcReply{ok: true, value: m.ipcStatus()}
+	case ipc.CmdPing:
+		reply = ipcReply{ok: true, data: "OK", value: ipc.Hello{Version: ipc.ProtocolVersion}}
 	default:
-		reply = ipcReply{ok: false, err: "unknown command"}
+		reply = ipcError(ipc.ErrUnknownCommand, "unknown command")
 	}
 
 	sendIPCReply(msg.reply, reply)
@@ -820,48 +1265,263 @@ func (m Model) handleIPC(msg ipcMsg) (tea.Model, tea.Cmd) {
 
 func (m *Model) ipcPlayPause() (tea.Cmd, ipcReply) {
 	if m.playing {
-		if m.player != nil {
-			_ = m.player.Stop()
-		}
-		m.playing = false
-		return nil, ipcReply{ok: true}
+		return m.stopPlayback(), ipcReply{ok: true}
 	}
 
 	station, ok := m.currentStation()
 	if !ok {
-		return nil, ipcReply{ok: false, err: "no station selected"}
+		return nil, ipcError(ipc.ErrNotFound, "no station selected")
 	}
 	m.noise.Start()
 	return m.playStationCmd(station), ipcReply{ok: true, data: "QUEUED"}
 }
 
+// ipcPlay plays the given station, or resumes the last one without a UUID
+// or name.
+func (m *Model) ipcPlay(args ipc.PlayArgs) (tea.Cmd, ipcReply) {
+	station := m.lastStation
+	switch {
+	case args.UUID != "":
+		station = m.lookupStation(args.UUID)
+	case args.Name != "":
+		var ok bool
+		if station, ok = m.stationByName(args.Name); !ok {
+			return nil, ipcError(ipc.ErrNotFound, fmt.Sprintf("no station matches %q", args.Name))
+		}
+	case station.UUID == "":
+		var ok bool
+		if station, ok = m.currentStation(); !ok {
+			return nil, ipcError(ipc.ErrNotFound, "no station selected")
+		}
+	}
+	if args.UUID != "" || args.Name != "" {
+		for i, s := range m.visibleStations() {
+			if s.UUID == station.UUID {
+				m.selected = i
+				m.dialTarget = m.dialValueForIndex(i)
+				break
+			}
+		}
+	}
+	m.noise.Start()
+	return tea.Batch(m.dialTickCmd(), m.playStationCmd(station)), ipcReply{ok: true, data: "QUEUED"}
+}
+
+// ipcSearchCmd searches the station directory and replies with the matches.
+func (m Model) ipcSearchCmd(args ipc.SearchArgs, reply chan ipcReply) tea.Cmd {
+	api := m.api
+	country := fallback(strings.ToUpper(strings.TrimSpace(args.Country)), m.country)
+	limit := args.Limit
+	if limit <= 0 || limit > m.pageSize() {
+		limit = m.pageSize()
+	}
+	return func() tea.Msg {
+		query := strings.TrimSpace(args.Query)
+		if query == "" {
+			sendIPCReply(reply, ipcError(ipc.ErrBadRequest, "search query is required"))
+			return nil
+		}
+		if api == nil {
+			sendIPCReply(reply, ipcError(ipc.ErrUnavailable, "radio api not available"))
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ipcReplyTimeout)
+		defer cancel()
+		stations, err := api.SearchStationsByCountry(ctx, country, query, limit, 0)
+		if err != nil {
+			sendIPCReply(reply, ipcError(ipc.ErrUnavailable, err.Error()))
+			return nil
+		}
+
+		results := make([]ipc.Station, 0, len(stations))
+		for _, station := range stations {
+			results = append(results, ipc.Station{
+				UUID:    station.UUID,
+				Name:    station.Name,
+				Country: station.Country,
+				Tags:    station.Tags,
+			})
+		}
+		sendIPCReply(reply, ipcReply{ok: true, value: results})
+		return nil
+	}
+}
+
+// favoritesChanged tells subscribers about edited favorites and refreshes
+// the list when it shows them.
+func (m *Model) favoritesChanged() tea.Cmd {
+	m.favoritesRev++
+	m.forgetMissingGroup()
+	if m.stationSource != sourceFavorites {
+		return nil
+	}
+	m.page = 0
+	m.hasMore = false
+	m.selected = 0
+	m.loading = true
+	m.noise.Start()
+	return m.loadStationsCmd()
+}
+
+// ipcFavoriteCmd adds a station (the playing one by default) to favorites
+// or removes it, looking up its details when only the UUID is known.
+func (m Model) ipcFavoriteCmd(args ipc.FavoriteArgs, reply chan ipcReply) tea.Cmd {
+	favorites, api := m.favorites, m.api
+	uuid := fallback(args.UUID, fallback(m.playingUUID, m.lastStation.UUID))
+	station := m.lookupStation(uuid)
+	return func() tea.Msg {
+		if favorites == nil {
+			sendIPCReply(reply, ipcError(ipc.ErrUnavailable, "favorites not available"))
+			return nil
+		}
+		if uuid == "" {
+			sendIPCReply(reply, ipcError(ipc.ErrNotFound, "no station selected"))
+			return nil
+		}
+		if favorites.IsFavorite(uuid) != args.Remove {
+			// Already the way it was asked to be.
+			sendIPCReply(reply, ipcReply{ok: true})
+			return nil
+		}
+		if !args.Remove && station.Name == "" && api != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), ipcReplyTimeout)
+			defer cancel()
+			found, err := api.StationByUUID(ctx, uuid)
+			if err != nil {
+				sendIPCReply(reply, ipcError(ipc.ErrNotFound, err.Error()))
+				return nil
+			}
+			station = found
+		}
+		if _, err := favorites.Toggle(station); err != nil {
+			sendIPCReply(reply, ipcFailure(err))
+			return nil
+		}
+		sendIPCReply(reply, ipcReply{ok: true})
+		return favoritesChangedMsg{}
+	}
+}
+
+// ipcFavorites lists the favorite stations.
+func (m *Model) ipcFavorites() ipcReply {
+	stations := []ipc.Station{}
+	if m.favorites != nil {
+		for _, fav := range m.favorites.List() {
+			stations = append(stations, ipc.Station{UUID: fav.UUID, Name: fav.Name, Country: fav.Country, Tags: fav.Tags})
+		}
+	}
+	return ipcReply{ok: true, value: stations}
+}
+
+// ipcRecent lists the stations played this session, newest first.
+func (m *Model) ipcRecent() ipcReply {
+	stations := make([]ipc.Station, 0, len(m.recent))
+	for _, station := range m.recent {
+		stations = append(stations, ipc.Station{UUID: station.UUID, Name: station.Name, Country: station.Country, Tags: station.Tags})
+	}
+	return ipcReply{ok: true, value: stations}
+}
+
+// recentLimit is how many recently played stations are kept.
+const recentLimit = 10
+
+// rememberRecent moves station to the front of the recent list.
+func (m *Model) rememberRecent(station radio.Station) {
+	recent := []radio.Station{station}
+	for _, s := range m.recent {
+		if s.UUID != station.UUID && len(recent) < recentLimit {
+			recent = append(recent, s)
+		}
+	}
+	m.recent = recent
+}
+
+// ipcReload re-reads favorites and alarms that another process changed.
+func (m *Model) ipcReload() ipcReply {
+	favorites, err := config.LoadFavorites()
+	if err != nil {
+		return ipcError(ipc.ErrInternal, err.Error())
+	}
+	m.favorites = favorites
+	m.favoritesRev++
+	m.forgetMissingGroup()
+	m.alarms = config.LoadConfig().Alarms
+	return ipcReply{ok: true}
+}
+
 func (m *Model) ipcSelectAndPlay(delta int) (tea.Cmd, ipcReply) {
 	list := m.visibleStations()
 	if len(list) == 0 {
-		return nil, ipcReply{ok: false, err: "no stations available"}
+		return nil, ipcError(ipc.ErrNotFound, "no stations available")
 	}
 	m.moveSelection(delta)
 	station, ok := m.currentStation()
 	if !ok {
-		return nil, ipcReply{ok: false, err: "no station selected"}
+		return nil, ipcError(ipc.ErrNotFound, "no station selected")
 	}
 	cmds := []tea.Cmd{m.dialTickCmd(), m.playStationCmd(station)}
 	return tea.Batch(cmds...), ipcReply{ok: true, data: "QUEUED"}
 }
 
-func (m *Model) ipcStatus() string {
-	station, _ := m.currentStation()
-	name := station.Name
-	if name == "" {
-		name = "-"
+func (m *Model) ipcStatus() ipc.Status {
+	station := m.lastStation
+	if station.UUID == "" {
+		station, _ = m.currentStation()
 	}
 
-	playing := "false"
-	if m.playing {
-		playing = "true"
+	return ipc.Status{
+		Playing:        m.playing,
+		Station:        fallback(station.Name, "-"),
+		UUID:           station.UUID,
+		Country:        m.country,
+		Title:          m.nowPlaying,
+		Volume:         m.volume(),
+		Muted:          m.muted,
+		SleepRemaining: int(m.sleepRemaining(time.Now()).Seconds()),
+		NextAlarm:      m.nextAlarmLabel(),
 	}
+}
 
-	return fmt.Sprintf("{\"playing\":%s,\"station\":%q,\"country\":%q}", playing, name, m.country)
+// stopPlayback stops the station, here or on the daemon.
+func (m *Model) stopPlayback() tea.Cmd {
+	m.playing = false
+	m.nowPlaying = ""
+	if m.mode == ModeAttached {
+		return m.remoteCmd(ipc.CmdStop, nil)
+	}
+	if m.player != nil {
+		_ = m.player.Stop()
+	}
+	return nil
+}
+
+// shutdown releases audio and the IPC endpoint before quitting. An attached
+// TUI only detaches; the daemon keeps playing. It runs once: a daemon told
+// to QUIT has shut down already when RunDaemon gets its final model.
+func (m *Model) shutdown() {
+	if m.shutDown {
+		return
+	}
+	m.shutDown = true
+	m.saveState()
+	m.finishListening(time.Now())
+	if m.player != nil {
+		_ = m.player.Stop()
+	}
+	m.noise.Stop()
+	if m.ipc != nil {
+		m.ipc.Close()
+	}
+	if m.mpris != nil {
+		m.mpris.Close()
+	}
+	if m.web != nil {
+		_ = m.web.Close()
+	}
+	if m.notifier != nil {
+		_ = m.notifier.Close()
+		m.notifier = nil
+	}
 }
 
 func sendIPCReply(ch chan ipcReply, reply ipcReply) {
@@ -991,6 +1651,47 @@ func (m *Model) isFavoritesSource() bool {
 	return m.stationSource == sourceFavorites
 }
 
+// lookupStation finds a station by UUID among the listed stations and
+// favorites. Unknown stations carry only their UUID.
+func (m *Model) lookupStation(uuid string) radio.Station {
+	for _, station := range m.visibleStations() {
+		if station.UUID == uuid {
+			return station
+		}
+	}
+	if m.favorites != nil {
+		for _, fav := range m.favorites.List() {
+			if fav.UUID == uuid {
+				return favoritesToStations([]config.Favorite{fav})[0]
+			}
+		}
+	}
+	return radio.Station{UUID: uuid}
+}
+
+// stationByName finds a loaded station or favorite by name, preferring an
+// exact match, then a prefix, then any substring, ignoring case.
+func (m *Model) stationByName(name string) (radio.Station, bool) {
+	candidates := m.visibleStations()
+	if m.favorites != nil {
+		candidates = append(candidates[:len(candidates):len(candidates)], favoritesToStations(m.favorites.List())...)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	matchers := []func(string) bool{
+		func(s string) bool { return s == name },
+		func(s string) bool { return strings.HasPrefix(s, name) },
+		func(s string) bool { return strings.Contains(s, name) },
+	}
+	for _, matches := range matchers {
+		for _, station := range candidates {
+			if matches(strings.ToLower(strings.TrimSpace(station.Name))) {
+				return station, true
+			}
+		}
+	}
+	return radio.Station{}, false
+}
+
 func favoritesToStations(favs []config.Favorite) []radio.Station {
 	stations := make([]radio.Station, 0, len(favs))
 	for _, fav := range favs {
@@ -998,10 +1699,12 @@ func favoritesToStations(favs []config.Favorite) []radio.Station {
 			continue
 		}
 		stations = append(stations, radio.Station{
-			UUID:    fav.UUID,
-			Name:    fav.Name,
-			Country: fav.Country,
-			Tags:    fav.Tags,
+			UUID:      fav.UUID,
+			Name:      fav.Name,
+			Country:   fav.Country,
+			Tags:      fav.Tags,
+			URL:       fav.URL,
+			Frequency: radio.Frequency(fav.Frequency),
 		})
 	}
 	return stations
@@ -1020,6 +1723,20 @@ func (m *Model) updateDialRange() {
 		m.dialMax = 0
 		return
 	}
+	if m.stationSource != sourceCountry {
+		// Favorites and recent stations sit on fixed slots of the whole band
+		// (see favoriteSlot), or on their own frequency, which may lie
+		// below it.
+		m.dialUseFreq = true
+		m.dialMin = dialBandMin
+		m.dialMax = dialBandMax
+		for _, station := range list {
+			if freq := station.Frequency.Float64(); freq > 0 && freq < m.dialMin {
+				m.dialMin = freq
+			}
+		}
+		return
+	}
 
 	min := math.MaxFloat64
 	max := 0.0
@@ -1055,6 +1772,13 @@ func (m Model) dialValueForIndex(index int) float64 {
 		return 0
 	}
 
+	if m.stationSource != sourceCountry {
+		// The same frequency listFrequency shows in the list.
+		if freq := list[index].Frequency.Float64(); freq > 0 {
+			return freq
+		}
+		return m.favoriteSlot(index, len(list), list[index].UUID)
+	}
 	if m.dialUseFreq {
 		if freq := list[index].Frequency.Float64(); freq > 0 {
 			return freq
@@ -1,9 +1,14 @@
 package ui
 
 import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"radio-tui/internal/config"
+	"radio-tui/internal/player"
 	"radio-tui/internal/radio"
 )
 
@@ -389,6 +394,44 @@ func TestModel_SnapDial(t *testing.T) {
 	}
 }
 
+func TestModel_TuningBlend(t *testing.T) {
+	m := createTestModel()
+	m.updateDialRange()
+
+	m.dialPos = 98.5 // Rock FM
+	if got := m.tuningBlend(); got != 0 {
+		t.Errorf("tuningBlend() on a station = %v, want 0", got)
+	}
+
+	m.dialPos = 98.75
+	if got := m.tuningBlend(); got <= 0 || got >= 1 {
+		t.Errorf("tuningBlend() near a station = %v, want between 0 and 1", got)
+	}
+
+	m.dialPos = 95.0 // far from every station
+	if got := m.tuningBlend(); got != 1 {
+		t.Errorf("tuningBlend() between stations = %v, want 1", got)
+	}
+}
+
+func TestModel_TuningBlend_IndexDial(t *testing.T) {
+	m := &Model{
+		stations: []radio.Station{{UUID: "a"}, {UUID: "b"}},
+	}
+	m.updateDialRange()
+
+	m.dialPos = 0.5
+	if got := m.tuningBlend(); got != 1 {
+		t.Errorf("tuningBlend() halfway = %v, want 1", got)
+	}
+
+	m.dialPos = 1
+	m.applyTuningBlend() // nil noise and player must be tolerated
+	if got := m.tuningBlend(); got != 0 {
+		t.Errorf("tuningBlend() on a station = %v, want 0", got)
+	}
+}
+
 func TestModel_UpdateInputWidths(t *testing.T) {
 	m := createTestModel()
 
@@ -419,7 +462,8 @@ func TestModel_IPCStatus(t *testing.T) {
 	m.country = "US"
 	m.playing = false
 
-	status := m.ipcStatus()
+	data, _ := json.Marshal(m.ipcStatus())
+	status := string(data)
 
 	if status == "" {
 		t.Error("ipcStatus() should not be empty")
@@ -439,7 +483,8 @@ func TestModel_IPCStatus_Playing(t *testing.T) {
 	m.playing = true
 	m.country = "JP"
 
-	status := m.ipcStatus()
+	data, _ := json.Marshal(m.ipcStatus())
+	status := string(data)
 
 	if !contains(status, `"playing":true`) {
 		t.Errorf("ipcStatus() should show playing:true, got %q", status)
@@ -493,3 +538,122 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCycleChoice(t *testing.T) {
+	choices := []int{50, 100, 200}
+
+	tests := []struct {
+		name    string
+		current int
+		delta   int
+		want    int
+	}{
+		{"forward", 50, 1, 100},
+		{"backward", 100, -1, 50},
+		{"wraps forward", 200, 1, 50},
+		{"wraps backward", 50, -1, 200},
+		{"unknown value", 75, 1, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cycleChoice(choices, tt.current, tt.delta); got != tt.want {
+				t.Errorf("cycleChoice(%d, %d) = %d, want %d", tt.current, tt.delta, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_AudioSettingsDefaults(t *testing.T) {
+	m := createTestModel()
+	m.audioDraft = config.AudioConfig{}
+
+	if got := audioSampleRate(m.audioDraft); got != 44100 {
+		t.Errorf("audioSampleRate() = %d, want 44100", got)
+	}
+	if got := audioBufferMs(m.audioDraft); got != 100 {
+		t.Errorf("audioBufferMs() = %d, want 100", got)
+	}
+
+	m.audioField = audioFieldBuffer
+	m.cycleAudioField(1)
+	if m.audioDraft.BufferMs != 200 {
+		t.Errorf("BufferMs after cycling = %d, want 200", m.audioDraft.BufferMs)
+	}
+	m.audioField = audioFieldSampleRate
+	m.cycleAudioField(1)
+	if m.audioDraft.SampleRate != 48000 {
+		t.Errorf("SampleRate after cycling = %d, want 48000", m.audioDraft.SampleRate)
+	}
+}
+
+func TestModel_AudioSettingsDevice(t *testing.T) {
+	m := createTestModel()
+	m.audio = config.AudioConfig{Device: "alsa/hw:1"}
+	m.audioDraft = m.audio
+	updated, _ := m.update(audioDevicesMsg{devices: []player.Device{{Name: "pulse/usb-dac", Description: "USB DAC"}}})
+	got := updated.(Model)
+
+	got.audioField = audioFieldDevice
+	var seen []string
+	for range 3 {
+		got.cycleAudioField(1)
+		seen = append(seen, got.deviceLabel(got.audioDraft.Device))
+	}
+	if want := []string{"system default", "USB DAC", "alsa/hw:1"}; !slices.Equal(seen, want) {
+		t.Errorf("cycling devices gave %q, want %q", seen, want)
+	}
+
+	updated, _ = got.update(audioSavedMsg{audio: config.AudioConfig{SampleRate: 48000}, err: player.ErrRestartRequired})
+	if msg := updated.(Model).errMsg; !strings.Contains(msg, "restart") || !strings.Contains(msg, "48000") {
+		t.Errorf("errMsg = %q, want a notice to restart for 48000 Hz", msg)
+	}
+}
+
+func TestModel_AudioSettingsAttached(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VALVEFM_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	m := createTestModel()
+	m.mode = ModeAttached
+	m.audioDraft = config.AudioConfig{SampleRate: 48000, BufferMs: 400}
+
+	msg, ok := m.saveAudioCmd()().(audioSavedMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("save = %+v, want the settings saved for the daemon", msg)
+	}
+	if got := config.LoadConfig().Audio; got != m.audioDraft {
+		t.Errorf("saved audio = %+v, want %+v", got, m.audioDraft)
+	}
+	updated, _ := m.update(msg)
+	if got := updated.(Model).errMsg; !strings.Contains(got, "daemon picks them up") {
+		t.Errorf("errMsg = %q, want the daemon to apply the settings", got)
+	}
+}
+
+func TestNewModel_StartupSettings(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VALVEFM_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	off, volume := false, 40
+	cfg := config.AppConfig{
+		Startup: config.StartupConfig{Source: "country", Country: "de", Station: "Jazz FM", Volume: &volume},
+		UI:      config.UIConfig{PageSize: 25, TuningStatic: &off},
+	}
+	m := NewModel(nil, nil, nil, nil, nil, cfg)
+
+	if m.country != "DE" || m.stationSource != sourceCountry || m.volume() != 40 || m.pageSize() != 25 {
+		t.Errorf("country %q, source %v, volume %d, page size %d", m.country, m.stationSource, m.volume(), m.pageSize())
+	}
+	if m.noise != nil {
+		t.Error("tuning static is on")
+	}
+	if m.startupPlay == nil || m.startupPlay.Name != "Jazz FM" {
+		t.Errorf("startupPlay = %+v, want Jazz FM", m.startupPlay)
+	}
+
+	cfg.Startup.Autoplay = &off
+	if m := NewModel(nil, nil, nil, nil, nil, cfg); m.startupPlay != nil {
+		t.Errorf("startupPlay = %+v without autoplay", m.startupPlay)
+	}
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/mpris"
+)
+
+type mprisReadyMsg struct{ server *mpris.Server }
+
+// startMPRISCmd exposes the player to MPRIS clients once the IPC server,
+// whose handlers MPRIS shares, is up. Without a session bus there is
+// simply no MPRIS.
+func (m Model) startMPRISCmd() tea.Cmd {
+	server := m.ipc
+	if server == nil || m.mode == ModeAttached {
+		return nil
+	}
+	return func() tea.Msg {
+		mp, err := mpris.Start(server.call)
+		if err != nil {
+			return nil
+		}
+		return mprisReadyMsg{server: mp}
+	}
+}
+
+// mprisState describes the station last played for MPRIS clients.
+func (m Model) mprisState() mpris.State {
+	return mpris.State{
+		Playing: m.playing,
+		UUID:    m.lastStation.UUID,
+		Station: m.lastStation.Name,
+		Title:   m.nowPlaying,
+		ArtURL:  m.lastStation.Favicon,
+		Volume:  m.volume(),
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/notify"
+)
+
+type notifierReadyMsg struct {
+	service *notify.Service
+	err     error
+}
+
+// startNotifierCmd starts desktop notifications when they are enabled.
+// Only the process that plays audio notifies, so an attached TUI does not
+// repeat the daemon's notifications.
+func (m Model) startNotifierCmd() tea.Cmd {
+	cfg, cacheDir := m.notifyConfig, m.config.CacheDir
+	if !cfg.Enabled || m.mode == ModeAttached {
+		return nil
+	}
+	return func() tea.Msg {
+		notifier, err := notify.New()
+		if err != nil {
+			return notifierReadyMsg{err: err}
+		}
+		var icons *notify.IconCache
+		if cacheDir != "" {
+			icons = notify.NewIconCacheIn(filepath.Join(cacheDir, "icons"))
+		} else {
+			icons, _ = notify.NewIconCache()
+		}
+		interval := time.Duration(cfg.MinInterval) * time.Second
+		return notifierReadyMsg{service: notify.NewService(notifier, icons, interval)}
+	}
+}
+
+// notifyChanges announces a new station, or a new song on the same one.
+func (m Model) notifyChanges(prev Model) {
+	if m.notifier == nil || !m.playing {
+		return
+	}
+	station := m.lastStation
+	switch {
+	case m.playingUUID != prev.playingUUID || !prev.playing:
+		m.notifier.Post(notify.Notification{Summary: station.Name, Body: m.nowPlaying, IconURL: station.Favicon})
+	case m.nowPlaying != prev.nowPlaying && m.nowPlaying != "":
+		m.notifier.Post(notify.Notification{Summary: m.nowPlaying, Body: station.Name, IconURL: station.Favicon})
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"radio-tui/internal/notify"
+	"radio-tui/internal/radio"
+)
+
+// chanNotifier hands notifications to the test.
+type chanNotifier chan notify.Notification
+
+func (c chanNotifier) Notify(n notify.Notification) error {
+	c <- n
+	return nil
+}
+
+func (c chanNotifier) Close() error { return nil }
+
+func TestModel_NotifyChanges(t *testing.T) {
+	shown := make(chanNotifier, 4)
+	prev := *createTestModel()
+	prev.notifier = notify.NewService(shown, nil, time.Millisecond)
+	defer prev.notifier.Close()
+	next := func() notify.Notification {
+		t.Helper()
+		select {
+		case n := <-shown:
+			return n
+		case <-time.After(2 * time.Second):
+			t.Fatal("no notification posted")
+			return notify.Notification{}
+		}
+	}
+
+	m := prev
+	m.playing = true
+	m.playingUUID = "3"
+	m.lastStation = radio.Station{UUID: "3", Name: "Jazz Station", Favicon: "http://example.com/logo.png"}
+	m.notifyChanges(prev)
+	if n := next(); n.Summary != "Jazz Station" || n.IconURL != "http://example.com/logo.png" {
+		t.Errorf("station notification = %+v", n)
+	}
+
+	prev, m.nowPlaying = m, "Artist - Song"
+	m.notifyChanges(prev)
+	if n := next(); n.Summary != "Artist - Song" || n.Body != "Jazz Station" {
+		t.Errorf("song notification = %+v", n)
+	}
+
+	// Stopping, and titles cleared by the stream, stay quiet.
+	prev, m.nowPlaying = m, ""
+	m.notifyChanges(prev)
+	prev, m.playing = m, false
+	m.notifyChanges(prev)
+	select {
+	case n := <-shown:
+		t.Errorf("unexpected notification %+v", n)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestModel_NotifierUnavailable(t *testing.T) {
+	m := createTestModel()
+	updated, _ := m.update(notifierReadyMsg{err: notify.ErrUnsupported})
+	got := updated.(Model)
+	if got.notifier != nil || !strings.HasPrefix(got.errMsg, "Notifications unavailable: ") {
+		t.Errorf("notifier %v, errMsg %q; want the failure shown", got.notifier, got.errMsg)
+	}
+}
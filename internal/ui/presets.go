@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+const (
+	// presetSpacingMHz separates presets on the favorites dial, from 88 MHz
+	// up. Favorites after the presets share what is left of the band, so
+	// adding one never moves a preset.
+	presetSpacingMHz = 2.0
+	dialBandMin      = 88.0
+	dialBandMax      = 108.0
+)
+
+// favoriteFrequency places the favorite at index on the dial.
+func favoriteFrequency(index, total int) float64 {
+	if index < config.PresetCount {
+		return dialBandMin + presetSpacingMHz*float64(index)
+	}
+	lastPreset := dialBandMin + presetSpacingMHz*float64(config.PresetCount-1)
+	rest := total - config.PresetCount
+	step := (dialBandMax - lastPreset) / float64(rest+1)
+	return lastPreset + step*float64(index-config.PresetCount+1)
+}
+
+// favoriteSlot places the station at index of the list shown on the
+// favorites dial. A favorite keeps its place among all favorites, whatever
+// group, search or page is shown; other stations count down the whole list.
+func (m Model) favoriteSlot(index, total int, uuid string) float64 {
+	if m.favorites != nil {
+		all := m.favorites.List()
+		for i, fav := range all {
+			if fav.UUID == uuid {
+				return favoriteFrequency(i, len(all))
+			}
+		}
+	}
+	offset := max(m.page, 0) * m.pageSize()
+	return favoriteFrequency(offset+index, offset+total)
+}
+
+// presetNumbers maps the UUIDs of the preset stations to their numbers.
+func (m *Model) presetNumbers() map[string]int {
+	presets := map[string]int{}
+	if m.favorites == nil {
+		return presets
+	}
+	for n := 1; n <= config.PresetCount; n++ {
+		fav, ok := m.favorites.Preset(n)
+		if !ok {
+			break
+		}
+		presets[fav.UUID] = n
+	}
+	return presets
+}
+
+// playPreset tunes to preset n.
+func (m *Model) playPreset(n int) (tea.Cmd, ipcReply) {
+	if n < 1 || n > config.PresetCount {
+		return nil, ipcError(ipc.ErrBadRequest, fmt.Sprintf("presets run from 1 to %d", config.PresetCount))
+	}
+	if m.favorites == nil {
+		return nil, ipcError(ipc.ErrUnavailable, "favorites not available")
+	}
+	fav, ok := m.favorites.Preset(n)
+	if !ok {
+		return nil, ipcError(ipc.ErrNotFound, fmt.Sprintf("no preset %d", n))
+	}
+	return m.ipcPlay(ipc.PlayArgs{UUID: fav.UUID})
+}
+
+// moveFavorite moves the selected favorite up (negative delta) or down the
+// order of the favorites, or of the group shown, keeping it selected.
+func (m *Model) moveFavorite(delta int) tea.Cmd {
+	if !m.isFavoritesSource() || m.favorites == nil {
+		return nil
+	}
+	if m.activeSearch != "" {
+		m.errMsg = "Clear the search to reorder favorites"
+		return nil
+	}
+	station, ok := m.currentStation()
+	if !ok {
+		return nil
+	}
+	var err error
+	if group := m.listGroup(); group != "" {
+		err = m.favorites.MoveInGroup(station.UUID, group, delta)
+	} else {
+		err = m.favorites.Move(station.UUID, delta)
+	}
+	if err != nil {
+		m.errMsg = err.Error()
+		return nil
+	}
+
+	to := m.selected + delta
+	if to < 0 || to >= len(m.stations) {
+		// The station moved onto another page.
+		return tea.Batch(m.favoritesChanged(), m.reloadDaemonCmd())
+	}
+	m.favoritesRev++
+	m.stations = slices.Insert(slices.Delete(slices.Clone(m.stations), m.selected, m.selected+1), to, station)
+	m.selected = to
+	m.snapDial()
+	return m.reloadDaemonCmd()
+}
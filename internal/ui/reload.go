@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/hooks"
+	"radio-tui/internal/player"
+)
+
+// fileCheckInterval is how often config.json and favorites.json are
+// checked for changes made outside this process.
+const fileCheckInterval = 2 * time.Second
+
+type fileCheckMsg struct{}
+
+// filesReloadedMsg carries the files re-read after they changed on disk.
+type filesReloadedMsg struct {
+	config           bool // config.json changed; cfg and cfgErr are set
+	cfg              config.AppConfig
+	cfgErr           error
+	favoritesChanged bool
+	favErr           error
+}
+
+// fileCheckCmd waits for the next check and re-reads whichever file
+// changed. Favorites are reloaded in place; the config is applied in
+// applyConfig.
+func (m Model) fileCheckCmd() tea.Cmd {
+	watcher, favorites := m.watcher, m.favorites
+	if watcher == nil {
+		return nil
+	}
+	return tea.Tick(fileCheckInterval, func(time.Time) tea.Msg {
+		configChanged, favoritesChanged := watcher.Changed()
+		if !configChanged && !favoritesChanged {
+			return fileCheckMsg{}
+		}
+		var msg filesReloadedMsg
+		if configChanged {
+			msg.config = true
+			msg.cfg, msg.cfgErr = config.ReadConfig()
+		}
+		if favoritesChanged && favorites != nil {
+			msg.favoritesChanged, msg.favErr = favorites.Reload()
+		}
+		return msg
+	})
+}
+
+func (m Model) handleFilesReloaded(msg filesReloadedMsg) (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{m.fileCheckCmd()}
+	switch {
+	case msg.cfgErr != nil:
+		m.errMsg = "Keeping the current settings: " + msg.cfgErr.Error()
+	case msg.config:
+		cmds = append(cmds, m.applyConfig(msg.cfg))
+	}
+	switch {
+	case msg.favErr != nil:
+		m.errMsg = "Keeping the current favorites: " + msg.favErr.Error()
+	case msg.favoritesChanged:
+		cmds = append(cmds, m.relistFavorites())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// applyConfig takes up the settings of a config file changed on disk.
+// Settings being edited in an open dialog are left for the dialog to save,
+// and the startup settings wait for the next start.
+func (m *Model) applyConfig(cfg config.AppConfig) tea.Cmd {
+	cfg = cfg.WithDefaults()
+	prev := m.config
+	m.config = cfg
+	var cmds []tea.Cmd
+	// The theme is compared with the last config rather than the theme
+	// shown, so that a --theme flag does not undo the theme picker.
+	if theme := ThemeBySlug(cfg.Theme); cfg.Theme != prev.Theme && theme.Slug != m.theme.Slug && !m.showTheme {
+		m.theme = theme
+		m.styles = BuildStyles(theme)
+		for i, t := range Themes {
+			if t.Slug == theme.Slug {
+				m.themeIdx = i
+			}
+		}
+	}
+	if cfg.Audio != m.audio && !m.showAudio {
+		m.audio = cfg.Audio
+		if m.mode != ModeAttached {
+			if err := ApplyAudioConfig(cfg.Audio); errors.Is(err, player.ErrRestartRequired) {
+				m.errMsg = "Audio settings reloaded; the new sample rate applies after restart"
+			} else if err != nil {
+				m.errMsg = "Failed to apply audio settings: " + err.Error()
+			}
+		}
+	}
+	if !slices.Equal(cfg.Alarms, m.alarms) && !m.showAlarms {
+		m.alarms = cfg.Alarms
+	}
+	if cfg.Notifications != m.notifyConfig {
+		m.notifyConfig = cfg.Notifications
+		if m.notifier != nil {
+			m.notifier.Close()
+			m.notifier = nil
+		}
+		cmds = append(cmds, m.startNotifierCmd())
+	}
+	if !slices.Equal(cfg.Hooks, m.hookConfig) {
+		m.hookConfig = cfg.Hooks
+		m.hooks = nil
+		if len(cfg.Hooks) > 0 && m.mode != ModeAttached {
+			m.hooks = hooks.New(cfg.Hooks)
+			cmds = append(cmds, m.listenHooksCmd())
+		}
+	}
+	if !maps.EqualFunc(cfg.Keys, prev.Keys, slices.Equal) {
+		m.keys = newKeyMap(cfg.Keys)
+	}
+	if static := *cfg.UI.TuningStatic; static != *prev.WithDefaults().UI.TuningStatic && m.mode != ModeDaemon {
+		if static {
+			m.noise = player.NewNoisePlayer()
+		} else {
+			m.noise.Stop()
+			m.noise = nil
+		}
+	}
+	if cfg.HTTP != m.httpConfig {
+		m.httpConfig = cfg.HTTP
+		m.errMsg = "Web API settings changed; they apply after restart"
+	}
+	if cfg.Player != prev.Player || cfg.API != prev.API {
+		m.errMsg = "Player and API settings changed; they apply after restart"
+	}
+	return tea.Batch(cmds...)
+}
+
+// relistFavorites shows the favorites again after the file changed on
+// disk, keeping the selected station selected if it is still listed.
+func (m *Model) relistFavorites() tea.Cmd {
+	m.favoritesRev++
+	m.forgetMissingGroup()
+	if m.stationSource != sourceFavorites {
+		return nil
+	}
+	if station, ok := m.currentStation(); ok {
+		m.keepSelected = station.UUID
+	}
+	m.loading = true
+	return m.loadStationsCmd()
+}
+
+// selectKept selects the station relistFavorites asked to keep, or the
+// first one.
+func (m *Model) selectKept() {
+	m.selected = 0
+	if m.keepSelected != "" {
+		for i, station := range m.stations {
+			if station.UUID == m.keepSelected {
+				m.selected = i
+			}
+		}
+		m.keepSelected = ""
+	}
+	m.ensureSelection()
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"radio-tui/internal/config"
+)
+
+func TestModel_FavoritesReloadKeepsSelection(t *testing.T) {
+	m := groupedModel(t)
+	m.stationSource = sourceFavorites
+	m.favGroup = "Talk"
+	talk, _ := m.favorites.ListGroup("Talk")
+	m.stations = favoritesToStations(talk)
+	m.selected = 0 // Jazz Station
+
+	// Edited by hand: News Talk moves to the top, Pop Radio is added and
+	// the groups are gone.
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "valvefm", "favorites.json")
+	edited := `{"version": 4, "stations": [{"uuid": "4", "name": "News Talk"}, {"uuid": "1", "name": "Rock FM"}, {"uuid": "3", "name": "Jazz Station"}, {"uuid": "2", "name": "Pop Radio"}]}`
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := m.favorites.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v", changed, err)
+	}
+
+	updated, cmd := m.update(filesReloadedMsg{favoritesChanged: true})
+	got := loadList(t, updated.(Model), cmd)
+	if len(got.stations) != 4 {
+		t.Fatalf("list = %+v, want the 4 favorites on disk", got.stations)
+	}
+	if station, _ := got.currentStation(); station.UUID != "3" {
+		t.Errorf("selected %+v, want Jazz Station kept", station)
+	}
+	if got.favGroup != "" {
+		t.Errorf("group %q survived its removal from the file", got.favGroup)
+	}
+}
+
+func TestModel_ConfigReload(t *testing.T) {
+	m := createTestModel()
+	m.theme = ThemeBySlug("vintage")
+
+	updated, _ := m.update(filesReloadedMsg{config: true, cfg: config.AppConfig{
+		Theme:  "nord",
+		Alarms: []config.Alarm{{Time: "07:00", UUID: "1", Name: "Rock FM", Enabled: true}},
+	}})
+	got := updated.(Model)
+	if got.theme.Slug != "nord" || Themes[got.themeIdx].Slug != "nord" {
+		t.Errorf("theme = %s, want nord", got.theme.Slug)
+	}
+	if len(got.alarms) != 1 {
+		t.Errorf("alarms = %+v", got.alarms)
+	}
+
+	updated, _ = got.update(filesReloadedMsg{config: true, cfgErr: errors.New("config.json: unexpected end of JSON input")})
+	got = updated.(Model)
+	if got.theme.Slug != "nord" || got.errMsg == "" {
+		t.Errorf("a bad config changed the theme to %s or went unreported (%q)", got.theme.Slug, got.errMsg)
+	}
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+// restoreState comes back where the last session left off: its list,
+// search and station, playing again if it was playing. Startup settings
+// given in cfg, which is not yet filled with defaults, take precedence.
+func (m *Model) restoreState(state config.State, cfg config.AppConfig) {
+	if cfg.Startup.Source == "" {
+		switch {
+		case state.Source == "country":
+			m.stationSource = sourceCountry
+		case state.Source == "recent":
+			m.stationSource = sourceRecent
+		case state.Source == "favorites" && m.favorites != nil && m.favorites.Count() > 0:
+			m.stationSource = sourceFavorites
+			m.favGroup = state.Group
+			m.forgetMissingGroup()
+		}
+	}
+	if cfg.Startup.Country == "" && state.Country != "" {
+		m.country = state.Country
+	}
+	m.activeSearch = state.Search
+	if state.Station.UUID == "" {
+		return
+	}
+	m.lastStation = favoritesToStations([]config.Favorite{state.Station})[0]
+	m.keepSelected = state.Station.UUID
+	if state.Playing && cfg.Startup.Station == "" && *cfg.WithDefaults().Startup.Autoplay {
+		// An empty PLAY resumes lastStation.
+		m.startupPlay = &ipc.PlayArgs{}
+	}
+}
+
+// sessionState is what the next session restores.
+func (m Model) sessionState() config.State {
+	state := config.State{
+		Source:  "country",
+		Country: m.country,
+		Search:  m.activeSearch,
+		Playing: m.playing,
+	}
+	switch m.stationSource {
+	case sourceFavorites:
+		state.Source = "favorites"
+		state.Group = m.favGroup
+	case sourceRecent:
+		state.Source = "recent"
+	}
+	station, ok := m.currentStation()
+	if m.playing || !ok {
+		station = m.lastStation
+	}
+	if station.UUID != "" {
+		state.Station = config.FavoriteOf(station)
+	}
+	return state
+}
+
+type stateSavedMsg struct{ err error }
+
+// keepsState reports whether this process records the session for the next
+// start. An attached TUI leaves that to the daemon, and nothing is saved
+// until the startup station had its chance to play.
+func (m Model) keepsState() bool {
+	return m.stateFile != nil && m.mode != ModeAttached && m.startupPlay == nil
+}
+
+// saveStateCmd writes the session in the background when it changed.
+func (m Model) saveStateCmd() tea.Cmd {
+	if !m.keepsState() {
+		return nil
+	}
+	file, state := m.stateFile, m.sessionState()
+	if state == file.State() {
+		return nil
+	}
+	return func() tea.Msg {
+		return stateSavedMsg{err: file.Save(state)}
+	}
+}
+
+// saveState records the session before quitting.
+func (m Model) saveState() {
+	if m.keepsState() {
+		_ = m.stateFile.Save(m.sessionState())
+	}
+}
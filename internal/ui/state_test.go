@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"testing"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/radio"
+)
+
+func TestRestoreState_ResumesPlayback(t *testing.T) {
+	state := config.State{Source: "country", Country: "DE", Search: "jazz", Station: config.Favorite{UUID: "3", Name: "Jazz Station"}, Playing: true}
+
+	m := createTestModel()
+	m.restoreState(state, config.AppConfig{})
+	if m.country != "DE" || m.activeSearch != "jazz" || m.stationSource != sourceCountry {
+		t.Errorf("country %q, search %q, source %v", m.country, m.activeSearch, m.stationSource)
+	}
+	if m.lastStation.UUID != "3" || m.keepSelected != "3" {
+		t.Errorf("lastStation = %+v, keepSelected = %q", m.lastStation, m.keepSelected)
+	}
+	if m.startupPlay == nil || m.startupPlay.UUID != "" || m.startupPlay.Name != "" {
+		t.Errorf("startupPlay = %+v, want an empty PLAY that resumes lastStation", m.startupPlay)
+	}
+
+	off := false
+	m = createTestModel()
+	m.restoreState(state, config.AppConfig{Startup: config.StartupConfig{Country: "FR", Autoplay: &off}})
+	if m.country != "US" || m.startupPlay != nil {
+		t.Errorf("country %q, startupPlay %+v; the startup settings should win", m.country, m.startupPlay)
+	}
+	if m.lastStation.UUID != "3" {
+		t.Error("the station is not remembered without autoplay")
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	m := createTestModel()
+	m.selected = 1
+	m.activeSearch = "pop"
+	if got := m.sessionState(); got.Station.UUID != "2" || got.Playing || got.Source != "country" || got.Search != "pop" {
+		t.Errorf("sessionState() = %+v, want the selected station, stopped", got)
+	}
+
+	m.playing = true
+	m.lastStation = radio.Station{UUID: "5", Name: "Classical Music"}
+	if got := m.sessionState(); got.Station.UUID != "5" || !got.Playing {
+		t.Errorf("sessionState() = %+v, want the playing station", got)
+	}
+}
+
+func TestSaveStateCmd(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	file, err := config.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := createTestModel()
+	m.stateFile = file
+	m.selected = 2
+
+	cmd := m.saveStateCmd()
+	if cmd == nil {
+		t.Fatal("no save for a changed session")
+	}
+	if file.State().Station.UUID != "" {
+		t.Error("the state was written before the command ran")
+	}
+	if msg := cmd().(stateSavedMsg); msg.err != nil {
+		t.Fatalf("save failed: %v", msg.err)
+	}
+	if file.State().Station.UUID != "3" {
+		t.Errorf("saved %+v, want Jazz Station", file.State())
+	}
+	if m.saveStateCmd() != nil {
+		t.Error("an unchanged session is saved again")
+	}
+
+	m.selected = 0
+	m.mode = ModeAttached
+	if m.saveStateCmd() != nil {
+		t.Error("an attached TUI saves the session")
+	}
+}
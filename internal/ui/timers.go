@@ -0,0 +1,379 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+	"radio-tui/internal/player"
+	"radio-tui/internal/radio"
+)
+
+const (
+	// sleepFadeDuration is how long before the sleep timer ends the station
+	// starts fading out.
+	sleepFadeDuration = time.Minute
+	// alarmRampDuration is how long an alarm takes to reach full volume.
+	alarmRampDuration = 90 * time.Second
+	// clockTickInterval drives the sleep timer, alarms and their fades.
+	clockTickInterval = time.Second
+)
+
+// sleepChoices are the sleep timer lengths offered in the dialog, in minutes.
+// Zero turns the timer off.
+var sleepChoices = []int{0, 15, 30, 45, 60, 90, 120}
+
+type clockTickMsg struct{ at time.Time }
+
+type alarmsSavedMsg struct{ err error }
+
+func (m Model) clockTickCmd() tea.Cmd {
+	return tea.Tick(clockTickInterval, func(at time.Time) tea.Msg {
+		return clockTickMsg{at: at}
+	})
+}
+
+// handleClockTick advances the sleep timer and alarms to now.
+func (m Model) handleClockTick(now time.Time) (tea.Model, tea.Cmd) {
+	last := m.lastClock
+	m.lastClock = now
+	cmds := []tea.Cmd{m.clockTickCmd()}
+
+	if m.mode == ModeAttached {
+		// The daemon runs the timers; follow its state instead.
+		return m, tea.Batch(append(cmds, m.remoteStatusCmd())...)
+	}
+
+	if !m.sleepAt.IsZero() && !now.Before(m.sleepAt) {
+		m.sleepAt = time.Time{}
+		cmds = append(cmds, m.stopPlayback())
+		m.errMsg = "Sleep timer ended playback"
+	}
+
+	if !last.IsZero() {
+		for _, alarm := range m.alarms {
+			if alarm.Due(last, now) {
+				cmds = append(cmds, m.startAlarm(alarm, now))
+				break
+			}
+		}
+	}
+
+	m.refreshNowPlaying()
+	if cmd := m.saveStateCmd(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	if !m.alarmRampAt.IsZero() && now.Sub(m.alarmRampAt) >= alarmRampDuration {
+		m.alarmRampAt = time.Time{}
+	}
+
+	m.applyOutputLevel(now, clockTickInterval)
+	return m, tea.Batch(cmds...)
+}
+
+// startAlarm plays the alarm's station, starting silent and ramping up.
+func (m *Model) startAlarm(alarm config.Alarm, now time.Time) tea.Cmd {
+	m.alarmRampAt = now
+	m.sleepAt = time.Time{}
+	m.errMsg = fmt.Sprintf("Alarm %s: %s", alarm.Time, fallback(alarm.Name, alarm.UUID))
+	m.applyOutputLevel(now, 0)
+	m.noise.Start()
+	return m.playStationCmd(m.alarmStation(alarm))
+}
+
+func (m *Model) alarmStation(alarm config.Alarm) radio.Station {
+	station := m.lookupStation(alarm.UUID)
+	station.Name = fallback(station.Name, alarm.Name)
+	return station
+}
+
+// applyOutputLevel glides the station level to the product of the volume,
+// the tuning blend, the sleep fade-out and the alarm ramp-up.
+func (m *Model) applyOutputLevel(now time.Time, d time.Duration) {
+	if leveler, ok := m.player.(player.Leveler); ok {
+		leveler.FadeTo(m.outputLevel(now), d)
+	}
+}
+
+// canFade reports whether level changes are heard; ffplay plays at full
+// level whatever is asked.
+func (m Model) canFade() bool {
+	if _, ok := m.player.(player.Leveler); !ok {
+		return false
+	}
+	if reporter, ok := m.player.(player.FadeReporter); ok {
+		return reporter.CanFade()
+	}
+	return true
+}
+
+// fadeNotice says what the sleep timer or a running alarm does when the
+// player cannot fade, or is empty when it can or neither is set.
+func (m Model) fadeNotice() string {
+	if !m.playing || m.canFade() {
+		return ""
+	}
+	switch {
+	case !m.sleepAt.IsZero():
+		return "This player cannot fade; the sleep timer stops playback at the end"
+	case !m.alarmRampAt.IsZero():
+		return "This player cannot fade; the alarm plays at full volume"
+	}
+	return ""
+}
+
+func (m Model) outputLevel(now time.Time) float64 {
+	if m.muted {
+		return 0
+	}
+	level := float64(m.volume()) / 100 * (1 - m.tuningBlend())
+	if !m.sleepAt.IsZero() {
+		if remaining := m.sleepAt.Sub(now); remaining < sleepFadeDuration {
+			level *= clampUnit(float64(remaining) / float64(sleepFadeDuration))
+		}
+	}
+	if !m.alarmRampAt.IsZero() {
+		if elapsed := now.Sub(m.alarmRampAt); elapsed < alarmRampDuration {
+			level *= clampUnit(float64(elapsed) / float64(alarmRampDuration))
+		}
+	}
+	return level
+}
+
+// setSleepTimer schedules playback to fade out and stop after the given
+// number of minutes; zero cancels the timer.
+func (m *Model) setSleepTimer(minutes int, now time.Time) {
+	if minutes <= 0 {
+		m.sleepAt = time.Time{}
+	} else {
+		m.sleepAt = now.Add(time.Duration(minutes) * time.Minute)
+	}
+	m.applyOutputLevel(now, clockTickInterval)
+	if notice := m.fadeNotice(); notice != "" {
+		m.errMsg = notice
+	}
+}
+
+func (m Model) sleepRemaining(now time.Time) time.Duration {
+	if m.sleepAt.IsZero() {
+		return 0
+	}
+	if remaining := m.sleepAt.Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (m Model) updateSleepDialog(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "z", "Z", "esc":
+		m.showSleep = false
+	case "up", "k":
+		if m.sleepIdx > 0 {
+			m.sleepIdx--
+		}
+	case "down", "j":
+		if m.sleepIdx < len(sleepChoices)-1 {
+			m.sleepIdx++
+		}
+	case "enter":
+		m.showSleep = false
+		if m.mode == ModeAttached {
+			return m, m.remoteCmd(ipc.CmdSleep, ipc.SleepArgs{Minutes: sleepChoices[m.sleepIdx]})
+		}
+		m.setSleepTimer(sleepChoices[m.sleepIdx], time.Now())
+	}
+	return m, nil
+}
+
+func (m Model) updateAlarmDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.alarmEditing {
+		return m.updateAlarmEditor(msg)
+	}
+
+	// The last row is "New alarm".
+	switch msg.String() {
+	case "w", "W", "esc":
+		m.showAlarms = false
+	case "up", "k":
+		if m.alarmIdx > 0 {
+			m.alarmIdx--
+		}
+	case "down", "j":
+		if m.alarmIdx < len(m.alarms) {
+			m.alarmIdx++
+		}
+	case " ":
+		if m.alarmIdx < len(m.alarms) {
+			m.alarms[m.alarmIdx].Enabled = !m.alarms[m.alarmIdx].Enabled
+			return m, m.saveAlarmsCmd()
+		}
+	case "d", "D", "delete":
+		if m.alarmIdx < len(m.alarms) {
+			m.alarms = append(m.alarms[:m.alarmIdx:m.alarmIdx], m.alarms[m.alarmIdx+1:]...)
+			if m.alarmIdx > len(m.alarms) {
+				m.alarmIdx = len(m.alarms)
+			}
+			return m, m.saveAlarmsCmd()
+		}
+	case "enter":
+		if m.favorites == nil || m.favorites.Count() == 0 {
+			m.errMsg = "Add a favorite first; alarms play a favorite station"
+			return m, nil
+		}
+		m.alarmEditing = true
+		m.alarmStationIdx = 0
+		m.alarmTime.SetValue("07:00")
+		if m.alarmIdx < len(m.alarms) {
+			alarm := m.alarms[m.alarmIdx]
+			m.alarmTime.SetValue(alarm.Time)
+			for i, fav := range m.favorites.List() {
+				if fav.UUID == alarm.UUID {
+					m.alarmStationIdx = i
+				}
+			}
+		}
+		m.alarmTime.Focus()
+		m.alarmTime.CursorEnd()
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+func (m Model) updateAlarmEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	favs := m.favorites.List()
+	switch msg.String() {
+	case "esc":
+		m.alarmEditing = false
+		m.alarmTime.Blur()
+		return m, nil
+	case "up":
+		if m.alarmStationIdx > 0 {
+			m.alarmStationIdx--
+		}
+		return m, nil
+	case "down":
+		if m.alarmStationIdx < len(favs)-1 {
+			m.alarmStationIdx++
+		}
+		return m, nil
+	case "enter":
+		if len(favs) == 0 {
+			m.alarmEditing = false
+			return m, nil
+		}
+		fav := favs[min(m.alarmStationIdx, len(favs)-1)]
+		alarm := config.Alarm{
+			Time:    strings.TrimSpace(m.alarmTime.Value()),
+			UUID:    fav.UUID,
+			Name:    fav.Name,
+			Enabled: true,
+		}
+		if err := alarm.Validate(); err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		hour, minute, _ := config.ParseClock(alarm.Time)
+		alarm.Time = config.FormatClock(hour, minute)
+		if m.alarmIdx < len(m.alarms) {
+			m.alarms[m.alarmIdx] = alarm
+		} else {
+			m.alarms = append(m.alarms, alarm)
+		}
+		m.alarmEditing = false
+		m.alarmTime.Blur()
+		m.errMsg = ""
+		return m, m.saveAlarmsCmd()
+	}
+
+	var cmd tea.Cmd
+	m.alarmTime, cmd = m.alarmTime.Update(msg)
+	return m, cmd
+}
+
+func (m Model) saveAlarmsCmd() tea.Cmd {
+	alarms := append([]config.Alarm(nil), m.alarms...)
+	save := func() tea.Msg {
+		return alarmsSavedMsg{err: config.SaveAlarms(alarms)}
+	}
+	if m.mode == ModeAttached {
+		return tea.Sequence(save, m.reloadDaemonCmd())
+	}
+	return save
+}
+
+// ipcSleepStatus reports the remaining sleep time in seconds.
+func (m *Model) ipcSleepStatus() ipcReply {
+	remaining := int(m.sleepRemaining(time.Now()).Seconds())
+	return ipcReply{ok: true, data: strconv.Itoa(remaining), value: ipc.SleepStatus{Remaining: remaining}}
+}
+
+// ipcSleep sets the sleep timer; zero minutes cancels it.
+func (m *Model) ipcSleep(args ipc.SleepArgs) ipcReply {
+	if args.Minutes < 0 {
+		return ipcError(ipc.ErrBadRequest, "sleep minutes must not be negative")
+	}
+	m.setSleepTimer(args.Minutes, time.Now())
+	return ipcReply{ok: true}
+}
+
+// ipcNextAlarm reports the next enabled alarm.
+func (m *Model) ipcNextAlarm() ipcReply {
+	alarm, at, ok := config.NextAlarm(m.alarms, time.Now())
+	if !ok {
+		return ipcReply{ok: true, data: "NONE"}
+	}
+	info := ipc.AlarmInfo{Time: at.Format("15:04"), UUID: alarm.UUID, Name: alarm.Name}
+	return ipcReply{ok: true, data: fmt.Sprintf("%s %s %s", info.Time, info.UUID, info.Name), value: info}
+}
+
+// ipcAlarm adds an alarm for a favorite (the playing station by default) or
+// disables all alarms.
+func (m *Model) ipcAlarm(args ipc.AlarmArgs) (tea.Cmd, ipcReply) {
+	if args.Off {
+		for i := range m.alarms {
+			m.alarms[i].Enabled = false
+		}
+		return m.saveAlarmsCmd(), ipcReply{ok: true}
+	}
+
+	uuid := fallback(args.UUID, m.playingUUID)
+	alarm := config.Alarm{Time: args.Time, UUID: uuid, Enabled: true}
+	if err := alarm.Validate(); err != nil {
+		return nil, ipcError(ipc.ErrBadRequest, err.Error())
+	}
+	if m.favorites == nil || !m.favorites.IsFavorite(uuid) {
+		return nil, ipcError(ipc.ErrBadRequest, "alarm station must be a favorite")
+	}
+	hour, minute, _ := config.ParseClock(alarm.Time)
+	alarm.Time = config.FormatClock(hour, minute)
+	alarm.Name = m.alarmStation(alarm).Name
+	m.alarms = append(m.alarms, alarm)
+	return m.saveAlarmsCmd(), ipcReply{ok: true}
+}
+
+// formatCountdown renders a remaining duration as "1h05m" or "24:13".
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func clampUnit(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
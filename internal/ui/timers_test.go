@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/ipc"
+)
+
+func TestModel_OutputLevel_SleepFade(t *testing.T) {
+	m := createTestModel()
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	if got := m.outputLevel(now); got != 1 {
+		t.Errorf("outputLevel() without timers = %v, want 1", got)
+	}
+
+	m.sleepAt = now.Add(10 * time.Minute)
+	if got := m.outputLevel(now); got != 1 {
+		t.Errorf("outputLevel() long before sleep = %v, want 1", got)
+	}
+
+	m.sleepAt = now.Add(sleepFadeDuration / 2)
+	if got := m.outputLevel(now); got != 0.5 {
+		t.Errorf("outputLevel() halfway through fade = %v, want 0.5", got)
+	}
+}
+
+func TestModel_OutputLevel_AlarmRamp(t *testing.T) {
+	m := createTestModel()
+	now := time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC)
+
+	m.alarmRampAt = now
+	if got := m.outputLevel(now); got != 0 {
+		t.Errorf("outputLevel() at alarm start = %v, want 0", got)
+	}
+	if got := m.outputLevel(now.Add(alarmRampDuration / 2)); got != 0.5 {
+		t.Errorf("outputLevel() mid ramp = %v, want 0.5", got)
+	}
+	if got := m.outputLevel(now.Add(alarmRampDuration)); got != 1 {
+		t.Errorf("outputLevel() after ramp = %v, want 1", got)
+	}
+}
+
+func TestModel_SetSleepTimer_ReportsMissingFade(t *testing.T) {
+	m := createTestModel()
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	m.playing = true
+	m.player = &levelPlayer{}
+	m.setSleepTimer(30, now)
+	if m.errMsg != "" {
+		t.Errorf("errMsg = %q, want none when the player fades", m.errMsg)
+	}
+
+	m.player = &fullLevelPlayer{}
+	m.setSleepTimer(30, now)
+	if !strings.Contains(m.errMsg, "cannot fade") {
+		t.Errorf("errMsg = %q, want a notice that fading is unavailable", m.errMsg)
+	}
+}
+
+func TestModel_HandleClockTick_SleepStopsPlayback(t *testing.T) {
+	m := createTestModel()
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	m.playing = true
+	m.sleepAt = now
+
+	updated, _ := m.handleClockTick(now)
+	got := updated.(Model)
+	if got.playing {
+		t.Error("playback should stop when the sleep timer ends")
+	}
+	if !got.sleepAt.IsZero() {
+		t.Error("sleep timer should be cleared once it fires")
+	}
+}
+
+func TestModel_HandleClockTick_AlarmFires(t *testing.T) {
+	m := createTestModel()
+	m.alarms = []config.Alarm{{Time: "07:00", UUID: "3", Name: "Jazz Station", Enabled: true}}
+	m.lastClock = time.Date(2026, 3, 1, 6, 59, 59, 0, time.UTC)
+
+	updated, cmd := m.handleClockTick(time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC))
+	got := updated.(Model)
+	if got.alarmRampAt.IsZero() {
+		t.Error("alarm should start its volume ramp")
+	}
+	if cmd == nil {
+		t.Error("alarm should queue playback")
+	}
+}
+
+func TestModel_HandleClockTick_FirstTickSkipsAlarms(t *testing.T) {
+	m := createTestModel()
+	m.alarms = []config.Alarm{{Time: "07:00", UUID: "3", Enabled: true}}
+
+	updated, _ := m.handleClockTick(time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC))
+	if !updated.(Model).alarmRampAt.IsZero() {
+		t.Error("an alarm should not fire on the first tick after launch")
+	}
+}
+
+func TestModel_IPCSleep(t *testing.T) {
+	m := createTestModel()
+
+	if reply := m.ipcSleep(ipc.SleepArgs{Minutes: 30}); !reply.ok {
+		t.Fatalf("SLEEP 30 failed: %s", reply.err)
+	}
+	if remaining := m.sleepRemaining(time.Now()); remaining < 29*time.Minute || remaining > 30*time.Minute {
+		t.Errorf("sleepRemaining() = %v, want ~30m", remaining)
+	}
+
+	if reply := m.ipcSleep(ipc.SleepArgs{Minutes: 0}); !reply.ok {
+		t.Fatalf("SLEEP OFF failed: %s", reply.err)
+	}
+	if !m.sleepAt.IsZero() {
+		t.Error("SLEEP OFF should cancel the timer")
+	}
+
+	if reply := m.ipcSleep(ipc.SleepArgs{Minutes: -5}); reply.ok || reply.code != ipc.ErrBadRequest {
+		t.Errorf("SLEEP with negative minutes = %+v, want bad_request", reply)
+	}
+}
+
+func TestModel_IPCAlarm_RequiresFavorite(t *testing.T) {
+	m := createTestModel()
+	m.playingUUID = "1"
+
+	_, reply := m.ipcAlarm(ipc.AlarmArgs{Time: "07:30"})
+	if reply.ok {
+		t.Error("ALARM should reject stations that are not favorites")
+	}
+
+	_, reply = m.ipcAlarm(ipc.AlarmArgs{Time: "7am"})
+	if reply.ok {
+		t.Error("ALARM should reject bad times")
+	}
+}
+
+func TestModel_IPCAlarm_Off(t *testing.T) {
+	m := createTestModel()
+	m.alarms = []config.Alarm{{Time: "07:00", UUID: "1", Enabled: true}}
+
+	_, reply := m.ipcAlarm(ipc.AlarmArgs{Off: true})
+	if !reply.ok {
+		t.Fatalf("ALARM OFF failed: %s", reply.err)
+	}
+	if m.alarms[0].Enabled {
+		t.Error("ALARM OFF should disable every alarm")
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{24*time.Minute + 13*time.Second, "24:13"},
+		{59 * time.Second, "0:59"},
+		{time.Hour + 5*time.Minute, "1h05m"},
+	}
+	for _, tt := range tests {
+		if got := formatCountdown(tt.in); got != tt.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -3,9 +3,14 @@ package ui
 import (
 	"fmt"
 	"math"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"radio-tui/internal/config"
+	"radio-tui/internal/radio"
 )
 
 func (m Model) View() string {
@@ -34,7 +39,10 @@ func (m Model) View() string {
 		meta = m.styles.InsetPanel.Width(contentWidth).Render(m.renderStationMeta())
 	}
 
-	keyHints := m.styles.KeyHint.Width(contentWidth).Render(m.renderKeyHints(contentWidth))
+	var keyHints string
+	if m.showKeyHints() {
+		keyHints = m.styles.KeyHint.Width(contentWidth).Render(m.renderKeyHints(contentWidth))
+	}
 
 	var errLine string
 	if m.errMsg != "" {
@@ -50,7 +58,10 @@ func (m Model) View() string {
 	}
 
 	appPadding := 2
-	baseHeight := lipgloss.Height(header) + lipgloss.Height(dial) + lipgloss.Height(meta) + lipgloss.Height(keyHints)
+	baseHeight := lipgloss.Height(header) + lipgloss.Height(dial) + lipgloss.Height(meta)
+	if keyHints != "" {
+		baseHeight += lipgloss.Height(keyHints)
+	}
 	if errLine != "" {
 		baseHeight += lipgloss.Height(errLine)
 	}
@@ -73,7 +84,10 @@ func (m Model) View() string {
 
 	list := m.renderList(contentWidth, listItems)
 
-	sections := []string{header, dial, meta, list, keyHints}
+	sections := []string{header, dial, meta, list}
+	if keyHints != "" {
+		sections = append(sections, keyHints)
+	}
 	if errLine != "" {
 		sections = append(sections, errLine)
 	}
@@ -88,10 +102,38 @@ func (m Model) View() string {
 		help := m.renderHelp()
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, help)
 	}
+	if m.showStats {
+		stats := m.renderStats()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, stats)
+	}
+	if m.showBookmarks {
+		bookmarks := m.renderBookmarks()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, bookmarks)
+	}
 	if m.showTheme {
 		picker := m.renderThemePicker()
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, picker)
 	}
+	if m.showAudio {
+		settings := m.renderAudioSettings()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, settings)
+	}
+	if m.showSleep {
+		dialog := m.renderSleepDialog()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+	}
+	if m.showAlarms {
+		dialog := m.renderAlarmDialog()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+	}
+	if m.showGroups {
+		dialog := m.renderGroupDialog()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+	}
+	if m.showCustom {
+		dialog := m.renderCustomDialog()
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+	}
 	if m.inputMode == inputCountrySelect {
 		selector := m.renderCountrySelect(contentWidth, m.height)
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, selector)
@@ -110,14 +152,20 @@ func (m Model) renderHeader(width int) string {
 
 	left := "VALVE FM"
 	source := strings.ToUpper(m.country)
-	if m.isFavoritesSource() {
+	switch m.stationSource {
+	case sourceFavorites:
 		source = "FAVORITES"
+	case sourceRecent:
+		source = "RECENT"
 	}
 	if width >= 30 {
 		left = fmt.Sprintf("VALVE FM [%s] FM STEREO", source)
 	} else if width >= 20 {
 		left = fmt.Sprintf("VALVE FM [%s]", source)
 	}
+	if remaining := m.sleepRemaining(time.Now()); remaining > 0 {
+		status = fmt.Sprintf("SLEEP %s  %s", formatCountdown(remaining), status)
+	}
 	right := statusStyle.Render(status)
 	line := joinHeader(left, right, width)
 	return m.styles.Header.Width(width).Render(line)
@@ -255,12 +303,19 @@ func (m Model) renderStationMetaCompact(width int, tiny bool) string {
 func (m Model) renderList(width int, maxItems int) string {
 	list := m.visibleStations()
 	header := fmt.Sprintf("Stations (Page %d)", m.page+1)
-	if m.isFavoritesSource() {
-		header = fmt.Sprintf("Favorites (Page %d)", m.page+1)
+	favorites := "Favorites"
+	if group := m.listGroup(); group != "" {
+		favorites = "Favorites: " + group
+	}
+	switch m.stationSource {
+	case sourceFavorites:
+		header = fmt.Sprintf("%s (Page %d)", favorites, m.page+1)
+	case sourceRecent:
+		header = fmt.Sprintf("Recently played (Page %d)", m.page+1)
 	}
 	if strings.TrimSpace(m.activeSearch) != "" {
 		if m.isFavoritesSource() {
-			header = fmt.Sprintf("Favorites Search: %q (Page %d)", m.activeSearch, m.page+1)
+			header = fmt.Sprintf("%s Search: %q (Page %d)", favorites, m.activeSearch, m.page+1)
 		} else {
 			header = fmt.Sprintf("Search: %q (Page %d)", m.activeSearch, m.page+1)
 		}
@@ -269,8 +324,11 @@ func (m Model) renderList(width int, maxItems int) string {
 
 	if m.loading {
 		label := "Loading stations..."
-		if m.isFavoritesSource() {
+		switch m.stationSource {
+		case sourceFavorites:
 			label = "Loading favorites..."
+		case sourceRecent:
+			label = "Loading history..."
 		}
 		lines = append(lines, m.styles.Muted.Render(label))
 		return m.styles.Panel.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
@@ -278,8 +336,11 @@ func (m Model) renderList(width int, maxItems int) string {
 
 	if len(list) == 0 {
 		label := "No stations found"
-		if m.isFavoritesSource() {
+		switch m.stationSource {
+		case sourceFavorites:
 			label = "No favorites found"
+		case sourceRecent:
+			label = "Nothing played yet"
 		}
 		lines = append(lines, m.styles.Muted.Render(label))
 		return m.styles.Panel.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
@@ -294,6 +355,7 @@ func (m Model) renderList(width int, maxItems int) string {
 		lineWidth = width
 	}
 	showFreq := lineWidth >= 32
+	presets := m.presetNumbers()
 
 	for i := start; i < end; i++ {
 		station := list[i]
@@ -305,13 +367,15 @@ func (m Model) renderList(width int, maxItems int) string {
 		}
 
 		fav := ""
-		if m.favorites != nil && m.favorites.IsFavorite(station.UUID) {
+		if n := presets[station.UUID]; n > 0 {
+			fav = fmt.Sprintf(" [%d]", n)
+		} else if m.favorites != nil && m.favorites.IsFavorite(station.UUID) {
 			fav = " *"
 		}
 
 		name := station.Name
 		if showFreq {
-			freq, exact := m.listFrequency(i, len(list), station.Frequency.Float64())
+			freq, exact := m.listFrequency(i, len(list), station)
 			prefix := " "
 			if !exact {
 				prefix = "~"
@@ -334,6 +398,11 @@ func (m Model) renderList(width int, maxItems int) string {
 	return m.styles.Panel.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
 }
 
+// showKeyHints reports whether the key hint line is on, as it is by default.
+func (m Model) showKeyHints() bool {
+	return m.config.UI.KeyHints == nil || *m.config.UI.KeyHints
+}
+
 func (m Model) renderKeyHints(width int) string {
 	vLabel := "V Favorites"
 	if m.isFavoritesSource() {
@@ -348,7 +417,7 @@ func (m Model) renderKeyHints(width int) string {
 	if width < 62 {
 		return "Arrows Tune  Enter Play  Space Stop  [ ] Page  L Country  " + vLabel + "  / Search  T Theme  ? Help  Q Quit"
 	}
-	return "Arrows Tune  Up/Down Browse  Enter Play  Space Stop  [ ] Page  L Country  " + vLabel + "  / Search  F Favorite  T Theme  ? Help  Q Quit"
+	return "Arrows Tune  Up/Down Browse  Enter Play  Space Stop  [ ] Page  L Country  " + vLabel + "  / Search  F Favorite  T Theme  A Audio  Z Sleep  W Alarm  ? Help  Q Quit"
 }
 
 func (m Model) renderHelp() string {
@@ -362,12 +431,32 @@ func (m Model) renderHelp() string {
 		"[ / ]        Previous/Next stations page",
 		"L            Choose country",
 		"V            Toggle favorites / all stations",
+		"R            Toggle recently played / all stations",
+		"S            Listening stats",
+		"b            Bookmark the song playing",
+		"B            Bookmarks (search, copy, export)",
 		"/            Search stations (exits favorites view)",
 		"F            Favorite station",
+		"G            Favorite groups",
+		"Tab          Next favorites group",
+		"1-9          Play preset",
+		"Shift+Up/Dn  Move favorite",
+		"C            Add custom station",
+		"E            Edit custom station",
 		"T            Change theme",
+		"A            Audio output settings",
+		"Z            Sleep timer",
+		"W            Wake-up alarms",
 		"?            Close help",
 		"Q            Quit",
 	}
+	if m.mode == ModeAttached {
+		lines[len(lines)-1] = "Q            Detach (the daemon keeps playing)"
+	}
+	if rebound := reboundHelp(m.config.Keys); len(rebound) > 0 {
+		lines = append(lines, "", "Rebound keys")
+		lines = append(lines, rebound...)
+	}
 	if m.missingPlayer {
 		lines = append(lines, "", "Audio player not found.")
 		if m.downloadingPlayer {
@@ -398,6 +487,197 @@ func (m Model) renderThemePicker() string {
 	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
 }
 
+func (m Model) renderAudioSettings() string {
+	fields := []string{
+		fmt.Sprintf("Sample rate  < %d Hz >", audioSampleRate(m.audioDraft)),
+		fmt.Sprintf("Buffer       < %d ms >", audioBufferMs(m.audioDraft)),
+		fmt.Sprintf("Device       < %s >", truncateText(m.deviceLabel(m.audioDraft.Device), 32)),
+	}
+	lines := []string{
+		m.styles.ListHeader.Render("Audio Output"),
+		"",
+	}
+	for i, field := range fields {
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.audioField {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		lines = append(lines, style.Render(marker+field))
+	}
+	lines = append(lines, "")
+	if m.sampleRatePending() {
+		lines = append(lines, m.styles.Accent.Render("The new sample rate applies after restart"))
+	}
+	if len(m.audioDevices) == 0 {
+		lines = append(lines, m.styles.Muted.Render("mpv lists devices; for ffplay set audio.device in config.json"))
+	} else {
+		lines = append(lines, m.styles.Muted.Render("The device applies to mpv and ffplay"))
+	}
+	lines = append(lines,
+		"",
+		m.styles.Muted.Render("Left/Right change  Enter save  Esc cancel"),
+	)
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m Model) renderSleepDialog() string {
+	lines := []string{
+		m.styles.ListHeader.Render("Sleep Timer"),
+		"",
+	}
+	if remaining := m.sleepRemaining(time.Now()); remaining > 0 {
+		lines = append(lines, m.styles.Meta.Render("Stops in "+formatCountdown(remaining)), "")
+	}
+	for i, minutes := range sleepChoices {
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.sleepIdx {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		label := "Off"
+		if minutes > 0 {
+			label = fmt.Sprintf("%d minutes", minutes)
+		}
+		lines = append(lines, style.Render(marker+label))
+	}
+	lines = append(lines, "", m.styles.Muted.Render("Fades out over the last minute"), m.styles.Muted.Render("Enter set  Esc cancel"))
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m Model) renderAlarmDialog() string {
+	if m.alarmEditing {
+		return m.renderAlarmEditor()
+	}
+
+	lines := []string{
+		m.styles.ListHeader.Render("Alarms"),
+		"",
+	}
+	for i := 0; i <= len(m.alarms); i++ {
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.alarmIdx {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		if i == len(m.alarms) {
+			lines = append(lines, style.Render(marker+"+ New alarm"))
+			continue
+		}
+		alarm := m.alarms[i]
+		state := "off"
+		if alarm.Enabled {
+			state = "on"
+		}
+		label := fmt.Sprintf("%s  %s  [%s]", alarm.Time, truncateText(fallback(alarm.Name, alarm.UUID), 28), state)
+		lines = append(lines, style.Render(marker+label))
+	}
+	lines = append(lines, "", m.styles.Muted.Render("Enter edit  Space on/off  D delete  Esc close"))
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m Model) renderAlarmEditor() string {
+	lines := []string{
+		m.styles.ListHeader.Render("Edit Alarm"),
+		"",
+		m.alarmTime.View(),
+		"",
+		m.styles.Meta.Render("Station:"),
+	}
+	favs := []config.Favorite{}
+	if m.favorites != nil {
+		favs = m.favorites.List()
+	}
+	start, end := listWindow(len(favs), m.alarmStationIdx, 8)
+	for i := start; i < end; i++ {
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.alarmStationIdx {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		lines = append(lines, style.Render(marker+truncateText(favs[i].Name, 32)))
+	}
+	lines = append(lines, "", m.styles.Muted.Render("Up/Down station  Enter save  Esc back"))
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m Model) renderGroupDialog() string {
+	lines := []string{
+		m.styles.ListHeader.Render("Favorite Groups"),
+		"",
+	}
+	if m.groupEditing {
+		title := "New group"
+		if m.groupRenaming != "" {
+			title = "Rename " + truncateText(m.groupRenaming, 24)
+		}
+		lines = append(lines, m.styles.Meta.Render(title), m.groupName.View(), "", m.styles.Muted.Render("Enter save  Esc back"))
+		return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	}
+
+	station := m.groupStation.UUID != "" && m.favorites.IsFavorite(m.groupStation.UUID)
+	if station {
+		lines = append(lines, m.styles.Meta.Render("Station: "+truncateText(m.groupStation.Name, 28)), "")
+	}
+	groups := m.favorites.Groups()
+	for i := 0; i <= len(groups)+1; i++ {
+		marker := "  "
+		style := m.styles.ListItem
+		if i == m.groupIdx {
+			marker = "> "
+			style = m.styles.ListActive
+		}
+		var label string
+		switch {
+		case i == 0:
+			label = fmt.Sprintf("All favorites (%d)", m.favorites.Count())
+		case i > len(groups):
+			label = "+ New group"
+		default:
+			group := groups[i-1]
+			label = fmt.Sprintf("%s (%d)", truncateText(group.Name, 28), len(group.Stations))
+			if station {
+				check := "[ ] "
+				if slices.Contains(group.Stations, m.groupStation.UUID) {
+					check = "[x] "
+				}
+				label = check + label
+			}
+		}
+		lines = append(lines, style.Render(marker+label))
+	}
+	lines = append(lines,
+		"",
+		m.styles.Muted.Render("Enter show  Space add/remove station"),
+		m.styles.Muted.Render("N new  R rename  D delete  Shift+Up/Down move  Esc close"),
+	)
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+func (m Model) renderCustomDialog() string {
+	title := "New Custom Station"
+	if m.customUUID != "" {
+		title = "Edit Custom Station"
+	}
+	lines := []string{
+		m.styles.ListHeader.Render(title),
+		"",
+	}
+	for _, field := range m.customFields {
+		lines = append(lines, field.View())
+	}
+	lines = append(lines,
+		"",
+		m.styles.Muted.Render("Plays the URL directly, without Radio Browser"),
+		m.styles.Muted.Render("Tab next field  Enter save  Esc cancel"),
+	)
+	return m.styles.HelpBox.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
 func (m Model) renderCountrySelect(width int, height int) string {
 	panelWidth := width
 	if panelWidth <= 0 {
@@ -491,9 +771,12 @@ func fallback(value, alt string) string {
 	return value
 }
 
-func (m Model) listFrequency(index, total int, stationFreq float64) (float64, bool) {
-	if stationFreq > 0 {
-		return stationFreq, true
+func (m Model) listFrequency(index, total int, station radio.Station) (float64, bool) {
+	if freq := station.Frequency.Float64(); freq > 0 {
+		return freq, true
+	}
+	if m.stationSource != sourceCountry {
+		return m.favoriteSlot(index, total, station.UUID), false
 	}
 	if total <= 1 {
 		return 98.0, false
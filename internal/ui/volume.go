@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strconv"
+	"time"
+
+	"radio-tui/internal/ipc"
+)
+
+// volumeGlide is how long a volume change takes, so steps do not click.
+const volumeGlide = 150 * time.Millisecond
+
+// volume is the user volume in percent.
+func (m Model) volume() int {
+	return 100 - m.volumeCut
+}
+
+// ipcVolume sets the volume or moves it by a step, within 0-100.
+func (m *Model) ipcVolume(args ipc.VolumeArgs) ipcReply {
+	if !m.canFade() {
+		return ipcError(ipc.ErrUnavailable, "volume control needs the built-in player or mpv")
+	}
+	level := m.volume() + args.Delta
+	if args.Level != nil {
+		level = *args.Level
+	}
+	level = max(0, min(100, level))
+	m.volumeCut = 100 - level
+	m.applyOutputLevel(time.Now(), volumeGlide)
+	return ipcReply{ok: true, data: strconv.Itoa(level), value: m.volumeStatus()}
+}
+
+// ipcMute mutes or unmutes, or toggles without arguments. The volume is
+// kept for unmuting.
+func (m *Model) ipcMute(args *ipc.MuteArgs) ipcReply {
+	if !m.canFade() {
+		return ipcError(ipc.ErrUnavailable, "muting needs the built-in player or mpv")
+	}
+	if args == nil {
+		m.muted = !m.muted
+	} else {
+		m.muted = args.Muted
+	}
+	m.applyOutputLevel(time.Now(), volumeGlide)
+	return ipcReply{ok: true, data: strconv.FormatBool(m.muted), value: m.volumeStatus()}
+}
+
+func (m Model) volumeStatus() ipc.VolumeStatus {
+	return ipc.VolumeStatus{Level: m.volume(), Muted: m.muted}
+}